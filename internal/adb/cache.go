@@ -0,0 +1,129 @@
+package adb
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultVolatileTTL is how long battery level and IP address are reused before being probed again
+const DefaultVolatileTTL = 30 * time.Second
+
+// deviceKey identifies one connection of a device; a reconnect gets a new transport ID
+type deviceKey struct {
+	Serial      string
+	TransportID string
+}
+
+// cachedDeviceInfo holds the extended info last probed for a device connection
+type cachedDeviceInfo struct {
+	info        Device
+	hasStatic   bool
+	volatileAge time.Time // When battery and IP were probed, zero if never
+}
+
+// DeviceInfoCache remembers extended device info between refreshes
+// Static properties (Android version, ABI, API level, screen, AVD name) are kept until the
+// device disconnects; volatile ones (battery, IP address) expire after VolatileTTL
+type DeviceInfoCache struct {
+	VolatileTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[deviceKey]*cachedDeviceInfo
+}
+
+// NewDeviceInfoCache creates an empty cache, using DefaultVolatileTTL if ttl is zero
+func NewDeviceInfoCache(ttl time.Duration) *DeviceInfoCache {
+	if ttl <= 0 {
+		ttl = DefaultVolatileTTL
+	}
+	return &DeviceInfoCache{
+		VolatileTTL: ttl,
+		entries:     make(map[deviceKey]*cachedDeviceInfo),
+	}
+}
+
+// Load fills in the device's extended info, probing only what isn't cached or has expired
+func (c *DeviceInfoCache) Load(ctx context.Context, adbPath string, d *Device) {
+	if d.Status != StatusDevice {
+		return // Only load info for connected devices
+	}
+
+	key := deviceKey{Serial: d.Serial, TransportID: d.TransportID}
+	c.mu.Lock()
+	var entry cachedDeviceInfo
+	if cached, ok := c.entries[key]; ok {
+		entry = *cached
+	}
+	c.mu.Unlock()
+
+	needStatic := !entry.hasStatic
+	needVolatile := entry.volatileAge.IsZero() || time.Since(entry.volatileAge) > c.VolatileTTL
+
+	var probes []shellProbe
+	if needStatic {
+		probes = append(probes, staticInfoProbes...)
+		if d.GetConnectionType() == DeviceTypeEmulator {
+			probes = append(probes, avdNameProbe)
+		}
+	}
+	if needVolatile {
+		probes = append(probes, volatileInfoProbes...)
+	}
+
+	var sections map[string]string
+	if len(probes) > 0 {
+		sections = runProbes(ctx, adbPath, d.Serial, probes)
+	}
+
+	if needStatic {
+		d.applyStaticInfo(ctx, sections)
+		// Only remember static info that was actually read, not the result of a timeout
+		if _, ok := sections[probeSDK]; ok {
+			entry.info.copyStaticInfo(*d)
+			entry.hasStatic = true
+		}
+	} else {
+		d.copyStaticInfo(entry.info)
+	}
+
+	if needVolatile {
+		d.applyVolatileInfo(sections)
+		if _, ok := sections[probeBattery]; ok {
+			entry.info.copyVolatileInfo(*d)
+			entry.volatileAge = time.Now()
+		}
+	} else {
+		d.copyVolatileInfo(entry.info)
+	}
+
+	c.mu.Lock()
+	c.entries[key] = &entry
+	c.mu.Unlock()
+}
+
+// LoadAll loads extended info for all devices concurrently, at most workers at a time
+func (c *DeviceInfoCache) LoadAll(ctx context.Context, adbPath string, devices []Device, workers int) {
+	forEachDevice(devices, workers, func(device *Device) {
+		c.Load(ctx, adbPath, device)
+	})
+}
+
+// Invalidate forgets everything cached for the serial, across all of its connections
+func (c *DeviceInfoCache) Invalidate(serial string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if key.Serial == serial {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// HandleEvent drops cached info for devices the tracker reports as gone or no longer usable
+func (c *DeviceInfoCache) HandleEvent(event DeviceChangeEvent) {
+	if event.Type == DeviceRemoved || event.Status != StatusDevice {
+		c.Invalidate(event.Serial)
+	}
+}
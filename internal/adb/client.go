@@ -0,0 +1,336 @@
+package adb
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultServerAddress is the address the adb server listens on by default
+const DefaultServerAddress = "127.0.0.1:5037"
+
+// Shell protocol v2 packet IDs
+const (
+	shellPacketStdout = 1
+	shellPacketStderr = 2
+	shellPacketExit   = 3
+)
+
+// legacyExitMarker is appended to legacy shell: commands to recover the exit status
+const legacyExitMarker = "\x1fGADGET_EXIT:"
+
+// Client talks the adb host protocol directly to an adb server over TCP
+type Client struct {
+	Address     string
+	DialTimeout time.Duration
+}
+
+// NewClient creates a client for the adb server at the given address
+func NewClient(address string) *Client {
+	return &Client{
+		Address:     address,
+		DialTimeout: 2 * time.Second,
+	}
+}
+
+// ServerError is returned when the adb server answers a request with FAIL
+type ServerError struct {
+	Request string
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("adb server: %s", e.Message)
+}
+
+// ShellExitError is returned when a shell command exits with a non-zero status
+type ShellExitError struct {
+	Code   int
+	Stderr string
+}
+
+func (e *ShellExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ShellResult holds the output of a shell command run through the server
+type ShellResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Version returns the adb server's internal protocol version
+func (c *Client) Version(ctx context.Context) (int, error) {
+	data, err := c.hostQuery(ctx, "host:version")
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := strconv.ParseInt(strings.TrimSpace(data), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid server version %q: %w", data, err)
+	}
+	return int(version), nil
+}
+
+// Devices returns the raw device list in the same format as "adb devices -l" (without the header)
+func (c *Client) Devices(ctx context.Context) (string, error) {
+	return c.hostQuery(ctx, "host:devices-l")
+}
+
+// TrackDevices opens a host:track-devices stream
+// The server sends the full device list, length-prefixed, every time it changes
+func (c *Client) TrackDevices(ctx context.Context) (io.ReadCloser, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := exchange(conn, "host:track-devices"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Shell runs a command on the device with the given serial
+// It prefers the shell v2 protocol, which carries stderr and the exit code,
+// and falls back to the legacy shell: service on devices that don't support it
+func (c *Client) Shell(ctx context.Context, serial, command string) (*ShellResult, error) {
+	result, err := c.shellV2(ctx, serial, command)
+	var serverErr *ServerError
+	if err == nil || !errors.As(err, &serverErr) || !strings.HasPrefix(serverErr.Request, "shell,v2") {
+		return result, contextError(ctx, err)
+	}
+
+	result, err = c.shellLegacy(ctx, serial, command)
+	return result, contextError(ctx, err)
+}
+
+// hostQuery sends a host service request and returns its length-prefixed payload
+func (c *Client) hostQuery(ctx context.Context, request string) (string, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if err := exchange(conn, request); err != nil {
+		return "", contextError(ctx, err)
+	}
+	payload, err := readLengthPrefixed(conn)
+	return payload, contextError(ctx, err)
+}
+
+// openTransport dials the server and switches the connection to the given device
+func (c *Client) openTransport(ctx context.Context, serial string) (net.Conn, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := exchange(conn, "host:transport:"+serial); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// openService opens a device service such as shell: on the given device
+func (c *Client) openService(ctx context.Context, serial, service string) (net.Conn, error) {
+	conn, err := c.openTransport(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := exchange(conn, service); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *Client) shellV2(ctx context.Context, serial, command string) (*ShellResult, error) {
+	conn, err := c.openService(ctx, serial, "shell,v2,raw:"+command)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var stdout, stderr bytes.Buffer
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return nil, fmt.Errorf("shell stream ended without exit status: %w", err)
+		}
+
+		payload := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return nil, fmt.Errorf("failed to read shell packet: %w", err)
+		}
+
+		switch header[0] {
+		case shellPacketStdout:
+			stdout.Write(payload)
+		case shellPacketStderr:
+			stderr.Write(payload)
+		case shellPacketExit:
+			exitCode := 0
+			if len(payload) > 0 {
+				exitCode = int(payload[0])
+			}
+			return newShellResult(stdout.String(), stderr.String(), exitCode)
+		}
+	}
+}
+
+func (c *Client) shellLegacy(ctx context.Context, serial, command string) (*ShellResult, error) {
+	conn, err := c.openService(ctx, serial, fmt.Sprintf("shell:%s; echo \"%s$?\"", command, legacyExitMarker))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	output, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shell output: %w", err)
+	}
+
+	stdout := string(output)
+	markerIndex := strings.LastIndex(stdout, legacyExitMarker)
+	if markerIndex == -1 {
+		return nil, fmt.Errorf("shell output ended without exit status")
+	}
+
+	exitCode, err := strconv.Atoi(strings.TrimSpace(stdout[markerIndex+len(legacyExitMarker):]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid shell exit status: %w", err)
+	}
+
+	return newShellResult(stdout[:markerIndex], "", exitCode)
+}
+
+// newShellResult wraps shell output and turns non-zero exit codes into errors like exec does
+func newShellResult(stdout, stderr string, exitCode int) (*ShellResult, error) {
+	result := &ShellResult{Stdout: stdout, Stderr: stderr, ExitCode: exitCode}
+	if exitCode != 0 {
+		return result, &ShellExitError{Code: exitCode, Stderr: stderr}
+	}
+	return result, nil
+}
+
+// dial connects to the server; the connection is closed as soon as ctx is done,
+// which unblocks any pending read or write
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: c.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.Address)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(ctx, err)
+		}
+		return nil, &ConnectionError{Address: c.Address, Err: err}
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	return &contextConn{Conn: conn, stop: stop}, nil
+}
+
+// contextConn releases its context watcher when closed
+type contextConn struct {
+	net.Conn
+	stop func() bool
+}
+
+func (c *contextConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// ConnectionError is returned when the adb server can't be reached
+type ConnectionError struct {
+	Address string
+	Err     error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("failed to connect to adb server at %s: %v", e.Address, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// exchange sends a request and waits for the server to acknowledge it
+func exchange(conn net.Conn, request string) error {
+	if err := sendRequest(conn, request); err != nil {
+		return err
+	}
+
+	err := readStatus(conn)
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		serverErr.Request = request
+	}
+	return err
+}
+
+// sendRequest writes a request prefixed with its length as 4 hex digits
+func sendRequest(w io.Writer, request string) error {
+	if len(request) > 0xffff {
+		return fmt.Errorf("adb request too long: %d bytes", len(request))
+	}
+	if _, err := fmt.Fprintf(w, "%04x%s", len(request), request); err != nil {
+		return fmt.Errorf("failed to send adb request: %w", err)
+	}
+	return nil
+}
+
+// readStatus reads an OKAY/FAIL status, returning the server message as an error on FAIL
+func readStatus(r io.Reader) error {
+	status := make([]byte, 4)
+	if _, err := io.ReadFull(r, status); err != nil {
+		return fmt.Errorf("failed to read adb status: %w", err)
+	}
+
+	switch string(status) {
+	case "OKAY":
+		return nil
+	case "FAIL":
+		message, err := readLengthPrefixed(r)
+		if err != nil {
+			return err
+		}
+		return &ServerError{Message: message}
+	default:
+		return fmt.Errorf("unexpected adb status: %q", status)
+	}
+}
+
+// readLengthPrefixed reads a payload prefixed with its length as 4 hex digits
+func readLengthPrefixed(r io.Reader) (string, error) {
+	lengthHex := make([]byte, 4)
+	if _, err := io.ReadFull(r, lengthHex); err != nil {
+		return "", fmt.Errorf("failed to read adb payload length: %w", err)
+	}
+
+	length, err := strconv.ParseUint(string(lengthHex), 16, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid adb payload length %q: %w", lengthHex, err)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", fmt.Errorf("failed to read adb payload: %w", err)
+	}
+	return string(payload), nil
+}
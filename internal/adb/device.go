@@ -2,10 +2,14 @@ package adb
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"gadget/internal/display"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Device represents an ADB device
@@ -27,6 +31,13 @@ type Device struct {
 	AVDName         string // Emulator AVD name if device is an emulator
 }
 
+// ProbeTimeout bounds the shell invocation that loads extended device info,
+// so an unresponsive device can't stall device listing
+const ProbeTimeout = 5 * time.Second
+
+// DefaultLoadWorkers is how many devices have their extended info loaded at once
+const DefaultLoadWorkers = 4
+
 // DeviceConnectionType represents the type of device connection
 type DeviceConnectionType int
 
@@ -48,7 +59,15 @@ func (d Device) GetConnectionType() DeviceConnectionType {
 }
 
 // GetStatusIndicator returns a colored status indicator for the device
+// Devices that can't take commands get a status icon instead of a connection type icon
 func (d Device) GetStatusIndicator() string {
+	switch d.Status {
+	case StatusUnauthorized, StatusAuthorizing:
+		return "🔒"
+	case StatusOffline:
+		return "🔌"
+	}
+
 	switch d.GetConnectionType() {
 	case DeviceTypeEmulator:
 		return "🖥️" // Yellow dot for emulators
@@ -61,6 +80,20 @@ func (d Device) GetStatusIndicator() string {
 	}
 }
 
+// GetStatusHint explains what an unauthorized or offline device needs, "" for other devices
+func (d Device) GetStatusHint() string {
+	switch d.Status {
+	case StatusUnauthorized:
+		return "Unauthorized: accept the \"Allow USB debugging?\" prompt on the device"
+	case StatusAuthorizing:
+		return "Authorizing: waiting for the device to finish authorization"
+	case StatusOffline:
+		return "Offline: replug the device, reconnect it or restart the adb server"
+	default:
+		return ""
+	}
+}
+
 // String returns a formatted string representation of the device
 func (d Device) String() string {
 	// Check if this is an emulator
@@ -99,23 +132,38 @@ func (d Device) String() string {
 
 // GetConnectedDevices returns a list of connected ADB devices
 func GetConnectedDevices(adbPath string) ([]Device, error) {
-	cmd := execCommand(adbPath, "devices", "-l")
-	output, err := cmd.Output()
+	return GetConnectedDevicesContext(context.Background(), adbPath)
+}
+
+// GetConnectedDevicesContext is like GetConnectedDevices but gives up when ctx is done
+func GetConnectedDevicesContext(ctx context.Context, adbPath string) ([]Device, error) {
+	if client := serverClient(); client != nil {
+		output, err := client.Devices(ctx)
+		if err == nil {
+			return parseDeviceList(output), nil
+		}
+		if !shouldFallBackToBinary(err) {
+			return nil, fmt.Errorf("failed to get devices: %w", err)
+		}
+	}
+
+	output, err := runCommand(ctx, adbPath, "devices", "-l")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get devices: %w", err)
 	}
 
-	var devices []Device
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	return parseDeviceList(output), nil
+}
 
-	// Skip the header line "List of devices attached"
-	if scanner.Scan() {
-		// Skip header
-	}
+// parseDeviceList parses the output of adb devices -l or the host:devices-l service
+func parseDeviceList(output string) []Device {
+	var devices []Device
+	scanner := bufio.NewScanner(strings.NewReader(output))
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
+		// Skip the "List of devices attached" header and "* daemon started" notices
+		if line == "" || strings.HasPrefix(line, "List of devices") || strings.HasPrefix(line, "*") {
 			continue
 		}
 
@@ -125,7 +173,7 @@ func GetConnectedDevices(adbPath string) ([]Device, error) {
 		}
 	}
 
-	return devices, nil
+	return devices
 }
 
 // parseDeviceLine parses a single line from adb devices -l output
@@ -141,6 +189,11 @@ func parseDeviceLine(line string) *Device {
 		Status: parts[1],
 	}
 
+	// "no permissions" is the only status containing a space
+	if parts[1] == "no" && len(parts) > 2 && parts[2] == "permissions" {
+		device.Status = StatusNoPermissions
+	}
+
 	// Parse additional properties like model:, product:, etc.
 	for i := 2; i < len(parts); i++ {
 		if strings.HasPrefix(parts[i], "model:") {
@@ -159,49 +212,110 @@ func parseDeviceLine(line string) *Device {
 
 // ExecuteCommand runs an adb command on a specific device
 func ExecuteCommand(adbPath, deviceSerial string, args ...string) error {
+	return ExecuteCommandContext(context.Background(), adbPath, deviceSerial, args...)
+}
+
+// ExecuteCommandContext runs an adb command on a specific device, stopping it when ctx is done
+func ExecuteCommandContext(ctx context.Context, adbPath, deviceSerial string, args ...string) error {
+	if _, handled, err := executeShellViaServer(ctx, deviceSerial, args); handled {
+		return err
+	}
+
 	cmdArgs := []string{"-s", deviceSerial}
 	cmdArgs = append(cmdArgs, args...)
 
-	cmd := execCommand(adbPath, cmdArgs...)
-	return cmd.Run()
+	_, err := runCommand(ctx, adbPath, cmdArgs...)
+	return err
 }
 
 // ExecuteGlobalCommand runs an adb command without targeting a specific device
 func ExecuteGlobalCommand(adbPath string, args ...string) error {
-	cmd := execCommand(adbPath, args...)
-	return cmd.Run()
+	return ExecuteGlobalCommandContext(context.Background(), adbPath, args...)
+}
+
+// ExecuteGlobalCommandContext runs an adb command without targeting a specific device, stopping it when ctx is done
+func ExecuteGlobalCommandContext(ctx context.Context, adbPath string, args ...string) error {
+	_, err := runCommand(ctx, adbPath, args...)
+	return err
 }
 
 // ExecuteGlobalCommandWithOutput runs an adb command without targeting a specific device and returns output
 func ExecuteGlobalCommandWithOutput(adbPath string, args ...string) (string, error) {
-	cmd := execCommand(adbPath, args...)
-	output, err := cmd.Output()
-	return string(output), err
+	return ExecuteGlobalCommandWithOutputContext(context.Background(), adbPath, args...)
+}
+
+// ExecuteGlobalCommandWithOutputContext is like ExecuteGlobalCommandWithOutput but stops the command when ctx is done
+func ExecuteGlobalCommandWithOutputContext(ctx context.Context, adbPath string, args ...string) (string, error) {
+	return runCommand(ctx, adbPath, args...)
 }
 
 // ExecuteCommandWithOutput runs an adb command and returns output
 func ExecuteCommandWithOutput(adbPath, deviceSerial string, args ...string) (string, error) {
+	return ExecuteCommandWithOutputContext(context.Background(), adbPath, deviceSerial, args...)
+}
+
+// ExecuteCommandWithOutputContext is like ExecuteCommandWithOutput but stops the command when ctx is done
+func ExecuteCommandWithOutputContext(ctx context.Context, adbPath, deviceSerial string, args ...string) (string, error) {
+	if output, handled, err := executeShellViaServer(ctx, deviceSerial, args); handled {
+		return output, err
+	}
+
 	cmdArgs := []string{"-s", deviceSerial}
 	cmdArgs = append(cmdArgs, args...)
 
-	cmd := execCommand(adbPath, cmdArgs...)
-	output, err := cmd.Output()
-	return string(output), err
+	return runCommand(ctx, adbPath, cmdArgs...)
 }
 
-// getAVDNameForEmulator tries to find the AVD name for a running emulator
-func getAVDNameForEmulator(adbPath, serial string) string {
+// ExecuteShell runs a shell command on the device and returns its output
+func ExecuteShell(adbPath, deviceSerial string, args ...string) (string, error) {
+	return ExecuteShellContext(context.Background(), adbPath, deviceSerial, args...)
+}
+
+// ExecuteShellContext runs a shell command through the device's persistent shell session,
+// falling back to a one-shot "adb shell" when the device or server can't keep one open
+func ExecuteShellContext(ctx context.Context, adbPath, deviceSerial string, args ...string) (string, error) {
+	if session := shellSessionFor(deviceSerial); session != nil {
+		result, err := session.Run(ctx, strings.Join(args, " "))
+		if !errors.Is(err, errSessionUnavailable) {
+			if result == nil {
+				return "", err
+			}
+			return result.Stdout, err
+		}
+	}
+
+	return ExecuteCommandWithOutputContext(ctx, adbPath, deviceSerial, append([]string{"shell"}, args...)...)
+}
+
+// executeShellViaServer runs "shell ..." invocations through the adb server protocol
+// handled is false when the args aren't a shell command or the server can't be reached
+func executeShellViaServer(ctx context.Context, deviceSerial string, args []string) (output string, handled bool, err error) {
+	client := serverClient()
+	if client == nil || len(args) < 2 || args[0] != "shell" {
+		return "", false, nil
+	}
+
+	// Like the adb binary, join the remaining arguments into a single command line
+	result, err := client.Shell(ctx, deviceSerial, strings.Join(args[1:], " "))
+	if err != nil && shouldFallBackToBinary(err) {
+		return "", false, nil
+	}
+	if result != nil {
+		output = result.Stdout
+	}
+	return output, true, err
+}
+
+// getAVDNameForEmulator resolves the AVD name of a running emulator from its probed
+// ro.boot.qemu.avd_name property, falling back to the emulator console
+func getAVDNameForEmulator(ctx context.Context, avdNameProp, serial string) string {
 	if !strings.HasPrefix(serial, "emulator-") {
 		return ""
 	}
 
-	// Try to get AVD name via adb shell getprop
-	output, err := ExecuteCommandWithOutput(adbPath, serial, "shell", "getprop", "ro.boot.qemu.avd_name")
-	if err == nil {
-		avdName := strings.TrimSpace(output)
-		if avdName != "" && avdName != "unknown" {
-			return avdName
-		}
+	avdName := strings.TrimSpace(avdNameProp)
+	if avdName != "" && avdName != "unknown" {
+		return avdName
 	}
 
 	// Fallback: try console connection (less reliable)
@@ -211,16 +325,16 @@ func getAVDNameForEmulator(adbPath, serial string) string {
 		if consolePort%2 == 1 {
 			consolePort--
 		}
-		return getAVDNameFromConsole(consolePort + 1)
+		return getAVDNameFromConsole(ctx, consolePort+1)
 	}
 
 	return ""
 }
 
 // getAVDNameFromConsole attempts to get AVD name from emulator console
-func getAVDNameFromConsole(consolePort int) string {
+func getAVDNameFromConsole(ctx context.Context, consolePort int) string {
 	// This is a fallback method - less reliable than getprop method
-	cmd := execCommand("nc", "-w", "1", "localhost", fmt.Sprintf("%d", consolePort))
+	cmd := execCommandContext(ctx, "nc", "-w", "1", "localhost", fmt.Sprintf("%d", consolePort))
 	cmd.Stdin = strings.NewReader("avd name\nquit\n")
 	output, err := cmd.Output()
 	if err != nil {
@@ -249,118 +363,160 @@ func GetRunningAVDNames(devices []Device) []string {
 	return avdNames
 }
 
+// LoadAVDNames resolves the AVD names of running emulators without probing anything else,
+// which is all a device selector needs
+func LoadAVDNames(ctx context.Context, adbPath string, devices []Device) {
+	forEachDevice(devices, DefaultLoadWorkers, func(device *Device) {
+		if device.Status != StatusDevice || device.GetConnectionType() != DeviceTypeEmulator || device.AVDName != "" {
+			return
+		}
+		sections := runProbes(ctx, adbPath, device.Serial, []shellProbe{avdNameProbe})
+		device.AVDName = getAVDNameForEmulator(ctx, sections[probeAVDName], device.Serial)
+	})
+}
+
 // LoadExtendedInfo populates battery, Android version, screen resolution, CPU architecture, API level, and IP address for the device
-func (d *Device) LoadExtendedInfo(adbPath string) {
-	if d.Status != "device" {
+// All probes run in a single shell invocation bounded by ProbeTimeout
+func (d *Device) LoadExtendedInfo(ctx context.Context, adbPath string) {
+	if d.Status != StatusDevice {
 		return // Only load info for connected devices
 	}
 
-	// Load battery level
-	if batteryOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "dumpsys", "battery"); err == nil {
-		lines := strings.Split(strings.TrimSpace(batteryOutput), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.Contains(line, "level:") {
-				parts := strings.Split(line, ":")
-				if len(parts) >= 2 {
-					if level, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
-						d.BatteryLevel = level
-						break
-					}
-				}
-			}
-		}
+	probes := append([]shellProbe{}, staticInfoProbes...)
+	if d.GetConnectionType() == DeviceTypeEmulator {
+		probes = append(probes, avdNameProbe)
 	}
-	if d.BatteryLevel == 0 {
-		d.BatteryLevel = -1 // Unknown
+	probes = append(probes, volatileInfoProbes...)
+
+	sections := runProbes(ctx, adbPath, d.Serial, probes)
+	d.applyStaticInfo(ctx, sections)
+	d.applyVolatileInfo(sections)
+}
+
+// LoadExtendedInfoForDevices loads extended info for all devices concurrently, at most workers at a time
+func LoadExtendedInfoForDevices(ctx context.Context, adbPath string, devices []Device, workers int) {
+	forEachDevice(devices, workers, func(device *Device) {
+		device.LoadExtendedInfo(ctx, adbPath)
+	})
+}
+
+// forEachDevice runs fn for every device in parallel, at most workers at a time
+func forEachDevice(devices []Device, workers int, fn func(device *Device)) {
+	if workers < 1 {
+		workers = 1
 	}
 
-	// Load Android version
-	if versionOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "getprop", "ro.build.version.release"); err == nil {
-		d.AndroidVersion = strings.TrimSpace(versionOutput)
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for i := range devices {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(device *Device) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			fn(device)
+		}(&devices[i])
 	}
+	wg.Wait()
+}
 
-	// Load screen resolution
-	if resOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "wm", "size"); err == nil {
-		lines := strings.Split(strings.TrimSpace(resOutput), "\n")
-		for _, line := range lines {
-			if strings.Contains(line, "Physical size:") {
-				parts := strings.Split(line, ":")
-				if len(parts) >= 2 {
-					d.ScreenRes = strings.TrimSpace(parts[1])
-					break
-				}
-			}
-		}
+// applyStaticInfo sets the properties that don't change while the device stays connected
+func (d *Device) applyStaticInfo(ctx context.Context, sections map[string]string) {
+	d.AndroidVersion = strings.TrimSpace(sections[probeRelease])
+	d.ScreenRes = parsePhysicalSize(sections[probeScreenSize])
+	d.CPUArchitecture = strings.TrimSpace(sections[probeABI])
+
+	d.APILevel = -1 // Unknown
+	if apiLevel, err := strconv.Atoi(strings.TrimSpace(sections[probeSDK])); err == nil {
+		d.APILevel = apiLevel
 	}
 
-	// Load CPU architecture
-	if cpuOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "getprop", "ro.product.cpu.abi"); err == nil {
-		d.CPUArchitecture = strings.TrimSpace(cpuOutput)
+	// Load AVD name for emulators
+	if d.GetConnectionType() == DeviceTypeEmulator {
+		d.AVDName = getAVDNameForEmulator(ctx, sections[probeAVDName], d.Serial)
 	}
+}
 
-	// Load API level
-	if apiOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "getprop", "ro.build.version.sdk"); err == nil {
-		if apiLevel, err := strconv.Atoi(strings.TrimSpace(apiOutput)); err == nil {
-			d.APILevel = apiLevel
-		} else {
-			d.APILevel = -1 // Unknown
+// applyVolatileInfo sets the properties that change over time
+func (d *Device) applyVolatileInfo(sections map[string]string) {
+	d.BatteryLevel = parseBatteryLevel(sections[probeBattery])
+	d.IPAddress = d.parseIPAddress(sections[probeIPAddr], sections[probeIfconfig])
+}
+
+// copyStaticInfo copies the static extended properties from another device
+func (d *Device) copyStaticInfo(from Device) {
+	d.AndroidVersion = from.AndroidVersion
+	d.ScreenRes = from.ScreenRes
+	d.CPUArchitecture = from.CPUArchitecture
+	d.APILevel = from.APILevel
+	d.AVDName = from.AVDName
+}
+
+// copyVolatileInfo copies the volatile extended properties from another device
+func (d *Device) copyVolatileInfo(from Device) {
+	d.BatteryLevel = from.BatteryLevel
+	d.IPAddress = from.IPAddress
+}
+
+// parseBatteryLevel extracts the level from dumpsys battery output, -1 if unknown
+func parseBatteryLevel(output string) int {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "level:") {
+			parts := strings.Split(line, ":")
+			if len(parts) >= 2 {
+				if level, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil && level != 0 {
+					return level
+				}
+			}
 		}
-	} else {
-		d.APILevel = -1 // Unknown
 	}
+	return -1 // Unknown
+}
 
-	// Load IP address - try multiple methods
-	d.loadIPAddress(adbPath)
+// parsePhysicalSize extracts the physical size from wm size output
+func parsePhysicalSize(output string) string {
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.Contains(line, "Physical size:") {
+			parts := strings.Split(line, ":")
+			if len(parts) >= 2 {
+				return strings.TrimSpace(parts[1])
+			}
+		}
+	}
+	return ""
+}
 
-	// Load AVD name for emulators
-	if d.GetConnectionType() == DeviceTypeEmulator {
-		d.AVDName = getAVDNameForEmulator(adbPath, d.Serial)
-	}
-}
-
-// loadIPAddress attempts to get the device's IP address using various methods
-func (d *Device) loadIPAddress(adbPath string) {
-	// Method 1: Try to get WiFi IP address from wlan0 interface
-	if ipOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "ip", "addr", "show", "wlan0"); err == nil {
-		lines := strings.Split(strings.TrimSpace(ipOutput), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.Contains(line, "inet ") && !strings.Contains(line, "127.0.0.1") {
-				// Extract IP from line like "inet 192.168.1.100/24 brd 192.168.1.255 scope global wlan0"
-				parts := strings.Fields(line)
-				for i, part := range parts {
-					if part == "inet" && i+1 < len(parts) {
-						ipWithMask := parts[i+1]
-						if slashIndex := strings.Index(ipWithMask, "/"); slashIndex != -1 {
-							d.IPAddress = ipWithMask[:slashIndex]
-						} else {
-							d.IPAddress = ipWithMask
-						}
-						return
+// parseIPAddress finds the device's IP address using various methods
+func (d *Device) parseIPAddress(ipAddrOutput, ifconfigOutput string) string {
+	// Method 1: WiFi IP address from the wlan0 interface
+	for _, line := range strings.Split(strings.TrimSpace(ipAddrOutput), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, "inet ") && !strings.Contains(line, "127.0.0.1") {
+			// Extract IP from line like "inet 192.168.1.100/24 brd 192.168.1.255 scope global wlan0"
+			parts := strings.Fields(line)
+			for i, part := range parts {
+				if part == "inet" && i+1 < len(parts) {
+					ipWithMask := parts[i+1]
+					if slashIndex := strings.Index(ipWithMask, "/"); slashIndex != -1 {
+						return ipWithMask[:slashIndex]
 					}
+					return ipWithMask
 				}
 			}
 		}
 	}
 
-	// Method 2: Try using ifconfig (fallback for older devices)
-	if ipOutput, err := ExecuteCommandWithOutput(adbPath, d.Serial, "shell", "ifconfig", "wlan0"); err == nil {
-		lines := strings.Split(strings.TrimSpace(ipOutput), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.Contains(line, "inet addr:") {
-				// Extract IP from line like "inet addr:192.168.1.100  Bcast:192.168.1.255  Mask:255.255.255.0"
-				if startIndex := strings.Index(line, "inet addr:"); startIndex != -1 {
-					remaining := line[startIndex+10:] // Skip "inet addr:"
-					if spaceIndex := strings.Index(remaining, " "); spaceIndex != -1 {
-						d.IPAddress = remaining[:spaceIndex]
-					} else {
-						d.IPAddress = remaining
-					}
-					return
-				}
+	// Method 2: ifconfig (fallback for older devices)
+	for _, line := range strings.Split(strings.TrimSpace(ifconfigOutput), "\n") {
+		line = strings.TrimSpace(line)
+		// Extract IP from line like "inet addr:192.168.1.100  Bcast:192.168.1.255  Mask:255.255.255.0"
+		if startIndex := strings.Index(line, "inet addr:"); startIndex != -1 {
+			remaining := line[startIndex+10:] // Skip "inet addr:"
+			if spaceIndex := strings.Index(remaining, " "); spaceIndex != -1 {
+				return remaining[:spaceIndex]
 			}
+			return remaining
 		}
 	}
 
@@ -368,9 +524,10 @@ func (d *Device) loadIPAddress(adbPath string) {
 	if d.GetConnectionType() == DeviceTypeWiFi && strings.Contains(d.Serial, ":") {
 		parts := strings.Split(d.Serial, ":")
 		if len(parts) >= 2 {
-			d.IPAddress = parts[0]
+			return parts[0]
 		}
 	}
+	return ""
 }
 
 // GetExtendedInfo returns a formatted string with extended device information
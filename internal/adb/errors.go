@@ -0,0 +1,129 @@
+package adb
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// Failure kinds recognised from adb output; match them with errors.Is
+var (
+	ErrNoDevices       = errors.New("no devices connected")
+	ErrDeviceNotFound  = errors.New("device not found")
+	ErrMultipleDevices = errors.New("more than one device connected")
+	ErrUnauthorized    = errors.New("device unauthorized")
+	ErrOffline         = errors.New("device offline")
+	ErrAdbNotFound     = errors.New("adb not found")
+	ErrTimeout         = errors.New("adb command timed out")
+	ErrVersionMismatch = errors.New("adb server version mismatch")
+)
+
+// CommandError is returned when an adb process fails
+// Kind is the recognised failure, nil when adb's output didn't match a known one
+type CommandError struct {
+	Args   []string
+	Output string // What adb printed about the failure, usually its stderr
+	Kind   error
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	message := firstLine(e.Output)
+	if message == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %s", e.Err, message)
+}
+
+func (e *CommandError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Unwrap exposes the recognised failure kind of the server's message, if any
+func (e *ServerError) Unwrap() error {
+	return classifyOutput(e.Message)
+}
+
+// newCommandError wraps the failure of an adb process with the kind its output describes
+func newCommandError(args []string, stdout, stderr string, err error) *CommandError {
+	output := strings.TrimSpace(stderr)
+	if output == "" {
+		// Some subcommands, e.g. disconnect and connect, report errors on stdout
+		output = strings.TrimSpace(stdout)
+	}
+
+	kind := classifyOutput(output)
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		kind = ErrAdbNotFound
+	}
+	return &CommandError{Args: args, Output: output, Kind: kind, Err: err}
+}
+
+// classifyOutput maps adb and adb server error messages to a failure kind
+func classifyOutput(output string) error {
+	output = strings.ToLower(output)
+	switch {
+	case strings.Contains(output, "doesn't match this client"):
+		return ErrVersionMismatch
+	case strings.Contains(output, "no devices/emulators found"), strings.Contains(output, "no devices found"):
+		return ErrNoDevices
+	case strings.Contains(output, "more than one device"), strings.Contains(output, "more than one emulator"):
+		return ErrMultipleDevices
+	case strings.Contains(output, "unauthorized"), strings.Contains(output, "still authorizing"):
+		return ErrUnauthorized
+	case strings.Contains(output, "device offline"):
+		return ErrOffline
+	case strings.Contains(output, "no such device"),
+		strings.Contains(output, "device not found"),
+		strings.Contains(output, "device '") && strings.Contains(output, "' not found"):
+		return ErrDeviceNotFound
+	default:
+		return nil
+	}
+}
+
+// StatusError returns the failure kind a device's status implies, nil if it accepts commands
+func StatusError(device Device) error {
+	switch device.Status {
+	case StatusUnauthorized, StatusAuthorizing:
+		return fmt.Errorf("%s: %w", device.Serial, ErrUnauthorized)
+	case StatusOffline:
+		return fmt.Errorf("%s: %w", device.Serial, ErrOffline)
+	default:
+		return nil
+	}
+}
+
+// Hint returns advice on how to fix a recognised failure, or "" if there is none
+func Hint(err error) string {
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return "Unlock the device and accept the \"Allow USB debugging?\" RSA key prompt. No prompt? Replug the cable or revoke USB debugging authorizations in Developer options"
+	case errors.Is(err, ErrOffline):
+		return "Replug the cable or run \"adb reconnect offline\"; if that doesn't help, restart the adb server with \"adb kill-server\""
+	case errors.Is(err, ErrNoDevices):
+		return "Connect a device with USB debugging enabled, start an emulator, or connect over WiFi"
+	case errors.Is(err, ErrDeviceNotFound):
+		return "Check the device list; a WiFi device may have disconnected or changed its port"
+	case errors.Is(err, ErrMultipleDevices):
+		return "Pick a device with -device (serial, model, AVD name, or usb, wifi, emulators)"
+	case errors.Is(err, ErrAdbNotFound):
+		return "Install the Android SDK platform-tools and point ANDROID_HOME or ANDROID_SDK_ROOT at the SDK"
+	case errors.Is(err, ErrVersionMismatch):
+		return "Use the adb binary from the same platform-tools release as the server, or restart the server with \"gadget server restart\""
+	case errors.Is(err, ErrTimeout):
+		return "The device stopped responding; check its connection and try again"
+	default:
+		return ""
+	}
+}
+
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(line)
+}
@@ -1,12 +1,17 @@
 package adb
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"os/exec"
 )
 
 // CommandExecutor interface allows dependency injection for testing
 type CommandExecutor interface {
 	Command(name string, arg ...string) *exec.Cmd
+	CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd
 }
 
 // RealCommandExecutor is the production implementation using exec.Command
@@ -16,20 +21,90 @@ func (r *RealCommandExecutor) Command(name string, arg ...string) *exec.Cmd {
 	return exec.Command(name, arg...)
 }
 
+func (r *RealCommandExecutor) CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, arg...)
+}
+
+// ServerCommandExecutor talks to the adb server directly for device listing and shell commands,
+// and spawns the adb binary for everything else (pull, connect, pair, ...)
+type ServerCommandExecutor struct {
+	RealCommandExecutor
+	client *Client
+}
+
+// NewServerCommandExecutor creates an executor backed by the adb server at the given address
+func NewServerCommandExecutor(address string) *ServerCommandExecutor {
+	return &ServerCommandExecutor{client: NewClient(address)}
+}
+
+// Client returns the protocol client used to reach the adb server
+func (s *ServerCommandExecutor) Client() *Client {
+	return s.client
+}
+
 // Global executor that can be replaced in tests
-var globalExecutor CommandExecutor = &RealCommandExecutor{}
+var globalExecutor CommandExecutor = NewServerCommandExecutor(DefaultServerAddress)
 
 // SetCommandExecutor allows tests to inject a fake executor
 func SetCommandExecutor(executor CommandExecutor) {
+	CloseShellSessions()
 	globalExecutor = executor
 }
 
 // ResetCommandExecutor resets to the default real executor
 func ResetCommandExecutor() {
-	globalExecutor = &RealCommandExecutor{}
+	CloseShellSessions()
+	globalExecutor = NewServerCommandExecutor(ServerAddress())
 }
 
 // execCommand is a wrapper that uses the global executor
 func execCommand(name string, arg ...string) *exec.Cmd {
 	return globalExecutor.Command(name, arg...)
 }
+
+// execCommandContext is a wrapper that uses the global executor and kills the process when ctx is done
+func execCommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return globalExecutor.CommandContext(ctx, name, arg...)
+}
+
+// contextError replaces an error caused by cancellation with the context's own error,
+// so callers can tell a timeout or Esc/Ctrl+C apart from a failing command
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+	return fmt.Errorf("adb command interrupted: %w", ctx.Err())
+}
+
+// runCommand runs an adb process against the server in use and returns its stdout;
+// failures become a CommandError classified from what adb printed
+func runCommand(ctx context.Context, adbPath string, args ...string) (string, error) {
+	args = adbArgs(args)
+	var stdout, stderr bytes.Buffer
+	cmd := execCommandContext(ctx, adbPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), contextError(ctx, newCommandError(args, stdout.String(), stderr.String(), err))
+	}
+	return stdout.String(), nil
+}
+
+// serverClient returns the protocol client of the global executor, or nil if it only spawns processes
+func serverClient() *Client {
+	if server, ok := globalExecutor.(interface{ Client() *Client }); ok {
+		return server.Client()
+	}
+	return nil
+}
+
+// shouldFallBackToBinary reports whether a protocol error means the server isn't reachable,
+// in which case the adb binary is used instead (it also starts the server on demand)
+func shouldFallBackToBinary(err error) bool {
+	var connErr *ConnectionError
+	return errors.As(err, &connErr)
+}
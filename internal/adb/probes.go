@@ -0,0 +1,101 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// probeMarker delimits the output of each probe in a batched shell invocation
+const probeMarker = "::gadget-probe:"
+
+// Probe names used as section keys
+const (
+	probeBattery    = "battery"
+	probeRelease    = "release"
+	probeScreenSize = "screen-size"
+	probeABI        = "abi"
+	probeSDK        = "sdk"
+	probeIPAddr     = "ip-addr"
+	probeIfconfig   = "ifconfig"
+	probeAVDName    = "avd-name"
+	probeEnd        = "end"
+)
+
+// shellProbe is a single shell command whose output becomes one section
+type shellProbe struct {
+	Name    string
+	Command string
+}
+
+// staticInfoProbes read properties that don't change while a device stays connected
+var staticInfoProbes = []shellProbe{
+	{probeRelease, "getprop ro.build.version.release"},
+	{probeScreenSize, "wm size"},
+	{probeABI, "getprop ro.product.cpu.abi"},
+	{probeSDK, "getprop ro.build.version.sdk"},
+}
+
+// volatileInfoProbes read properties that change over time
+var volatileInfoProbes = []shellProbe{
+	{probeBattery, "dumpsys battery"},
+	{probeIPAddr, "ip addr show wlan0"},
+	{probeIfconfig, "ifconfig wlan0"},
+}
+
+// avdNameProbe is a static probe only run on emulators
+var avdNameProbe = shellProbe{probeAVDName, "getprop ro.boot.qemu.avd_name"}
+
+// buildProbeScript joins probes into one shell command line, echoing a marker before each section
+// Errors are discarded so a missing tool (e.g. ifconfig) only leaves its section empty
+func buildProbeScript(probes []shellProbe) string {
+	var parts []string
+	for _, probe := range probes {
+		parts = append(parts, fmt.Sprintf("echo %s%s", probeMarker, probe.Name))
+		parts = append(parts, probe.Command+" 2>/dev/null")
+	}
+	parts = append(parts, fmt.Sprintf("echo %s%s", probeMarker, probeEnd))
+	return strings.Join(parts, "; ")
+}
+
+// parseProbeSections splits batched output into per-probe sections keyed by probe name
+func parseProbeSections(output string) map[string]string {
+	sections := make(map[string]string)
+	current := ""
+	var body strings.Builder
+
+	flush := func() {
+		if current != "" && current != probeEnd {
+			sections[current] = body.String()
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, probeMarker) {
+			flush()
+			current = strings.TrimPrefix(trimmed, probeMarker)
+			continue
+		}
+		if current != "" {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+	flush()
+
+	return sections
+}
+
+// runProbes runs all probes on the device in a single shell command bounded by ProbeTimeout,
+// through the device's shell session when it has one
+// Sections of probes that didn't complete are missing from the result
+func runProbes(ctx context.Context, adbPath, serial string, probes []shellProbe) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	// Output is parsed even on error so probes that finished before a timeout still count
+	output, _ := ExecuteShellContext(ctx, adbPath, serial, buildProbeScript(probes))
+	return parseProbeSections(output)
+}
@@ -0,0 +1,177 @@
+package adb
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Selector keywords that pick a whole category of devices
+const (
+	SelectorAll       = "all"
+	SelectorUSB       = "usb"
+	SelectorWiFi      = "wifi"
+	SelectorEmulators = "emulators"
+)
+
+// AmbiguousSelectorError is returned when a selector that must pick one device matches several
+type AmbiguousSelectorError struct {
+	Selector   string
+	Candidates []Device
+}
+
+func (e *AmbiguousSelectorError) Error() string {
+	lines := []string{fmt.Sprintf("%q matches %d devices, be more specific:", e.Selector, len(e.Candidates))}
+	for _, device := range e.Candidates {
+		lines = append(lines, "  "+device.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *AmbiguousSelectorError) Unwrap() error {
+	return ErrMultipleDevices
+}
+
+// NoMatchingDeviceError is returned when no connected device matches a selector
+type NoMatchingDeviceError struct {
+	Selector string
+}
+
+func (e *NoMatchingDeviceError) Error() string {
+	return fmt.Sprintf("no connected device matches %q", e.Selector)
+}
+
+func (e *NoMatchingDeviceError) Unwrap() error {
+	return ErrDeviceNotFound
+}
+
+// MatchDevices returns the devices a selector refers to; an empty selector matches every device
+//
+// A selector is one of:
+//   - a keyword: all, usb, wifi, emulators (or emulator)
+//   - a serial, transport ID, AVD name or model, matched exactly (case-insensitive)
+//   - a glob such as emulator-* matched against serials, AVD names and models
+//   - a substring of a model or AVD name, e.g. pixel
+//
+// Exact matches win over glob and substring matches
+func MatchDevices(devices []Device, selector string) []Device {
+	selector = strings.TrimSpace(selector)
+	if selector == "" {
+		return devices
+	}
+
+	switch strings.ToLower(selector) {
+	case SelectorAll:
+		return devices
+	case SelectorUSB:
+		return filterDevices(devices, func(d Device) bool { return d.GetConnectionType() == DeviceTypePhysical })
+	case SelectorWiFi:
+		return filterDevices(devices, func(d Device) bool { return d.GetConnectionType() == DeviceTypeWiFi })
+	case SelectorEmulators, "emulator":
+		return filterDevices(devices, func(d Device) bool { return d.GetConnectionType() == DeviceTypeEmulator })
+	}
+
+	if exact := filterDevices(devices, func(d Device) bool { return d.matchesExactly(selector) }); len(exact) > 0 {
+		return exact
+	}
+
+	if strings.ContainsAny(selector, "*?[") {
+		return filterDevices(devices, func(d Device) bool { return d.matchesGlob(selector) })
+	}
+
+	needle := normalizeName(selector)
+	return filterDevices(devices, func(d Device) bool {
+		for _, name := range d.selectableNames() {
+			if strings.Contains(normalizeName(name), needle) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SelectDevice resolves a selector that must refer to exactly one device
+// An empty selector is only accepted when a single device is connected
+func SelectDevice(devices []Device, selector string) (Device, error) {
+	matches := MatchDevices(devices, selector)
+	switch {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) == 0 && selector == "":
+		return Device{}, ErrNoDevices
+	case len(matches) == 0:
+		return Device{}, &NoMatchingDeviceError{Selector: selector}
+	default:
+		return Device{}, &AmbiguousSelectorError{Selector: selector, Candidates: matches}
+	}
+}
+
+// IsMultiSelector reports whether a selector asks for several devices on purpose (a keyword or a glob),
+// as opposed to naming one device
+func IsMultiSelector(selector string) bool {
+	switch strings.ToLower(strings.TrimSpace(selector)) {
+	case SelectorAll, SelectorUSB, SelectorWiFi, SelectorEmulators, "emulator":
+		return true
+	}
+	return strings.ContainsAny(selector, "*?[")
+}
+
+// IsExactSerial reports whether the selector is the serial of one of the devices
+func IsExactSerial(devices []Device, selector string) bool {
+	for _, device := range devices {
+		if device.Serial == selector {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesExactly compares the selector against every identifier of the device
+func (d Device) matchesExactly(selector string) bool {
+	if d.Serial == selector || (d.TransportID != "" && d.TransportID == selector) {
+		return true
+	}
+	for _, name := range d.selectableNames() {
+		if normalizeName(name) == normalizeName(selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesGlob matches a shell-style pattern against the serial and names of the device
+func (d Device) matchesGlob(pattern string) bool {
+	candidates := append([]string{d.Serial}, d.selectableNames()...)
+	for _, candidate := range candidates {
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(candidate)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// selectableNames returns the human-readable names a device can be selected by
+func (d Device) selectableNames() []string {
+	var names []string
+	for _, name := range []string{d.Model, d.AVDName} {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// normalizeName makes "Pixel_6", "pixel 6" and "PIXEL-6" compare equal
+func normalizeName(name string) string {
+	return strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(name))
+}
+
+func filterDevices(devices []Device, keep func(Device) bool) []Device {
+	var filtered []Device
+	for _, device := range devices {
+		if keep(device) {
+			filtered = append(filtered, device)
+		}
+	}
+	return filtered
+}
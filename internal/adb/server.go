@@ -0,0 +1,133 @@
+package adb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Default location of the adb server
+const (
+	DefaultServerHost = "127.0.0.1"
+	DefaultServerPort = 5037
+)
+
+// VersionMismatchError is returned when the adb binary and server versions differ
+// Running the binary against such a server makes it kill and restart the server,
+// dropping every device connection, so gadget refuses instead
+type VersionMismatchError struct {
+	Address string
+	Client  int
+	Server  int
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("adb server at %s runs protocol version %d but the adb binary is version %d", e.Address, e.Server, e.Client)
+}
+
+func (e *VersionMismatchError) Unwrap() error {
+	return ErrVersionMismatch
+}
+
+// currentServer is the adb server every command talks to
+var currentServer = struct {
+	sync.RWMutex
+	address string
+	args    []string // -H/-P arguments the adb binary needs to reach it
+}{address: DefaultServerAddress}
+
+// UseServer points gadget at the adb server on host and port; empty or zero values mean the defaults
+// The adb binary is given matching -H and -P arguments so both paths reach the same server
+func UseServer(host string, port int) {
+	if host == "" {
+		host = DefaultServerHost
+	}
+	if port == 0 {
+		port = DefaultServerPort
+	}
+
+	var args []string
+	if host != DefaultServerHost && host != "localhost" {
+		args = append(args, "-H", host)
+	}
+	if port != DefaultServerPort {
+		args = append(args, "-P", strconv.Itoa(port))
+	}
+
+	currentServer.Lock()
+	currentServer.address = net.JoinHostPort(host, strconv.Itoa(port))
+	currentServer.args = args
+	currentServer.Unlock()
+
+	ResetCommandExecutor()
+}
+
+// ServerAddress returns the host:port of the adb server in use
+func ServerAddress() string {
+	currentServer.RLock()
+	defer currentServer.RUnlock()
+	return currentServer.address
+}
+
+// ServerArgs returns the arguments that point the adb binary at the server in use
+func ServerArgs() []string {
+	currentServer.RLock()
+	defer currentServer.RUnlock()
+	return append([]string(nil), currentServer.args...)
+}
+
+// IsRemoteServer reports whether the adb server in use is on another machine
+func IsRemoteServer() bool {
+	host, _, _ := net.SplitHostPort(ServerAddress())
+	return host != DefaultServerHost && host != "localhost" && host != "::1"
+}
+
+// adbArgs prefixes args with the server arguments
+func adbArgs(args []string) []string {
+	return append(ServerArgs(), args...)
+}
+
+var clientVersionPattern = regexp.MustCompile(`Android Debug Bridge version \d+\.\d+\.(\d+)`)
+
+// ClientVersion returns the protocol version of the adb binary, e.g. 41 for 1.0.41
+func ClientVersion(ctx context.Context, adbPath string) (int, error) {
+	output, err := runCommand(ctx, adbPath, "version")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adb version: %w", err)
+	}
+
+	match := clientVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("unrecognised adb version output: %q", firstLine(output))
+	}
+	return strconv.Atoi(match[1])
+}
+
+// ServerVersion returns the protocol version of the adb server in use
+func ServerVersion(ctx context.Context) (int, error) {
+	version, err := NewClient(ServerAddress()).Version(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get adb server version: %w", err)
+	}
+	return version, nil
+}
+
+// CheckServerVersion compares the adb binary and server versions
+// It returns VersionMismatchError if they differ and a ConnectionError if no server is running
+func CheckServerVersion(ctx context.Context, adbPath string) (client, server int, err error) {
+	client, err = ClientVersion(ctx, adbPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	server, err = ServerVersion(ctx)
+	if err != nil {
+		return client, 0, err
+	}
+	if client != server {
+		return client, server, &VersionMismatchError{Address: ServerAddress(), Client: client, Server: server}
+	}
+	return client, server, nil
+}
@@ -0,0 +1,313 @@
+package adb
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// shellPacketStdin is the shell protocol v2 packet ID for data sent to the shell
+const shellPacketStdin = 0
+
+// sessionMarker prefixes the end-of-command markers a session echoes after every command
+const sessionMarker = "::gadget-session:"
+
+// errSessionUnavailable means the device can't host a persistent shell and one-shot commands must be used
+var errSessionUnavailable = errors.New("shell session unavailable")
+
+// ShellSession keeps one shell open on a device and runs commands through it one at a time,
+// saving the connection setup and shell startup every one-shot command pays
+type ShellSession struct {
+	Serial string
+
+	client      *Client
+	token       string // Keeps markers unique across sessions
+	mu          sync.Mutex
+	stream      *shellStream
+	nextID      int
+	unsupported bool // The device has no shell v2, so there's nothing to keep open
+}
+
+// NewShellSession creates a session on the device; the shell is opened on first use
+func NewShellSession(client *Client, serial string) *ShellSession {
+	token := make([]byte, 4)
+	rand.Read(token)
+	return &ShellSession{Serial: serial, client: client, token: hex.EncodeToString(token)}
+}
+
+// Run executes a command line in the session and returns its output like Client.Shell does
+// A shell that went away is reopened first; when ctx is done the shell is closed
+// and the next command starts a fresh one
+func (s *ShellSession) Run(ctx context.Context, command string) (*ShellResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.unsupported {
+		return nil, errSessionUnavailable
+	}
+
+	s.nextID++
+	marker := fmt.Sprintf("%s%s:%d:", sessionMarker, s.token, s.nextID)
+	// A subshell keeps a cd, export or exit in one command from reaching the next
+	script := fmt.Sprintf("( %s\n) </dev/null; echo \"%s$?\"; echo \"%s\" >&2\n", command, marker, marker)
+
+	for attempt := 0; ; attempt++ {
+		if err := s.ensureOpen(ctx); err != nil {
+			return nil, err
+		}
+
+		stream := s.stream
+		stop := context.AfterFunc(ctx, stream.close)
+		result, received, err := stream.run(script, marker)
+		stop()
+		if err == nil {
+			return newShellResult(result.Stdout, result.Stderr, result.ExitCode)
+		}
+
+		s.closeStream()
+		// A shell that died between commands fails before printing anything; nothing ran, so retry once
+		if ctx.Err() == nil && !received && attempt == 0 {
+			continue
+		}
+		return result, contextError(ctx, fmt.Errorf("shell session on %s: %w", s.Serial, err))
+	}
+}
+
+// Close ends the shell; the session reopens it if used again
+func (s *ShellSession) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeStream()
+}
+
+// ensureOpen opens the shell unless a live one is already open
+func (s *ShellSession) ensureOpen(ctx context.Context) error {
+	if s.stream != nil && !s.stream.isDone() {
+		return nil
+	}
+	s.closeStream()
+
+	if err := ctx.Err(); err != nil {
+		return contextError(ctx, err)
+	}
+
+	// The shell outlives the caller's context, so it isn't tied to it
+	conn, err := s.client.openService(context.Background(), s.Serial, "shell,v2,raw:")
+	if err != nil {
+		var serverErr *ServerError
+		if errors.As(err, &serverErr) && strings.HasPrefix(serverErr.Request, "shell,v2") {
+			s.unsupported = true
+		}
+		if errors.As(err, &serverErr) || shouldFallBackToBinary(err) {
+			return errSessionUnavailable
+		}
+		return err
+	}
+
+	s.stream = newShellStream(conn)
+	return nil
+}
+
+func (s *ShellSession) closeStream() {
+	if s.stream != nil {
+		s.stream.close()
+		s.stream = nil
+	}
+}
+
+// shellStream splits a shell v2 connection into stdout and stderr readers
+type shellStream struct {
+	conn   net.Conn
+	stdout *bufio.Reader
+	stderr *bufio.Reader
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newShellStream(conn net.Conn) *shellStream {
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	stream := &shellStream{
+		conn:   conn,
+		stdout: bufio.NewReader(stdoutReader),
+		stderr: bufio.NewReader(stderrReader),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		err := demuxShellPackets(conn, stdoutWriter, stderrWriter)
+		stdoutWriter.CloseWithError(err)
+		stderrWriter.CloseWithError(err)
+		close(stream.done)
+	}()
+	return stream
+}
+
+// run sends a script and reads stdout and stderr up to the marker it ends with
+// received reports whether any output arrived, i.e. whether the shell started running the script
+func (s *shellStream) run(script, marker string) (result *ShellResult, received bool, err error) {
+	if err := writeShellPacket(s.conn, shellPacketStdin, []byte(script)); err != nil {
+		return nil, false, err
+	}
+
+	// stderr is drained alongside stdout so neither stream can block the other
+	type stderrResult struct {
+		text string
+		err  error
+	}
+	stderrDone := make(chan stderrResult, 1)
+	go func() {
+		text, _, err := readUntilMarker(s.stderr, marker)
+		stderrDone <- stderrResult{text, err}
+	}()
+
+	stdout, exitStatus, err := readUntilMarker(s.stdout, marker)
+	stderr := <-stderrDone
+
+	result = &ShellResult{Stdout: stdout, Stderr: stderr.text}
+	received = stdout != "" || stderr.text != "" || exitStatus != ""
+	if err == nil {
+		err = stderr.err
+	}
+	if err != nil {
+		return result, received, err
+	}
+
+	result.ExitCode, err = strconv.Atoi(exitStatus)
+	if err != nil {
+		return result, received, fmt.Errorf("invalid shell exit status %q: %w", exitStatus, err)
+	}
+	return result, received, nil
+}
+
+func (s *shellStream) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *shellStream) close() {
+	s.once.Do(func() {
+		s.conn.Close()
+	})
+}
+
+// demuxShellPackets copies shell v2 packets to stdout and stderr until the shell exits
+func demuxShellPackets(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return err
+		}
+
+		payload := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+
+		switch header[0] {
+		case shellPacketStdout:
+			if _, err := stdout.Write(payload); err != nil {
+				return err
+			}
+		case shellPacketStderr:
+			if _, err := stderr.Write(payload); err != nil {
+				return err
+			}
+		case shellPacketExit:
+			return io.EOF
+		}
+	}
+}
+
+// readUntilMarker reads up to the line containing marker, returning the text before it
+// and whatever follows the marker on that line
+func readUntilMarker(r *bufio.Reader, marker string) (before, after string, err error) {
+	var text strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if i := strings.Index(line, marker); i != -1 {
+			text.WriteString(line[:i])
+			return text.String(), strings.TrimSpace(line[i+len(marker):]), nil
+		}
+		text.WriteString(line)
+		if err != nil {
+			return text.String(), "", err
+		}
+	}
+}
+
+// writeShellPacket sends one shell v2 packet
+func writeShellPacket(w io.Writer, id byte, payload []byte) error {
+	packet := make([]byte, 5+len(payload))
+	packet[0] = id
+	binary.LittleEndian.PutUint32(packet[1:], uint32(len(payload)))
+	copy(packet[5:], payload)
+	if _, err := w.Write(packet); err != nil {
+		return fmt.Errorf("failed to write to shell: %w", err)
+	}
+	return nil
+}
+
+// shellSessions holds the open session of every device, keyed by serial
+var shellSessions = struct {
+	sync.Mutex
+	bySerial map[string]*ShellSession
+}{bySerial: make(map[string]*ShellSession)}
+
+// shellSessionFor returns the session of a device, or nil when commands can't go through the server
+func shellSessionFor(serial string) *ShellSession {
+	client := serverClient()
+	if client == nil {
+		return nil
+	}
+
+	shellSessions.Lock()
+	defer shellSessions.Unlock()
+
+	session, ok := shellSessions.bySerial[serial]
+	if !ok || session.client != client {
+		if ok {
+			session.Close()
+		}
+		session = NewShellSession(client, serial)
+		shellSessions.bySerial[serial] = session
+	}
+	return session
+}
+
+// CloseShellSession closes the session of a device, e.g. after it disconnected
+func CloseShellSession(serial string) {
+	shellSessions.Lock()
+	session := shellSessions.bySerial[serial]
+	delete(shellSessions.bySerial, serial)
+	shellSessions.Unlock()
+
+	if session != nil {
+		session.Close()
+	}
+}
+
+// CloseShellSessions closes the sessions of every device
+func CloseShellSessions() {
+	shellSessions.Lock()
+	sessions := shellSessions.bySerial
+	shellSessions.bySerial = make(map[string]*ShellSession)
+	shellSessions.Unlock()
+
+	for _, session := range sessions {
+		session.Close()
+	}
+}
@@ -0,0 +1,427 @@
+package adb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// syncMaxChunk is the largest DATA payload the sync protocol allows
+const syncMaxChunk = 64 * 1024
+
+// FileInfo describes a remote file as reported by the sync STAT and LIST requests
+type FileInfo struct {
+	Name    string
+	Mode    fs.FileMode
+	Size    int64
+	ModTime time.Time
+}
+
+// IsDir reports whether the remote file is a directory
+func (f FileInfo) IsDir() bool {
+	return f.Mode.IsDir()
+}
+
+// ProgressFunc receives the number of bytes transferred so far and the total, -1 if unknown
+type ProgressFunc func(transferred, total int64)
+
+// RemoteFileNotFoundError is returned when a file to pull doesn't exist on the device
+type RemoteFileNotFoundError struct {
+	Serial string
+	Path   string
+}
+
+func (e *RemoteFileNotFoundError) Error() string {
+	return fmt.Sprintf("remote file not found on %s: %s", e.Serial, e.Path)
+}
+
+// SizeMismatchError is returned when a transfer ends with a different size than expected
+type SizeMismatchError struct {
+	Path     string
+	Expected int64
+	Actual   int64
+}
+
+func (e *SizeMismatchError) Error() string {
+	return fmt.Sprintf("size mismatch for %s: expected %d bytes, got %d", e.Path, e.Expected, e.Actual)
+}
+
+// SyncConn is an open file transfer session with a device
+// Requests run one at a time; closing the connection aborts a running transfer
+type SyncConn struct {
+	serial string
+	conn   net.Conn
+}
+
+// OpenSync switches a connection to the device's sync service
+func (c *Client) OpenSync(ctx context.Context, serial string) (*SyncConn, error) {
+	conn, err := c.openService(ctx, serial, "sync:")
+	if err != nil {
+		return nil, err
+	}
+	return &SyncConn{serial: serial, conn: conn}, nil
+}
+
+// Close ends the sync session
+func (s *SyncConn) Close() error {
+	sendSyncRequest(s.conn, "QUIT", "")
+	return s.conn.Close()
+}
+
+// Stat returns information about a remote file, or RemoteFileNotFoundError if it doesn't exist
+func (s *SyncConn) Stat(path string) (*FileInfo, error) {
+	if err := sendSyncRequest(s.conn, "STAT", path); err != nil {
+		return nil, err
+	}
+
+	id, err := readSyncID(s.conn)
+	if err != nil {
+		return nil, err
+	}
+	if id != "STAT" {
+		return nil, fmt.Errorf("unexpected sync response to STAT: %q", id)
+	}
+
+	var header [12]byte
+	if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read sync STAT: %w", err)
+	}
+
+	info := parseSyncFileInfo(filepath.Base(path), header[:])
+	// adbd reports all zeroes for files that don't exist
+	if info.Mode == 0 && info.Size == 0 && info.ModTime.Unix() == 0 {
+		return nil, &RemoteFileNotFoundError{Serial: s.serial, Path: path}
+	}
+	return info, nil
+}
+
+// List returns the entries of a remote directory, excluding "." and ".."
+func (s *SyncConn) List(path string) ([]FileInfo, error) {
+	if err := sendSyncRequest(s.conn, "LIST", path); err != nil {
+		return nil, err
+	}
+
+	var entries []FileInfo
+	for {
+		id, err := readSyncID(s.conn)
+		if err != nil {
+			return nil, err
+		}
+
+		// DENT and DONE are followed by mode, size, mtime and name length
+		var header [16]byte
+		if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+			return nil, fmt.Errorf("failed to read sync LIST entry: %w", err)
+		}
+
+		switch id {
+		case "DONE":
+			return entries, nil
+		case "DENT":
+			name := make([]byte, binary.LittleEndian.Uint32(header[12:]))
+			if _, err := io.ReadFull(s.conn, name); err != nil {
+				return nil, fmt.Errorf("failed to read sync LIST entry: %w", err)
+			}
+			if string(name) == "." || string(name) == ".." {
+				continue
+			}
+			entries = append(entries, *parseSyncFileInfo(string(name), header[:12]))
+		default:
+			return nil, fmt.Errorf("unexpected sync response to LIST: %q", id)
+		}
+	}
+}
+
+// Recv streams a remote file into w, returning the number of bytes received
+// total is only used for progress reporting and may be -1
+func (s *SyncConn) Recv(path string, w io.Writer, total int64, progress ProgressFunc) (int64, error) {
+	if err := sendSyncRequest(s.conn, "RECV", path); err != nil {
+		return 0, err
+	}
+
+	var received int64
+	buffer := make([]byte, syncMaxChunk)
+	for {
+		id, length, err := readSyncHeader(s.conn)
+		if err != nil {
+			return received, err
+		}
+
+		switch id {
+		case "DATA":
+			if length > syncMaxChunk {
+				return received, fmt.Errorf("sync DATA chunk too large: %d bytes", length)
+			}
+			if _, err := io.ReadFull(s.conn, buffer[:length]); err != nil {
+				return received, fmt.Errorf("failed to read sync data: %w", err)
+			}
+			if _, err := w.Write(buffer[:length]); err != nil {
+				return received, err
+			}
+			received += int64(length)
+			if progress != nil {
+				progress(received, total)
+			}
+		case "DONE":
+			return received, nil
+		case "FAIL":
+			return received, s.readFailure(path, length)
+		default:
+			return received, fmt.Errorf("unexpected sync response to RECV: %q", id)
+		}
+	}
+}
+
+// Send uploads r to a remote path with the given permissions and modification time
+// total is only used for progress reporting and may be -1
+func (s *SyncConn) Send(path string, mode fs.FileMode, modTime time.Time, r io.Reader, total int64, progress ProgressFunc) (int64, error) {
+	if err := sendSyncRequest(s.conn, "SEND", fmt.Sprintf("%s,%d", path, mode.Perm()|0100000)); err != nil {
+		return 0, err
+	}
+
+	var sent int64
+	buffer := make([]byte, syncMaxChunk)
+	for {
+		n, err := r.Read(buffer)
+		if n > 0 {
+			if err := writeSyncHeader(s.conn, "DATA", uint32(n)); err != nil {
+				return sent, err
+			}
+			if _, err := s.conn.Write(buffer[:n]); err != nil {
+				return sent, fmt.Errorf("failed to send sync data: %w", err)
+			}
+			sent += int64(n)
+			if progress != nil {
+				progress(sent, total)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sent, err
+		}
+	}
+
+	if err := writeSyncHeader(s.conn, "DONE", uint32(modTime.Unix())); err != nil {
+		return sent, err
+	}
+
+	id, length, err := readSyncHeader(s.conn)
+	if err != nil {
+		return sent, err
+	}
+	switch id {
+	case "OKAY":
+		return sent, nil
+	case "FAIL":
+		return sent, s.readFailure(path, length)
+	default:
+		return sent, fmt.Errorf("unexpected sync response to SEND: %q", id)
+	}
+}
+
+// readFailure turns a sync FAIL message into an error
+func (s *SyncConn) readFailure(path string, length uint32) error {
+	message := make([]byte, length)
+	if _, err := io.ReadFull(s.conn, message); err != nil {
+		return fmt.Errorf("failed to read sync failure: %w", err)
+	}
+	return fmt.Errorf("sync %s on %s: %s", path, s.serial, message)
+}
+
+// PullFile copies a remote file to localPath and checks that the whole file arrived
+// It uses the sync protocol when the adb server is reachable and "adb pull" otherwise
+func PullFile(ctx context.Context, adbPath, serial, remotePath, localPath string, progress ProgressFunc) error {
+	if client := serverClient(); client != nil {
+		err := pullViaServer(ctx, client, serial, remotePath, localPath, progress)
+		if err == nil || !shouldFallBackToBinary(err) {
+			return err
+		}
+	}
+
+	_, err := runCommand(ctx, adbPath, "-s", serial, "pull", remotePath, localPath)
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) && strings.Contains(cmdErr.Output, "does not exist") {
+		return &RemoteFileNotFoundError{Serial: serial, Path: remotePath}
+	}
+	if err != nil {
+		return fmt.Errorf("adb pull failed: %w", err)
+	}
+	return nil
+}
+
+// PushFile copies a local file to remotePath, keeping its permissions and modification time
+// It uses the sync protocol when the adb server is reachable and "adb push" otherwise
+func PushFile(ctx context.Context, adbPath, serial, localPath, remotePath string, progress ProgressFunc) error {
+	if client := serverClient(); client != nil {
+		err := pushViaServer(ctx, client, serial, localPath, remotePath, progress)
+		if err == nil || !shouldFallBackToBinary(err) {
+			return err
+		}
+	}
+
+	if _, err := runCommand(ctx, adbPath, "-s", serial, "push", localPath, remotePath); err != nil {
+		return fmt.Errorf("adb push failed: %w", err)
+	}
+	return nil
+}
+
+// StatFile returns information about a remote file, or RemoteFileNotFoundError if it doesn't exist
+func StatFile(ctx context.Context, serial, remotePath string) (*FileInfo, error) {
+	client := serverClient()
+	if client == nil {
+		return nil, fmt.Errorf("adb server client not available")
+	}
+
+	sync, err := client.OpenSync(ctx, serial)
+	if err != nil {
+		return nil, err
+	}
+	defer sync.Close()
+
+	info, err := sync.Stat(remotePath)
+	return info, contextError(ctx, err)
+}
+
+// pullViaServer downloads to a temporary file that only replaces localPath once complete
+func pullViaServer(ctx context.Context, client *Client, serial, remotePath, localPath string, progress ProgressFunc) error {
+	sync, err := client.OpenSync(ctx, serial)
+	if err != nil {
+		return err
+	}
+	defer sync.Close()
+
+	info, err := sync.Stat(remotePath)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+
+	partPath := localPath + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", partPath, err)
+	}
+
+	received, err := sync.Recv(remotePath, file, info.Size, progress)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && received != info.Size {
+		err = &SizeMismatchError{Path: remotePath, Expected: info.Size, Actual: received}
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return contextError(ctx, err)
+	}
+
+	if err := os.Rename(partPath, localPath); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to save %s: %w", localPath, err)
+	}
+	return nil
+}
+
+// pushViaServer uploads a local file and verifies the remote size afterwards
+func pushViaServer(ctx context.Context, client *Client, serial, localPath, remotePath string, progress ProgressFunc) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", localPath, err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", localPath, err)
+	}
+
+	sync, err := client.OpenSync(ctx, serial)
+	if err != nil {
+		return err
+	}
+	defer sync.Close()
+
+	if _, err := sync.Send(remotePath, stat.Mode(), stat.ModTime(), file, stat.Size(), progress); err != nil {
+		return contextError(ctx, err)
+	}
+
+	info, err := sync.Stat(remotePath)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	if info.Size != stat.Size() {
+		return &SizeMismatchError{Path: remotePath, Expected: stat.Size(), Actual: info.Size}
+	}
+	return nil
+}
+
+// sendSyncRequest writes a sync request: a 4-byte ID, the payload length (little endian) and the payload
+func sendSyncRequest(w io.Writer, id, payload string) error {
+	if err := writeSyncHeader(w, id, uint32(len(payload))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, payload); err != nil {
+		return fmt.Errorf("failed to send sync request: %w", err)
+	}
+	return nil
+}
+
+func writeSyncHeader(w io.Writer, id string, value uint32) error {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], value)
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("failed to send sync request: %w", err)
+	}
+	return nil
+}
+
+func readSyncID(r io.Reader) (string, error) {
+	id := make([]byte, 4)
+	if _, err := io.ReadFull(r, id); err != nil {
+		return "", fmt.Errorf("failed to read sync response: %w", err)
+	}
+	return string(id), nil
+}
+
+func readSyncHeader(r io.Reader) (string, uint32, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", 0, fmt.Errorf("failed to read sync response: %w", err)
+	}
+	return string(header[:4]), binary.LittleEndian.Uint32(header[4:]), nil
+}
+
+// parseSyncFileInfo decodes the mode, size and mtime fields shared by STAT and DENT
+func parseSyncFileInfo(name string, fields []byte) *FileInfo {
+	rawMode := binary.LittleEndian.Uint32(fields[0:])
+	mode := fs.FileMode(rawMode & 0777)
+	switch rawMode & 0170000 {
+	case 0040000:
+		mode |= fs.ModeDir
+	case 0120000:
+		mode |= fs.ModeSymlink
+	}
+
+	return &FileInfo{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(binary.LittleEndian.Uint32(fields[4:])),
+		ModTime: time.Unix(int64(binary.LittleEndian.Uint32(fields[8:])), 0),
+	}
+}
+
+// IsRemoteFileNotFound reports whether err means a remote file doesn't exist
+func IsRemoteFileNotFound(err error) bool {
+	var notFound *RemoteFileNotFoundError
+	return errors.As(err, &notFound)
+}
@@ -2,70 +2,307 @@ package adb
 
 import (
 	"bufio"
+	"context"
 	"fmt"
-	"os/exec"
+	"io"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// Device states reported by the adb server
+const (
+	StatusDevice        = "device"
+	StatusOffline       = "offline"
+	StatusUnauthorized  = "unauthorized"
+	StatusAuthorizing   = "authorizing"
+	StatusRecovery      = "recovery"
+	StatusSideload      = "sideload"
+	StatusBootloader    = "bootloader"
+	StatusConnecting    = "connecting"
+	StatusNoPermissions = "no permissions"
+	StatusDisconnected  = "disconnected"
+)
+
+// DeviceEventType describes what changed about a device
+type DeviceEventType int
+
+const (
+	DeviceAdded DeviceEventType = iota
+	DeviceRemoved
+	DeviceStatusChanged
+)
+
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceAdded:
+		return "added"
+	case DeviceRemoved:
+		return "removed"
+	case DeviceStatusChanged:
+		return "status-changed"
+	default:
+		return "unknown"
+	}
+}
+
 // DeviceChangeEvent represents a device connection/disconnection event
 type DeviceChangeEvent struct {
-	Serial string
-	Status string // "device", "offline", "disconnected"
+	Type           DeviceEventType
+	Serial         string
+	Status         string // New status, StatusDisconnected for removed devices
+	PreviousStatus string // Empty for added devices
+}
+
+// TrackerHealth reports whether the tracker is currently connected to the adb server
+type TrackerHealth struct {
+	Connected  bool
+	Err        error         // Why the last connection ended, if it did
+	RetryIn    time.Duration // Delay before the next reconnect attempt
+	Reconnects int           // Number of times the connection was re-established
+}
+
+// DeviceTracker follows the adb server's track-devices stream and reconnects when it drops
+type DeviceTracker struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	adbPath string
+	events  chan DeviceChangeEvent
+	health  chan TrackerHealth
+	stop    chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	stream io.Closer
+}
+
+// NewDeviceTracker creates a tracker that has not been started yet
+func NewDeviceTracker(adbPath string) *DeviceTracker {
+	return &DeviceTracker{
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		adbPath:        adbPath,
+		events:         make(chan DeviceChangeEvent, 10),
+		health:         make(chan TrackerHealth, 10),
+		stop:           make(chan struct{}),
+	}
 }
 
 // StartDeviceTracking starts monitoring device changes using adb track-devices
-// Returns a channel that emits DeviceChangeEvent when devices connect/disconnect
-func StartDeviceTracking(adbPath string) (<-chan DeviceChangeEvent, error) {
-	eventChan := make(chan DeviceChangeEvent, 10)
+// The returned tracker keeps running, reconnecting as needed, until Stop is called
+func StartDeviceTracking(adbPath string) *DeviceTracker {
+	tracker := NewDeviceTracker(adbPath)
+	tracker.Start()
+	return tracker
+}
+
+// Events returns the channel of device changes
+func (t *DeviceTracker) Events() <-chan DeviceChangeEvent {
+	return t.events
+}
 
-	cmd := exec.Command(adbPath, "track-devices")
+// Health returns the channel of connection state changes
+func (t *DeviceTracker) Health() <-chan TrackerHealth {
+	return t.health
+}
+
+// Start begins tracking in the background
+func (t *DeviceTracker) Start() {
+	go t.run()
+}
+
+// Stop ends tracking and closes the event channels
+func (t *DeviceTracker) Stop() {
+	t.once.Do(func() {
+		close(t.stop)
+		t.mu.Lock()
+		if t.stream != nil {
+			t.stream.Close()
+		}
+		t.mu.Unlock()
+	})
+}
+
+func (t *DeviceTracker) run() {
+	defer close(t.events)
+	defer close(t.health)
+
+	known := make(map[string]string)
+	backoff := t.InitialBackoff
+	reconnects := 0
+
+	for attempt := 0; ; attempt++ {
+		stream, err := openTrackDevicesStream(t.adbPath)
+		if err == nil {
+			if attempt > 0 {
+				reconnects++
+			}
+			if !t.setStream(stream) {
+				stream.Close()
+				return
+			}
+			t.sendHealth(TrackerHealth{Connected: true, Reconnects: reconnects})
+			backoff = t.InitialBackoff
+
+			err = t.readFrames(stream, known)
+			t.setStream(nil)
+			stream.Close()
+		}
+
+		select {
+		case <-t.stop:
+			return
+		default:
+		}
+
+		t.sendHealth(TrackerHealth{Connected: false, Err: err, RetryIn: backoff, Reconnects: reconnects})
+
+		select {
+		case <-t.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > t.MaxBackoff {
+			backoff = t.MaxBackoff
+		}
+	}
+}
+
+// readFrames decodes length-prefixed device lists until the stream ends
+func (t *DeviceTracker) readFrames(stream io.Reader, known map[string]string) error {
+	reader := bufio.NewReader(stream)
+	for {
+		payload, err := readLengthPrefixed(reader)
+		if err != nil {
+			return err
+		}
+
+		current := parseTrackedDevices(payload)
+		for _, event := range diffDeviceStates(known, current) {
+			select {
+			case t.events <- event:
+			case <-t.stop:
+				return nil
+			}
+		}
+
+		clear(known)
+		for serial, status := range current {
+			known[serial] = status
+		}
+	}
+}
+
+// setStream records the open stream so Stop can interrupt it, returning false if already stopped
+func (t *DeviceTracker) setStream(stream io.Closer) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return false
+	default:
+	}
+	t.stream = stream
+	return true
+}
+
+// sendHealth reports health without blocking the tracker if nobody is listening
+func (t *DeviceTracker) sendHealth(health TrackerHealth) {
+	select {
+	case t.health <- health:
+	default:
+	}
+}
+
+// openTrackDevicesStream opens a track-devices stream via the server protocol or the adb binary
+func openTrackDevicesStream(adbPath string) (io.ReadCloser, error) {
+	if client := serverClient(); client != nil {
+		stream, err := client.TrackDevices(context.Background())
+		if err == nil || !shouldFallBackToBinary(err) {
+			return stream, err
+		}
+	}
+
+	cmd := execCommand(adbPath, adbArgs([]string{"track-devices"})...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		close(eventChan)
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
-
 	if err := cmd.Start(); err != nil {
-		close(eventChan)
 		return nil, fmt.Errorf("failed to start adb track-devices: %w", err)
 	}
+	return &processStream{ReadCloser: stdout, stop: func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}}, nil
+}
 
-	go func() {
-		defer close(eventChan)
-		defer cmd.Process.Kill() // Cleanup when goroutine exits
+// processStream closes a track-devices stream by stopping the adb process behind it
+type processStream struct {
+	io.ReadCloser
+	stop func()
+	once sync.Once
+}
 
-		scanner := bufio.NewScanner(stdout)
+func (p *processStream) Close() error {
+	p.once.Do(p.stop)
+	return nil
+}
 
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
+// parseTrackedDevices parses a track-devices payload: one "SERIAL\tSTATUS" line per device
+func parseTrackedDevices(payload string) map[string]string {
+	devices := make(map[string]string)
+	for _, line := range strings.Split(payload, "\n") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) < 2 {
+			continue
+		}
 
-			// Parse device status line: "SERIAL\tSTATUS"
-			parts := strings.Split(line, "\t")
-			if len(parts) >= 2 {
-				serial := strings.TrimSpace(parts[0])
-				status := strings.TrimSpace(parts[1])
-
-				// Send event if device status is meaningful
-				if serial != "" && (status == "device" || status == "offline" || status == "disconnected") {
-					select {
-					case eventChan <- DeviceChangeEvent{Serial: serial, Status: status}:
-					case <-time.After(100 * time.Millisecond):
-						// Skip if channel is full to prevent blocking
-					}
-				}
-			}
+		serial := strings.TrimSpace(parts[0])
+		if serial == "" {
+			continue
 		}
+		devices[serial] = normalizeStatus(strings.TrimSpace(parts[1]))
+	}
+	return devices
+}
+
+// normalizeStatus strips the udev hint adb appends to "no permissions"
+func normalizeStatus(status string) string {
+	if strings.HasPrefix(status, StatusNoPermissions) {
+		return StatusNoPermissions
+	}
+	return status
+}
+
+// diffDeviceStates returns the events that turn the previous device states into the current ones
+func diffDeviceStates(previous, current map[string]string) []DeviceChangeEvent {
+	var events []DeviceChangeEvent
 
-		// Handle scanner errors
-		if err := scanner.Err(); err != nil {
-			// Don't send error events to avoid noise, just log internally
-			// The tracking will be restarted by the caller if needed
+	for serial, status := range current {
+		oldStatus, existed := previous[serial]
+		switch {
+		case !existed:
+			events = append(events, DeviceChangeEvent{Type: DeviceAdded, Serial: serial, Status: status})
+		case oldStatus != status:
+			events = append(events, DeviceChangeEvent{Type: DeviceStatusChanged, Serial: serial, Status: status, PreviousStatus: oldStatus})
 		}
-	}()
+	}
+
+	for serial, oldStatus := range previous {
+		if _, exists := current[serial]; !exists {
+			events = append(events, DeviceChangeEvent{Type: DeviceRemoved, Serial: serial, Status: StatusDisconnected, PreviousStatus: oldStatus})
+		}
+	}
 
-	return eventChan, nil
+	// Keep event order stable across runs
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Serial < events[j].Serial
+	})
+	return events
 }
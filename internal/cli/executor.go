@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/commands"
@@ -10,14 +12,17 @@ import (
 	"gadget/internal/logger"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 // CommandExecutor defines the signature for command execution functions
-type CommandExecutor func(cfg *config.Config, deviceSerial, ip, code, value string) error
+type CommandExecutor func(ctx context.Context, cfg *config.Config, deviceSerial, ip, code, value string) error
 
 // NestedCommandExecutor defines the signature for nested command execution functions
-type NestedCommandExecutor func(cfg *config.Config, args []string) error
+type NestedCommandExecutor func(ctx context.Context, cfg *config.Config, args []string) error
 
 // CommandRegistry holds all available commands and their executors
 var CommandRegistry = map[string]CommandExecutor{
@@ -27,18 +32,38 @@ var CommandRegistry = map[string]CommandExecutor{
 	"dpi":                  executeDPI,
 	"font-size":            executeFontSize,
 	"screen-size":          executeScreenSize,
+	"animations":           executeAnimations,
+	"dark-mode":            executeDarkMode,
+	"locale":               executeLocale,
+	"talkback":             executeTalkBack,
+	"high-contrast-text":   executeHighContrastText,
+	"color-inversion":      executeColorInversion,
+	"color-correction":     executeColorCorrection,
+	"remove-animations":    executeRemoveAnimations,
+	"screenshot-locales":   executeScreenshotLocales,
 	"launch-emulator":      executeLaunchEmulator,
 	"configure-emulator":   executeConfigureEmulator,
 	"pair-wifi":            executePairWiFi,
 	"connect-wifi":         executeConnectWiFi,
 	"disconnect-wifi":      executeDisconnectWiFi,
 	"refresh-devices":      executeRefreshDevices,
+	"snapshot":             executeSnapshot,
+	"restore":              executeRestore,
+	"revert":               executeRevert,
+	"reset":                executeReset,
 }
 
 // NestedCommandRegistry holds nested commands and their executors
 var NestedCommandRegistry = map[string]NestedCommandExecutor{
-	"wifi":     executeWiFiCommand,
-	"emulator": executeEmulatorCommand,
+	"wifi":           executeWiFiCommand,
+	"emulator":       executeEmulatorCommand,
+	"devices":        executeDevicesCommand,
+	"server":         executeServerCommand,
+	"history":        executeHistoryCommand,
+	"overlay":        executeOverlayCommand,
+	"preset":         executePresetCommand,
+	"emulate-screen": executeEmulateScreenCommand,
+	"window-class":   executeWindowClassCommand,
 }
 
 // ExecuteCommand dispatches a command using the registry
@@ -47,7 +72,13 @@ func ExecuteCommand(cfg *config.Config, command, deviceSerial, ip, code, value s
 	if !exists {
 		return fmt.Errorf("unknown command: %s", command)
 	}
-	return executor(cfg, deviceSerial, ip, code, value)
+
+	ctx, stop := interruptContext()
+	defer stop()
+	defer adb.CloseShellSessions()
+	return runSession(ctx, cfg, func(ctx context.Context) error {
+		return executor(ctx, cfg, deviceSerial, ip, code, value)
+	})
 }
 
 // ExecuteNestedCommand dispatches a nested command using the nested registry
@@ -56,111 +87,222 @@ func ExecuteNestedCommand(cfg *config.Config, command string, args []string) err
 	if !exists {
 		return fmt.Errorf("unknown nested command: %s", command)
 	}
-	return executor(cfg, args)
+
+	ctx, stop := interruptContext()
+	defer stop()
+	defer adb.CloseShellSessions()
+	return runSession(ctx, cfg, func(ctx context.Context) error {
+		return executor(ctx, cfg, args)
+	})
+}
+
+// runSession runs a command; with cfg.NoTrace its setting changes are kept only until Ctrl+C
+func runSession(ctx context.Context, cfg *config.Config, run func(ctx context.Context) error) error {
+	if !cfg.NoTrace {
+		return run(ctx)
+	}
+
+	commands.EnableJournal(cfg)
+	defer commands.DisableJournal()
+	err := run(ctx)
+	if pending, _ := commands.PendingJournals(cfg); err == nil && len(pending) > 0 {
+		logger.Info("Leave no trace: press Ctrl+C to revert the changes and exit")
+		<-ctx.Done()
+	}
+
+	return errors.Join(err, RevertSessionChanges(cfg))
+}
+
+// RevertSessionChanges undoes the setting changes journaled by this and earlier sessions
+// It gets its own context, as the session's is usually cancelled by then
+func RevertSessionChanges(cfg *config.Config) error {
+	ctx, stop := interruptContext()
+	defer stop()
+	return commands.RevertJournals(ctx, cfg)
+}
+
+// interruptContext returns a context cancelled by Ctrl+C or SIGTERM
+// After the first signal default handling is restored, so a second Ctrl+C exits immediately
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+	return ctx, stop
+}
+
+func executeScreenshot(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteScreenshotDirect(ctx, cfg, deviceSerial)
 }
 
-func executeScreenshot(cfg *config.Config, deviceSerial, _, _, _ string) error {
-	return ExecuteScreenshotDirect(cfg, deviceSerial)
+func executeScreenshotDayNight(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteScreenshotDayNightDirect(ctx, cfg, deviceSerial)
 }
 
-func executeScreenshotDayNight(cfg *config.Config, deviceSerial, _, _, _ string) error {
-	return ExecuteScreenshotDayNightDirect(cfg, deviceSerial)
+func executeScreenshotLocales(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteScreenshotLocalesDirect(ctx, cfg, deviceSerial, value)
 }
 
-func executeScreenRecord(cfg *config.Config, deviceSerial, _, _, _ string) error {
-	return ExecuteScreenRecordDirect(cfg, deviceSerial)
+func executeScreenRecord(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteScreenRecordDirect(ctx, cfg, deviceSerial)
 }
 
-func executeDPI(cfg *config.Config, deviceSerial, _, _, value string) error {
-	return ExecuteDPIDirect(cfg, deviceSerial, value)
+func executeDPI(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteDPIDirect(ctx, cfg, deviceSerial, value)
 }
 
-func executeLaunchEmulator(cfg *config.Config, _, _, _, value string) error {
+func executeLaunchEmulator(_ context.Context, cfg *config.Config, _, _, _, value string) error {
 	return ExecuteLaunchEmulatorDirect(cfg, value)
 }
 
-func executeConfigureEmulator(cfg *config.Config, _, _, _, value string) error {
+func executeConfigureEmulator(_ context.Context, cfg *config.Config, _, _, _, value string) error {
 	return ExecuteConfigureEmulatorDirect(cfg, value)
 }
 
-func executePairWiFi(cfg *config.Config, _, ip, code, _ string) error {
+func executePairWiFi(ctx context.Context, cfg *config.Config, _, ip, code, _ string) error {
 	if ip == "" || code == "" {
 		return fmt.Errorf("pair-wifi requires IP address and pairing code")
 	}
-	return commands.PairWiFiDevice(cfg, ip, code)
+	return commands.PairWiFiDevice(ctx, cfg, ip, code)
 }
 
-func executeConnectWiFi(cfg *config.Config, _, ip, _, _ string) error {
+func executeConnectWiFi(ctx context.Context, cfg *config.Config, _, ip, _, _ string) error {
 	if ip == "" {
 		return fmt.Errorf("connect-wifi requires IP address")
 	}
-	return commands.ConnectWiFi(cfg, ip)
+	return commands.ConnectWiFi(ctx, cfg, ip)
 }
 
-func executeDisconnectWiFi(cfg *config.Config, _, ip, _, _ string) error {
+func executeDisconnectWiFi(ctx context.Context, cfg *config.Config, _, ip, _, _ string) error {
 	if ip == "" {
 		return fmt.Errorf("disconnect-wifi requires IP address")
 	}
-	return commands.DisconnectWiFi(cfg, ip)
+	return commands.DisconnectWiFi(ctx, cfg, ip)
 }
 
-func executeRefreshDevices(cfg *config.Config, _, _, _, _ string) error {
-	return ExecuteRefreshDevices(cfg)
+func executeRefreshDevices(ctx context.Context, cfg *config.Config, _, _, _, _ string) error {
+	return ExecuteRefreshDevices(ctx, cfg)
 }
 
 // selectDevice selects a device based on serial, or prompts if multiple devices
-func selectDevice(cfg *config.Config, deviceSerial string) (adb.Device, error) {
-	devices, err := adb.GetConnectedDevices(cfg.GetADBPath())
+func selectDevice(ctx context.Context, cfg *config.Config, selector string) (adb.Device, error) {
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
 	if err != nil {
 		return adb.Device{}, err
 	}
 
 	if len(devices) == 0 {
-		return adb.Device{}, fmt.Errorf("no devices connected")
+		return adb.Device{}, adb.ErrNoDevices
 	}
 
-	if deviceSerial != "" {
-		for _, device := range devices {
-			if device.Serial == deviceSerial {
-				return device, nil
-			}
+	// AVD names aren't part of the device list; only probe for them when the selector could be one
+	if selector != "" && !adb.IsExactSerial(devices, selector) {
+		adb.LoadAVDNames(ctx, cfg.GetADBPath(), devices)
+	}
+
+	device, err := adb.SelectDevice(devices, selector)
+	var ambiguous *adb.AmbiguousSelectorError
+	if errors.As(err, &ambiguous) && selector == "" {
+		logger.Info("Multiple devices connected. Please specify device with -device flag:")
+		for _, device := range ambiguous.Candidates {
+			logger.Info("  %s", device.String())
 		}
-		return adb.Device{}, fmt.Errorf("device with serial %s not found", deviceSerial)
+		logger.Info("-device accepts a serial, transport ID, model or AVD name (substring or glob), or usb, wifi, emulators")
+		return adb.Device{}, fmt.Errorf("%w, please specify -device", adb.ErrMultipleDevices)
+	}
+	if err != nil {
+		return adb.Device{}, err
+	}
+	// Unauthorized and offline devices reject every command; say why up front
+	if err := adb.StatusError(device); err != nil {
+		return adb.Device{}, err
+	}
+	return device, nil
+}
+
+// selectDevices resolves a selector to the devices a command runs on
+// Keywords and globs (all, usb, emulator-*) may pick several devices; anything else must pick exactly one
+func selectDevices(ctx context.Context, cfg *config.Config, selector string) ([]adb.Device, error) {
+	if !adb.IsMultiSelector(selector) {
+		device, err := selectDevice(ctx, cfg, selector)
+		if err != nil {
+			return nil, err
+		}
+		return []adb.Device{device}, nil
 	}
 
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+	if err != nil {
+		return nil, err
+	}
+	adb.LoadAVDNames(ctx, cfg.GetADBPath(), devices)
+
+	var ready []adb.Device
+	for _, device := range adb.MatchDevices(devices, selector) {
+		if device.Status != adb.StatusDevice {
+			logger.Info("Skipping %s (%s)", device.Serial, device.Status)
+			continue
+		}
+		ready = append(ready, device)
+	}
+	if len(ready) == 0 {
+		return nil, &adb.NoMatchingDeviceError{Selector: selector}
+	}
+	return ready, nil
+}
+
+// runOnDevices runs fn on one device directly, or on several in parallel followed by a summary
+func runOnDevices(ctx context.Context, action string, devices []adb.Device, fn func(ctx context.Context, device adb.Device) error) error {
 	if len(devices) == 1 {
-		return devices[0], nil
+		return fn(ctx, devices[0])
 	}
 
-	logger.Info("Multiple devices connected. Please specify device with -device flag:")
-	for _, device := range devices {
-		logger.Info("  %s", device.String())
+	logger.Info("Running %s on %d devices", action, len(devices))
+	results := commands.RunOnDevices(ctx, devices, commands.DefaultDeviceWorkers, fn)
+	return commands.SummarizeResults(action, results)
+}
+
+func ExecuteScreenshotDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
+	if err != nil {
+		return err
 	}
-	return adb.Device{}, fmt.Errorf("multiple devices connected, please specify -device")
+
+	return runOnDevices(ctx, "Screenshot", devices, func(ctx context.Context, device adb.Device) error {
+		logger.Info("Taking screenshot on device: %s", device.Serial)
+		return commands.TakeScreenshot(ctx, cfg, device)
+	})
 }
 
-func ExecuteScreenshotDirect(cfg *config.Config, deviceSerial string) error {
-	device, err := selectDevice(cfg, deviceSerial)
+func ExecuteScreenshotDayNightDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
 	if err != nil {
 		return err
 	}
 
-	logger.Info("Taking screenshot on device: %s", device.Serial)
-	return commands.TakeScreenshot(cfg, device)
+	return runOnDevices(ctx, "Day-night screenshots", devices, func(ctx context.Context, device adb.Device) error {
+		logger.Info("Taking day-night screenshots on device: %s", device.Serial)
+		return commands.TakeDayNightScreenshots(ctx, cfg, device)
+	})
 }
 
-func ExecuteScreenshotDayNightDirect(cfg *config.Config, deviceSerial string) error {
-	device, err := selectDevice(cfg, deviceSerial)
+// ExecuteScreenshotLocalesDirect takes a screenshot per locale, by default in the en-XA and ar-XB pseudo-locales
+func ExecuteScreenshotLocalesDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	locales, err := commands.ParseLocales(value)
 	if err != nil {
 		return err
 	}
 
-	logger.Info("Taking day-night screenshots on device: %s", device.Serial)
-	return commands.TakeDayNightScreenshots(cfg, device)
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
+	if err != nil {
+		return err
+	}
+
+	return runOnDevices(ctx, "Locale screenshots", devices, func(ctx context.Context, device adb.Device) error {
+		return commands.TakeLocaleScreenshots(ctx, cfg, device, locales)
+	})
 }
 
-func ExecuteScreenRecordDirect(cfg *config.Config, deviceSerial string) error {
-	device, err := selectDevice(cfg, deviceSerial)
+func ExecuteScreenRecordDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	device, err := selectDevice(ctx, cfg, deviceSerial)
 	if err != nil {
 		return err
 	}
@@ -173,68 +315,283 @@ func ExecuteScreenRecordDirect(cfg *config.Config, deviceSerial string) error {
 		return err
 	}
 
-	// Wait for interrupt signal
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	<-c
+	// Recording runs until Ctrl+C cancels the context
+	<-ctx.Done()
 
 	logger.Info("\nStopping recording...")
-	return recording.StopAndSave()
+	logger.Info("Press Ctrl+C again to abort saving")
+	saveCtx, stop := interruptContext()
+	defer stop()
+	return recording.StopAndSave(saveCtx)
 }
 
-func ExecuteDPIDirect(cfg *config.Config, deviceSerial, value string) error {
-	return executeSettingCommand(cfg, deviceSerial, value, commands.SettingTypeDPI, "Physical DPI", "Current DPI")
+func ExecuteDPIDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeDPI, "Physical DPI", "Current DPI")
 }
 
 // executeSettingCommand is a generic function for all setting commands
-func executeSettingCommand(cfg *config.Config, deviceSerial, value string, settingType commands.SettingType, defaultLabel, currentLabel string) error {
-	device, err := selectDevice(cfg, deviceSerial)
+func executeSettingCommand(ctx context.Context, cfg *config.Config, deviceSerial, value string, settingType commands.SettingType, defaultLabel, currentLabel string) error {
+	handler := commands.GetSettingHandler(settingType)
+	if value != "" && !cfg.Force {
+		if err := handler.Validate(value); err != nil {
+			if errors.Is(err, commands.ErrValueOutOfRange) {
+				return fmt.Errorf("%w, use --force to apply it anyway", err)
+			}
+			return err
+		}
+	}
+
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
 	if err != nil {
 		return err
 	}
 
-	handler := commands.GetSettingHandler(settingType)
+	err = runOnDevices(ctx, string(settingType), devices, func(ctx context.Context, device adb.Device) error {
+		// Tell devices apart when several report at once
+		prefix := ""
+		if len(devices) > 1 {
+			prefix = device.Serial + ": "
+		}
 
-	// If no value provided, show current setting info
-	if value == "" {
-		info, err := handler.GetInfo(cfg, device)
+		// Set new value, already validated unless forced
+		if value != "" {
+			if err := handler.SetValue(ctx, cfg, device, value); err != nil {
+				return err
+			}
+		}
+
+		// Show setting info, after setting if a value was provided
+		info, err := handler.GetInfo(ctx, cfg, device)
 		if err != nil {
 			return err
 		}
-		logger.Info("%s: %s", defaultLabel, info.Default)
-		logger.Info("%s: %s", currentLabel, info.Current)
+		logger.Info("%s%s: %s", prefix, defaultLabel, info.Default)
+		logger.Info("%s%s: %s", prefix, currentLabel, info.Current)
 		return nil
+	})
+	if err != nil || value != "" {
+		return err
+	}
+
+	metadata := handler.Metadata()
+	if allowed := metadata.Range(); allowed != "" {
+		logger.Info("Allowed: %s", allowed)
+	}
+	logger.Info("Suggested: %s", strings.Join(metadata.Suggestions, ", "))
+	return nil
+}
+
+// resetTargets maps the arguments of reset to the settings they reset
+var resetTargets = map[string][]commands.SettingType{
+	"dpi":         {commands.SettingTypeDPI},
+	"font-size":   {commands.SettingTypeFontSize},
+	"screen-size": {commands.SettingTypeScreenSize},
+	"animations":  {commands.SettingTypeAnimations},
+	"dark-mode":   {commands.SettingTypeNightMode},
+	"locale":      {commands.SettingTypeLocale},
+	"overlays":    {commands.SettingTypeOverlays},
+
+	"talkback":           {commands.SettingTypeTalkBack},
+	"high-contrast-text": {commands.SettingTypeHighContrastText},
+	"color-inversion":    {commands.SettingTypeColorInversion},
+	"color-correction":   {commands.SettingTypeColorCorrection},
+	"accessibility":      commands.AccessibilitySettingTypes,
+
+	"all": commands.SettingTypes,
+}
+
+func executeReset(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteResetDirect(ctx, cfg, deviceSerial, value)
+}
+
+// ExecuteResetDirect clears the overrides of one setting, or of all of them, back to the device defaults
+func ExecuteResetDirect(ctx context.Context, cfg *config.Config, deviceSerial, target string) error {
+	if target == "" {
+		target = "all"
+	}
+	settingTypes, ok := resetTargets[target]
+	if !ok {
+		return fmt.Errorf("unknown setting to reset: %s (expected dpi, font-size, screen-size, animations, dark-mode, locale, overlays, talkback, high-contrast-text, color-inversion, color-correction, accessibility or all)", target)
+	}
+
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
+	if err != nil {
+		return err
+	}
+
+	return runOnDevices(ctx, "Reset", devices, func(ctx context.Context, device adb.Device) error {
+		return commands.ResetSettings(ctx, cfg, device, settingTypes)
+	})
+}
+
+func executeFontSize(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteFontSizeDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteFontSizeDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeFontSize, "Default font size", "Current font size")
+}
+
+func executeScreenSize(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteScreenSizeDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteScreenSizeDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeScreenSize, "Physical screen size", "Current screen size")
+}
+
+func executeAnimations(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteAnimationsDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteAnimationsDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeAnimations, "Default animation scale", "Current animation scale")
+}
+
+func executeDarkMode(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteDarkModeDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteDarkModeDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeNightMode, "Default dark mode", "Current dark mode")
+}
+
+func executeLocale(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteLocaleDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteLocaleDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeLocale, "Default locale", "Current locale")
+}
+
+func executeTalkBack(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteTalkBackDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteTalkBackDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeTalkBack, "Default TalkBack", "Current TalkBack")
+}
+
+func executeHighContrastText(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteHighContrastTextDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteHighContrastTextDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeHighContrastText, "Default high-contrast text", "Current high-contrast text")
+}
+
+func executeColorInversion(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteColorInversionDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteColorInversionDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeColorInversion, "Default color inversion", "Current color inversion")
+}
+
+func executeColorCorrection(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteColorCorrectionDirect(ctx, cfg, deviceSerial, value)
+}
+
+func ExecuteColorCorrectionDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	return executeSettingCommand(ctx, cfg, deviceSerial, value, commands.SettingTypeColorCorrection, "Default color correction", "Current color correction")
+}
+
+func executeRemoveAnimations(ctx context.Context, cfg *config.Config, deviceSerial, _, _, value string) error {
+	return ExecuteRemoveAnimationsDirect(ctx, cfg, deviceSerial, value)
+}
+
+// ExecuteRemoveAnimationsDirect shows whether animations are removed, or removes them or brings them back
+// Unlike the accessibility switches it has no setting of its own: it changes the animation scales
+func ExecuteRemoveAnimationsDirect(ctx context.Context, cfg *config.Config, deviceSerial, value string) error {
+	on := false
+	if value != "" {
+		var err error
+		if on, err = commands.ParseSwitch(value); err != nil {
+			return err
+		}
 	}
 
-	// Set new value (validation happens in SetValue)
-	if err := handler.SetValue(cfg, device, value); err != nil {
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
+	if err != nil {
 		return err
 	}
 
-	// Show setting info after setting
-	info, err := handler.GetInfo(cfg, device)
+	return runOnDevices(ctx, "Remove animations", devices, func(ctx context.Context, device adb.Device) error {
+		if value != "" {
+			if err := commands.SetRemoveAnimations(ctx, cfg, device, on); err != nil {
+				return err
+			}
+		}
+
+		removed, err := commands.GetRemoveAnimations(ctx, cfg, device)
+		if err != nil {
+			return err
+		}
+		prefix, state := "", "off"
+		if len(devices) > 1 {
+			prefix = device.Serial + ": "
+		}
+		if removed {
+			state = "on"
+		}
+		logger.Info("%sAnimations removed: %s", prefix, state)
+		return nil
+	})
+}
+
+func executeSnapshot(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteSnapshotDirect(ctx, cfg, deviceSerial)
+}
+
+func ExecuteSnapshotDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
 	if err != nil {
 		return err
 	}
-	logger.Info("%s: %s", defaultLabel, info.Default)
-	logger.Info("%s: %s", currentLabel, info.Current)
-	return nil
+
+	return runOnDevices(ctx, "Snapshot", devices, func(ctx context.Context, device adb.Device) error {
+		return commands.TakeSnapshot(ctx, cfg, device)
+	})
 }
 
-func executeFontSize(cfg *config.Config, deviceSerial, _, _, value string) error {
-	return ExecuteFontSizeDirect(cfg, deviceSerial, value)
+func executeRestore(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteRestoreDirect(ctx, cfg, deviceSerial)
 }
 
-func ExecuteFontSizeDirect(cfg *config.Config, deviceSerial, value string) error {
-	return executeSettingCommand(cfg, deviceSerial, value, commands.SettingTypeFontSize, "Default font size", "Current font size")
+func ExecuteRestoreDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	devices, err := selectDevices(ctx, cfg, deviceSerial)
+	if err != nil {
+		return err
+	}
+
+	return runOnDevices(ctx, "Restore", devices, func(ctx context.Context, device adb.Device) error {
+		return commands.RestoreSnapshot(ctx, cfg, device)
+	})
 }
 
-func executeScreenSize(cfg *config.Config, deviceSerial, _, _, value string) error {
-	return ExecuteScreenSizeDirect(cfg, deviceSerial, value)
+func executeRevert(ctx context.Context, cfg *config.Config, deviceSerial, _, _, _ string) error {
+	return ExecuteRevertDirect(ctx, cfg, deviceSerial)
 }
 
-func ExecuteScreenSizeDirect(cfg *config.Config, deviceSerial, value string) error {
-	return executeSettingCommand(cfg, deviceSerial, value, commands.SettingTypeScreenSize, "Physical screen size", "Current screen size")
+// ExecuteRevertDirect undoes journaled changes on one device, or on every connected device without a selector
+func ExecuteRevertDirect(ctx context.Context, cfg *config.Config, deviceSerial string) error {
+	if deviceSerial == "" {
+		pending, err := commands.PendingJournals(cfg)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			logger.Info("No changes to revert")
+			return nil
+		}
+		return commands.RevertJournals(ctx, cfg)
+	}
+
+	device, err := selectDevice(ctx, cfg, deviceSerial)
+	if err != nil {
+		return err
+	}
+	return commands.RevertJournal(ctx, cfg, device)
 }
 
 func ExecuteLaunchEmulatorDirect(cfg *config.Config, avdName string) error {
@@ -254,24 +611,465 @@ func ExecuteConfigureEmulatorDirect(cfg *config.Config, avdName string) error {
 	return emulator.OpenConfigInEditor(*avd)
 }
 
-func ExecuteRefreshDevices(cfg *config.Config) error {
-	devices, err := adb.GetConnectedDevices(cfg.GetADBPath())
+func ExecuteRefreshDevices(ctx context.Context, cfg *config.Config) error {
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
 	if err != nil {
 		return err
 	}
 
+	adb.LoadExtendedInfoForDevices(ctx, cfg.GetADBPath(), devices, adb.DefaultLoadWorkers)
+
 	logger.Info("Connected devices: %d", len(devices))
+	needFix := 0
 	for i := range devices {
-		// Load extended info for each device
-		devices[i].LoadExtendedInfo(cfg.GetADBPath())
-
-		formattedInfo := display.FormatExtendedInfoWithIndent(devices[i].String(), devices[i].GetExtendedInfo())
+		info := devices[i].GetExtendedInfo()
+		if hint := devices[i].GetStatusHint(); hint != "" {
+			info = hint
+			needFix++
+		}
+		formattedInfo := display.FormatExtendedInfoWithIndent(devices[i].String(), info)
 		logger.Info("  %s", formattedInfo)
 	}
+	if needFix > 0 {
+		logger.Info("%d devices need attention, run \"gadget devices --fix\" to recover them", needFix)
+	}
+	return nil
+}
+
+func executeDevicesCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return ExecuteRefreshDevices(ctx, cfg)
+	}
+
+	switch args[0] {
+	case "--fix", "-fix", "fix":
+		return commands.FixDevices(ctx, cfg, commands.DefaultFixOptions)
+	case "authorize":
+		device, err := selectUnauthorizedDevice(ctx, cfg, args[1:])
+		if err != nil {
+			return err
+		}
+		if err := commands.RequestAuthorization(ctx, cfg, device); err != nil {
+			return err
+		}
+		logger.Success("Accept the \"Allow USB debugging?\" prompt on %s", device.Serial)
+		return nil
+	case "reconnect-offline":
+		if err := commands.ReconnectOffline(ctx, cfg); err != nil {
+			return err
+		}
+		logger.Success("Reconnected offline devices")
+		return nil
+	case "regenerate-keys":
+		return commands.RegenerateADBKeys(ctx, cfg)
+	case "help", "--help", "-h":
+		logger.Info("Device commands:")
+		logger.Info("  devices                        - List devices with extended info")
+		logger.Info("  devices --fix                  - Reconnect offline devices and re-request authorization")
+		logger.Info("  devices authorize [device]     - Show the USB debugging prompt on a device again")
+		logger.Info("  devices reconnect-offline      - Reconnect devices listed as offline")
+		logger.Info("  devices regenerate-keys        - Generate new host adb keys; every device asks for authorization again")
+		return nil
+	default:
+		return fmt.Errorf("unknown devices subcommand: %s", args[0])
+	}
+}
+
+func executeServerCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		logger.Info("ADB server commands (server: %s):", adb.ServerAddress())
+		logger.Info("  server start                   - Start the adb server")
+		logger.Info("  server stop                    - Stop the adb server, disconnecting every device")
+		logger.Info("  server restart                 - Stop and start the adb server")
+		logger.Info("  server version                 - Compare the adb binary and server versions")
+		logger.Info("")
+		logger.Info("Examples:")
+		logger.Info("  ./gadget server restart")
+		logger.Info("  ./gadget -H buildbox -P 5037 server version")
+		return nil
+	}
+
+	switch args[0] {
+	case "start":
+		if err := commands.StartServer(ctx, cfg); err != nil {
+			return err
+		}
+		logger.Success("adb server running at %s", adb.ServerAddress())
+		return nil
+	case "stop":
+		if err := commands.StopServer(ctx, cfg); err != nil {
+			return err
+		}
+		logger.Success("adb server at %s stopped", adb.ServerAddress())
+		return nil
+	case "restart":
+		if err := commands.RestartServer(ctx, cfg); err != nil {
+			return err
+		}
+		logger.Success("adb server at %s restarted", adb.ServerAddress())
+		return nil
+	case "version":
+		return commands.ShowServerVersion(ctx, cfg)
+	default:
+		return fmt.Errorf("unknown server subcommand: %s", args[0])
+	}
+}
+
+func executeHistoryCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	subcommand := "list"
+	if len(args) > 0 {
+		switch args[0] {
+		case "list", "undo", "redo", "revert", "clear", "help", "--help", "-h":
+			subcommand, args = args[0], args[1:]
+		}
+	}
+
+	if subcommand == "help" || subcommand == "--help" || subcommand == "-h" {
+		logger.Info("Setting history commands:")
+		logger.Info("  history [device]               - List setting changes, oldest first")
+		logger.Info("  history undo [device]          - Revert the last change")
+		logger.Info("  history redo [device]          - Apply the last undone change again")
+		logger.Info("  history revert <n> [device]    - Go back or forward to just after change n; 0 reverts every change")
+		logger.Info("  history clear [device]         - Forget the device's changes")
+		return nil
+	}
+
+	position := 0
+	if subcommand == "revert" {
+		if len(args) == 0 {
+			return fmt.Errorf("history revert requires a change number")
+		}
+		var err error
+		if position, err = strconv.Atoi(args[0]); err != nil {
+			return fmt.Errorf("invalid change number: %s", args[0])
+		}
+		args = args[1:]
+	}
+
+	selector := ""
+	if len(args) > 0 {
+		selector = args[0]
+	}
+	device, err := selectDevice(ctx, cfg, selector)
+	if err != nil {
+		return err
+	}
+
+	switch subcommand {
+	case "undo":
+		change, err := commands.Undo(ctx, cfg, device)
+		if err != nil {
+			return err
+		}
+		logger.Success("Undid %s", change)
+	case "redo":
+		change, err := commands.Redo(ctx, cfg, device)
+		if err != nil {
+			return err
+		}
+		logger.Success("Redid %s", change)
+	case "revert":
+		if err := commands.RevertHistoryTo(ctx, cfg, device, position); err != nil {
+			return err
+		}
+		logger.Success("Reverted %s to change %d", device.Serial, position)
+	case "clear":
+		if err := commands.ClearHistory(cfg, device.Serial); err != nil {
+			return err
+		}
+		logger.Success("Cleared the history of %s", device.Serial)
+		return nil
+	}
+	return showHistory(cfg, device)
+}
+
+// showHistory lists a device's setting changes, marking the ones that were undone
+func showHistory(cfg *config.Config, device adb.Device) error {
+	history, err := commands.LoadHistory(cfg, device.Serial)
+	if err != nil {
+		return err
+	}
+	if len(history.Changes) == 0 {
+		logger.Info("No setting changes recorded on %s", device.Serial)
+		return nil
+	}
+
+	logger.Info("Setting changes on %s:", device.Serial)
+	for i, change := range history.Changes {
+		line := fmt.Sprintf("  %3d  %s  %s", i+1, change.ChangedAt.Format(time.DateTime), change)
+		if i >= history.Position {
+			line += "  (undone)"
+		}
+		logger.Info("%s", line)
+	}
+	return nil
+}
+
+func executeOverlayCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "--help" || args[0] == "-h") {
+		logger.Info("Debug overlay commands:")
+		logger.Info("  overlay [device]                - Show which overlays are on")
+		logger.Info("  overlay <name> on|off [device]  - Show or hide an overlay")
+		logger.Info("")
+		logger.Info("Overlays:")
+		for _, overlay := range commands.DebugOverlays {
+			logger.Info("  %-30s - %s", overlay.Name, overlay.DisplayName)
+		}
+		return nil
+	}
+
+	// Without a name and state the overlays are listed
+	name, on, list := "", false, true
+	switch {
+	case len(args) >= 2 && (args[1] == "on" || args[1] == "off"):
+		if _, err := commands.FindDebugOverlay(args[0]); err != nil {
+			return err
+		}
+		name, on, list = args[0], args[1] == "on", false
+		args = args[2:]
+	case len(args) > 0 && args[0] == "list":
+		args = args[1:]
+	case len(args) > 0:
+		if _, err := commands.FindDebugOverlay(args[0]); err == nil {
+			return fmt.Errorf("overlay %s requires on or off", args[0])
+		}
+	}
+
+	selector := ""
+	if len(args) > 0 {
+		selector = args[0]
+	}
+	devices, err := selectDevices(ctx, cfg, selector)
+	if err != nil {
+		return err
+	}
+
+	return runOnDevices(ctx, "Overlay", devices, func(ctx context.Context, device adb.Device) error {
+		if !list {
+			return commands.SetDebugOverlay(ctx, cfg, device, name, on)
+		}
+
+		enabled, err := commands.GetDebugOverlays(ctx, cfg, device)
+		if err != nil {
+			return err
+		}
+		logger.Info("Debug overlays on %s:", device.Serial)
+		for _, overlay := range commands.DebugOverlays {
+			state := "off"
+			if enabled[overlay.Name] {
+				state = "on"
+			}
+			logger.Info("  %-18s %s", overlay.Name, state)
+		}
+		return nil
+	})
+}
+
+func executePresetCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "--help" || args[0] == "-h" {
+		logger.Info("Preset commands (presets file: %s):", commands.PresetsPath(cfg))
+		logger.Info("  preset list                    - List the presets and their settings")
+		logger.Info("  preset apply <name> [device]   - Apply every setting of a preset")
+		logger.Info("  preset save <name> [device]    - Save a device's current settings as a preset")
+		return nil
+	}
+
+	subcommand, args := args[0], args[1:]
+	switch subcommand {
+	case "list":
+		return listPresets(cfg)
+	case "apply", "save":
+	default:
+		return fmt.Errorf("unknown preset subcommand: %s", subcommand)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("preset %s requires a preset name", subcommand)
+	}
+	name, selector := args[0], ""
+	if len(args) > 1 {
+		selector = args[1]
+	}
+
+	if subcommand == "save" {
+		device, err := selectDevice(ctx, cfg, selector)
+		if err != nil {
+			return err
+		}
+		return commands.SavePreset(ctx, cfg, device, name)
+	}
+
+	// An unknown preset is reported before any device is picked
+	if _, err := commands.FindPreset(cfg, name); err != nil {
+		return err
+	}
+	devices, err := selectDevices(ctx, cfg, selector)
+	if err != nil {
+		return err
+	}
+	err = runOnDevices(ctx, "Preset", devices, func(ctx context.Context, device adb.Device) error {
+		return commands.ApplyPreset(ctx, cfg, device, name)
+	})
+	if errors.Is(err, commands.ErrValueOutOfRange) {
+		return fmt.Errorf("%w, use --force to apply it anyway", err)
+	}
+	return err
+}
+
+func executeEmulateScreenCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "--help" || args[0] == "-h") {
+		logger.Info("Emulate screen commands:")
+		logger.Info("  emulate-screen [list]              - List the devices that can be emulated")
+		logger.Info("  emulate-screen <device> [target]   - Lay out like a device, e.g. emulate-screen \"Pixel 4a\"")
+		logger.Info("  emulate-screen reset [target]      - Go back to the screen size and density from before emulating")
+		return nil
+	}
+	if len(args) == 0 || args[0] == "list" {
+		return listDeviceSpecs()
+	}
+
+	name, selector := args[0], ""
+	if len(args) > 1 {
+		selector = args[1]
+	}
+	if name != "reset" {
+		// An unknown device is reported before any target is picked
+		if _, err := commands.FindDeviceSpec(name); err != nil {
+			return err
+		}
+	}
+	devices, err := selectDevices(ctx, cfg, selector)
+	if err != nil {
+		return err
+	}
+
+	err = runOnDevices(ctx, "Emulate screen", devices, func(ctx context.Context, device adb.Device) error {
+		if name == "reset" {
+			return commands.ResetEmulatedScreen(ctx, cfg, device)
+		}
+		return commands.EmulateScreen(ctx, cfg, device, name)
+	})
+	if errors.Is(err, commands.ErrValueOutOfRange) {
+		return fmt.Errorf("%w, use --force to apply it anyway", err)
+	}
+	return err
+}
+
+func executeWindowClassCommand(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "--help" || args[0] == "-h") {
+		logger.Info("Window class commands:")
+		logger.Info("  window-class [device]                   - Show the dp dimensions and window size classes")
+		logger.Info("  window-class <width> [height] [device]  - Land in a width and height class, e.g. window-class medium compact")
+		logger.Info("  window-class reset [device]             - Go back to the physical screen size and density")
+		logger.Info("")
+		logger.Info("Classes: compact, medium, expanded (width under 600dp, under 840dp, from 840dp; height under 480dp, under 900dp, from 900dp)")
+		return nil
+	}
+
+	// Without classes the current window is shown
+	action, widthClass, heightClass := "show", "", ""
+	switch {
+	case len(args) > 0 && args[0] == "reset":
+		action, args = "reset", args[1:]
+	case len(args) > 0 && commands.IsWindowSizeClass(args[0]):
+		action, widthClass, args = "set", args[0], args[1:]
+		if len(args) > 0 && commands.IsWindowSizeClass(args[0]) {
+			heightClass, args = args[0], args[1:]
+		}
+	}
+
+	selector := ""
+	if len(args) > 0 {
+		selector = args[0]
+	}
+	devices, err := selectDevices(ctx, cfg, selector)
+	if err != nil {
+		return err
+	}
+
+	err = runOnDevices(ctx, "Window class", devices, func(ctx context.Context, device adb.Device) error {
+		switch action {
+		case "reset":
+			return commands.ResetSettings(ctx, cfg, device, []commands.SettingType{commands.SettingTypeScreenSize, commands.SettingTypeDPI})
+		case "set":
+			return commands.SetWindowClass(ctx, cfg, device, widthClass, heightClass)
+		}
+
+		metrics, err := commands.GetWindowMetrics(ctx, cfg, device)
+		if err != nil {
+			return err
+		}
+		logger.Info("%s: %s at %s, %d dpi", device.Serial, metrics, metrics.Size, metrics.Density)
+		return nil
+	})
+	if errors.Is(err, commands.ErrValueOutOfRange) {
+		return fmt.Errorf("%w, use --force to apply it anyway", err)
+	}
+	return err
+}
+
+// listDeviceSpecs shows the device catalog with each screen in pixels and dp
+func listDeviceSpecs() error {
+	logger.Info("Devices:")
+	for _, spec := range commands.DeviceSpecs {
+		dpWidth, dpHeight := spec.DPSize()
+		line := fmt.Sprintf("  %-16s %dx%d px, %d dpi, %dx%d dp", spec.Name, spec.Width, spec.Height, spec.Density, dpWidth, dpHeight)
+		if spec.Cutout != "" {
+			line += fmt.Sprintf(", %s cutout", spec.Cutout)
+		}
+		logger.Info("%s", line)
+	}
 	return nil
 }
 
-func executeWiFiCommand(cfg *config.Config, args []string) error {
+// listPresets shows every preset with its settings, marking the built-in ones
+func listPresets(cfg *config.Config) error {
+	presets, err := commands.LoadPresets(cfg)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Presets:")
+	for _, preset := range presets {
+		line := fmt.Sprintf("  %-16s %s", preset.Name, commands.FormatPresetSettings(preset.Preset))
+		if preset.BuiltIn {
+			line += "  (built-in)"
+		}
+		logger.Info("%s", line)
+		if preset.Description != "" {
+			logger.Info("  %-16s %s", "", preset.Description)
+		}
+	}
+	logger.Info("Changes such as +20%% are from the device default, not the current value")
+	return nil
+}
+
+// selectUnauthorizedDevice picks the device to re-request authorization from,
+// defaulting to the only unauthorized one
+func selectUnauthorizedDevice(ctx context.Context, cfg *config.Config, args []string) (adb.Device, error) {
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+	if err != nil {
+		return adb.Device{}, err
+	}
+
+	selector := ""
+	if len(args) > 0 {
+		selector = args[0]
+	} else {
+		var unauthorized []adb.Device
+		for _, device := range devices {
+			if device.Status == adb.StatusUnauthorized {
+				unauthorized = append(unauthorized, device)
+			}
+		}
+		if len(unauthorized) > 0 {
+			devices = unauthorized
+		}
+	}
+	return adb.SelectDevice(devices, selector)
+}
+
+func executeWiFiCommand(ctx context.Context, cfg *config.Config, args []string) error {
 	if len(args) == 0 {
 		// Show help when no subcommand provided
 		logger.Info("WiFi commands:")
@@ -294,23 +1092,23 @@ func executeWiFiCommand(cfg *config.Config, args []string) error {
 		if len(subArgs) < 2 {
 			return fmt.Errorf("wifi pair requires IP address and pairing code")
 		}
-		return commands.PairWiFiDevice(cfg, subArgs[0], subArgs[1])
+		return commands.PairWiFiDevice(ctx, cfg, subArgs[0], subArgs[1])
 	case "connect":
 		if len(subArgs) < 1 {
 			return fmt.Errorf("wifi connect requires IP address")
 		}
-		return commands.ConnectWiFi(cfg, subArgs[0])
+		return commands.ConnectWiFi(ctx, cfg, subArgs[0])
 	case "disconnect":
 		if len(subArgs) < 1 {
 			return fmt.Errorf("wifi disconnect requires IP address")
 		}
-		return commands.DisconnectWiFi(cfg, subArgs[0])
+		return commands.DisconnectWiFi(ctx, cfg, subArgs[0])
 	default:
 		return fmt.Errorf("unknown wifi subcommand: %s", subcommand)
 	}
 }
 
-func executeEmulatorCommand(cfg *config.Config, args []string) error {
+func executeEmulatorCommand(_ context.Context, cfg *config.Config, args []string) error {
 	if len(args) == 0 {
 		// Show help when no subcommand provided
 		logger.Info("Emulator commands:")
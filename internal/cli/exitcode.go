@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"errors"
+	"gadget/internal/adb"
+	"gadget/internal/commands"
+)
+
+// Exit codes of the CLI, so scripts can react to a failure without parsing its message
+const (
+	ExitOK              = 0
+	ExitFailure         = 1
+	ExitAdbNotFound     = 3
+	ExitNoDevices       = 4
+	ExitDeviceNotFound  = 5
+	ExitMultiple        = 6
+	ExitUnauthorized    = 7
+	ExitOffline         = 8
+	ExitTimeout         = 9
+	ExitVersionMismatch = 10
+	ExitValueOutOfRange = 11
+)
+
+// exitCodes maps failure kinds to exit codes, checked in order
+var exitCodes = []struct {
+	kind error
+	code int
+}{
+	{adb.ErrAdbNotFound, ExitAdbNotFound},
+	{adb.ErrNoDevices, ExitNoDevices},
+	{adb.ErrDeviceNotFound, ExitDeviceNotFound},
+	{adb.ErrMultipleDevices, ExitMultiple},
+	{adb.ErrUnauthorized, ExitUnauthorized},
+	{adb.ErrOffline, ExitOffline},
+	{adb.ErrTimeout, ExitTimeout},
+	{adb.ErrVersionMismatch, ExitVersionMismatch},
+	{commands.ErrValueOutOfRange, ExitValueOutOfRange},
+}
+
+// ExitCode returns the exit code for the error a command finished with
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	for _, entry := range exitCodes {
+		if errors.Is(err, entry.kind) {
+			return entry.code
+		}
+	}
+	return ExitFailure
+}
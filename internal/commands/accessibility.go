@@ -0,0 +1,322 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"strings"
+)
+
+// talkBackService is the TalkBack component in enabled_accessibility_services
+const talkBackService = "com.google.android.marvin.talkback/com.google.android.marvin.talkback.TalkBackService"
+
+// SwitchValues are the values of settings that are either on or off
+var SwitchValues = []string{"on", "off"}
+
+// ColorCorrectionModes maps the color correction modes to their accessibility_display_daltonizer values
+var ColorCorrectionModes = map[string]string{
+	"monochromacy":  "0",
+	"deuteranomaly": "11",
+	"protanomaly":   "12",
+	"tritanomaly":   "13",
+}
+
+// colorCorrectionChoices lists the color correction values, off first
+var colorCorrectionChoices = []string{"off", "deuteranomaly", "protanomaly", "tritanomaly", "monochromacy"}
+
+// accessibilitySwitch is an accessibility feature turned on and off by a secure setting
+type accessibilitySwitch struct {
+	settingType SettingType
+	displayName string
+	key         string
+}
+
+var (
+	highContrastTextSwitch = accessibilitySwitch{SettingTypeHighContrastText, "High-contrast text", "high_text_contrast_enabled"}
+	colorInversionSwitch   = accessibilitySwitch{SettingTypeColorInversion, "Color inversion", "accessibility_display_inversion_enabled"}
+)
+
+func (s accessibilitySwitch) get(ctx context.Context, cfg *config.Config, device adb.Device) (bool, error) {
+	value, err := getSecureSetting(ctx, cfg, device, s.key)
+	if err != nil {
+		return false, fmt.Errorf("failed to get %s: %w", strings.ToLower(s.displayName), err)
+	}
+	return value == "1", nil
+}
+
+func (s accessibilitySwitch) set(ctx context.Context, cfg *config.Config, device adb.Device, on bool) error {
+	if err := recordChange(ctx, device, s.settingType); err != nil {
+		return err
+	}
+	value := "0"
+	if on {
+		value = "1"
+	}
+	if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "put", "secure", s.key, value); err != nil {
+		return fmt.Errorf("failed to set %s to %s: %w", strings.ToLower(s.displayName), formatSwitch(on), err)
+	}
+
+	logger.Success("%s turned %s on device %s", s.displayName, formatSwitch(on), device.Serial)
+	return nil
+}
+
+func (s accessibilitySwitch) reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, s.settingType); err != nil {
+		return err
+	}
+	if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "delete", "secure", s.key); err != nil {
+		return fmt.Errorf("failed to reset %s: %w", strings.ToLower(s.displayName), err)
+	}
+
+	logger.Success("%s reset to off on device %s", s.displayName, device.Serial)
+	return nil
+}
+
+// GetTalkBack returns whether TalkBack is among the enabled accessibility services
+func GetTalkBack(ctx context.Context, cfg *config.Config, device adb.Device) (bool, error) {
+	services, err := getAccessibilityServices(ctx, cfg, device)
+	if err != nil {
+		return false, err
+	}
+	for _, service := range services {
+		if sameComponent(service, talkBackService) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SetTalkBack adds TalkBack to the enabled accessibility services or removes it, keeping the others
+// Nothing is written when TalkBack is already on or off
+func SetTalkBack(ctx context.Context, cfg *config.Config, device adb.Device, on bool) error {
+	services, err := getAccessibilityServices(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	var others []string
+	found := false
+	for _, service := range services {
+		if sameComponent(service, talkBackService) {
+			found = true
+		} else {
+			others = append(others, service)
+		}
+	}
+	if found == on {
+		logger.Success("TalkBack is already %s on device %s", formatSwitch(on), device.Serial)
+		return nil
+	}
+	if err := recordChange(ctx, device, SettingTypeTalkBack); err != nil {
+		return err
+	}
+	if on {
+		others = append(others, talkBackService)
+	}
+
+	adbPath := cfg.GetADBPath()
+	if len(others) == 0 {
+		_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "delete", "secure", "enabled_accessibility_services")
+	} else {
+		_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "secure", "enabled_accessibility_services", strings.Join(others, ":"))
+	}
+	if err == nil {
+		enabled := "0"
+		if len(others) > 0 {
+			enabled = "1"
+		}
+		_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "secure", "accessibility_enabled", enabled)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to turn TalkBack %s: %w", formatSwitch(on), err)
+	}
+
+	logger.Success("TalkBack turned %s on device %s", formatSwitch(on), device.Serial)
+	return nil
+}
+
+// GetColorCorrection returns the color correction mode, or "off"
+func GetColorCorrection(ctx context.Context, cfg *config.Config, device adb.Device) (string, error) {
+	enabled, err := getSecureSetting(ctx, cfg, device, "accessibility_display_daltonizer_enabled")
+	if err != nil {
+		return "", fmt.Errorf("failed to get color correction: %w", err)
+	}
+	if enabled != "1" {
+		return "off", nil
+	}
+
+	daltonizer, err := getSecureSetting(ctx, cfg, device, "accessibility_display_daltonizer")
+	if err != nil {
+		return "", fmt.Errorf("failed to get color correction: %w", err)
+	}
+	if daltonizer == "" {
+		return "deuteranomaly", nil // Android's default mode
+	}
+	for mode, value := range ColorCorrectionModes {
+		if value == daltonizer {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("unknown color correction mode: %s", daltonizer)
+}
+
+// SetColorCorrection turns color correction on in the given mode, or off
+func SetColorCorrection(ctx context.Context, cfg *config.Config, device adb.Device, mode string) error {
+	if _, err := parseColorCorrection(mode); err != nil {
+		return err
+	}
+	if err := recordChange(ctx, device, SettingTypeColorCorrection); err != nil {
+		return err
+	}
+
+	adbPath := cfg.GetADBPath()
+	var err error
+	if mode == "off" {
+		_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "secure", "accessibility_display_daltonizer_enabled", "0")
+	} else {
+		_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "secure", "accessibility_display_daltonizer", ColorCorrectionModes[mode])
+		if err == nil {
+			_, err = adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "secure", "accessibility_display_daltonizer_enabled", "1")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set color correction to %s: %w", mode, err)
+	}
+
+	logger.Success("Color correction changed to %s on device %s", mode, device.Serial)
+	return nil
+}
+
+// ResetColorCorrection deletes the color correction settings, which turns it off
+func ResetColorCorrection(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeColorCorrection); err != nil {
+		return err
+	}
+	for _, key := range []string{"accessibility_display_daltonizer_enabled", "accessibility_display_daltonizer"} {
+		if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "delete", "secure", key); err != nil {
+			return fmt.Errorf("failed to reset color correction: %w", err)
+		}
+	}
+
+	logger.Success("Color correction reset to off on device %s", device.Serial)
+	return nil
+}
+
+// GetRemoveAnimations returns whether every animation scale is off, as "Remove animations" leaves them
+func GetRemoveAnimations(ctx context.Context, cfg *config.Config, device adb.Device) (bool, error) {
+	info, err := GetAnimationScales(ctx, cfg, device)
+	if err != nil {
+		return false, err
+	}
+	for _, scale := range info.Scales {
+		if scale != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SetRemoveAnimations turns every animation scale off, or back to the default
+// It goes through the animations setting, which holds the same scales
+func SetRemoveAnimations(ctx context.Context, cfg *config.Config, device adb.Device, on bool) error {
+	handler := GetSettingHandler(SettingTypeAnimations)
+	if on {
+		return handler.SetValue(ctx, cfg, device, "off")
+	}
+	return handler.Reset(ctx, cfg, device)
+}
+
+// ToggleSetting turns an on or off setting, such as TalkBack, to the other state
+func ToggleSetting(ctx context.Context, cfg *config.Config, device adb.Device, settingType SettingType) error {
+	handler := GetSettingHandler(settingType)
+	info, err := handler.GetInfo(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	value := "on"
+	if info.Current == "on" {
+		value = "off"
+	}
+	return handler.SetValue(ctx, cfg, device, value)
+}
+
+// ToggleRemoveAnimations removes animations, or brings them back if they are removed
+func ToggleRemoveAnimations(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	removed, err := GetRemoveAnimations(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	return SetRemoveAnimations(ctx, cfg, device, !removed)
+}
+
+// getAccessibilityServices returns the enabled accessibility services
+func getAccessibilityServices(ctx context.Context, cfg *config.Config, device adb.Device) ([]string, error) {
+	value, err := getSecureSetting(ctx, cfg, device, "enabled_accessibility_services")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accessibility services: %w", err)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	return strings.Split(value, ":"), nil
+}
+
+// sameComponent reports whether two component names are the same, with the class in full
+// or relative to the package, as in "com.example/.Service"
+func sameComponent(a, b string) bool {
+	return expandComponent(a) == expandComponent(b)
+}
+
+// expandComponent writes a component name with its class in full
+func expandComponent(component string) string {
+	pkg, class, ok := strings.Cut(strings.TrimSpace(component), "/")
+	if !ok {
+		return component
+	}
+	if strings.HasPrefix(class, ".") {
+		class = pkg + class
+	}
+	return pkg + "/" + class
+}
+
+// getSecureSetting reads a secure setting, returning "" for one that isn't set
+func getSecureSetting(ctx context.Context, cfg *config.Config, device adb.Device, key string) (string, error) {
+	output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "get", "secure", key)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(output)
+	if value == "null" {
+		return "", nil
+	}
+	return value, nil
+}
+
+// ParseSwitch reads an on or off value
+func ParseSwitch(value string) (bool, error) {
+	switch value {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid value: %s (expected on or off)", value)
+}
+
+func formatSwitch(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
+
+// parseColorCorrection reads a color correction mode or "off"
+func parseColorCorrection(value string) (string, error) {
+	if _, ok := ColorCorrectionModes[value]; ok || value == "off" {
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid color correction: %s (expected %s)", value, strings.Join(colorCorrectionChoices, ", "))
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AnimationScale is one of the animation scales in the developer options
+type AnimationScale struct {
+	Name string // Short name used in values, e.g. "window=0.5"
+	Key  string // Global setting that holds the scale
+}
+
+// AnimationScales lists the scales the animations setting changes, in the order they are shown
+var AnimationScales = []AnimationScale{
+	{Name: "window", Key: "window_animation_scale"},
+	{Name: "transition", Key: "transition_animation_scale"},
+	{Name: "animator", Key: "animator_duration_scale"},
+}
+
+// defaultAnimationScale is what Android uses for a scale that isn't set
+const defaultAnimationScale = 1.0
+
+type AnimationInfo struct {
+	Scales     map[string]float64 // By short name, unset scales at their default
+	Overridden bool               // Whether any scale is set, rather than left at the system default
+}
+
+func GetAnimationScales(ctx context.Context, cfg *config.Config, device adb.Device) (*AnimationInfo, error) {
+	info := &AnimationInfo{Scales: make(map[string]float64)}
+	for _, scale := range AnimationScales {
+		output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "get", "global", scale.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", scale.Key, err)
+		}
+
+		current := strings.TrimSpace(output)
+		if current == "null" || current == "" {
+			info.Scales[scale.Name] = defaultAnimationScale
+			continue
+		}
+		value, err := strconv.ParseFloat(current, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse %s from output: %s", scale.Key, output)
+		}
+		info.Scales[scale.Name] = value
+		info.Overridden = true
+	}
+	return info, nil
+}
+
+// SetAnimationScales changes the given scales, by short name, and leaves the others alone
+func SetAnimationScales(ctx context.Context, cfg *config.Config, device adb.Device, scales map[string]float64) error {
+	if err := recordChange(ctx, device, SettingTypeAnimations); err != nil {
+		return err
+	}
+
+	for _, scale := range AnimationScales {
+		value, ok := scales[scale.Name]
+		if !ok {
+			continue
+		}
+		formatted := formatScale(value)
+		_, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "put", "global", scale.Key, formatted)
+		if err != nil {
+			return fmt.Errorf("failed to set %s to %s: %w", scale.Key, formatted, err)
+		}
+	}
+
+	logger.Success("Animations changed to %s on device %s", formatAnimationScales(scales), device.Serial)
+	return nil
+}
+
+// ResetAnimationScales deletes all animation scales, back to the system default
+func ResetAnimationScales(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeAnimations); err != nil {
+		return err
+	}
+
+	for _, scale := range AnimationScales {
+		_, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "delete", "global", scale.Key)
+		if err != nil {
+			return fmt.Errorf("failed to reset %s: %w", scale.Key, err)
+		}
+	}
+
+	logger.Success("Animations reset to default on device %s", device.Serial)
+	return nil
+}
+
+// parseAnimationScales reads an animations value: "off", one scale for all of them ("0.5"),
+// or some of them by name ("window=0.5,animator=0")
+func parseAnimationScales(value string) (map[string]float64, error) {
+	scales := make(map[string]float64)
+	if !strings.Contains(value, "=") {
+		scale, ok := parseAnimationScale(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid animation scale: %s (expected off, a scale such as 0.5, or window=0.5,animator=0)", value)
+		}
+		for _, s := range AnimationScales {
+			scales[s.Name] = scale
+		}
+		return scales, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		name, number, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if !isAnimationScale(name) {
+			return nil, fmt.Errorf("unknown animation scale: %s (expected window, transition or animator)", name)
+		}
+		scale, ok := parseAnimationScale(number)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s animation scale: %s", name, number)
+		}
+		scales[name] = scale
+	}
+	return scales, nil
+}
+
+// parseAnimationScale reads a single scale, where "off" is 0
+func parseAnimationScale(number string) (float64, bool) {
+	if number == "off" {
+		return 0, true
+	}
+	scale, err := strconv.ParseFloat(number, 64)
+	if err != nil || scale < 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		return 0, false
+	}
+	return scale, true
+}
+
+// formatAnimationScales writes scales the way parseAnimationScales reads them,
+// as a single scale when they are all the same
+func formatAnimationScales(scales map[string]float64) string {
+	var parts []string
+	same := len(scales) == len(AnimationScales)
+	for _, s := range AnimationScales {
+		value, ok := scales[s.Name]
+		if !ok {
+			continue
+		}
+		if value != scales[AnimationScales[0].Name] {
+			same = false
+		}
+		parts = append(parts, s.Name+"="+formatScale(value))
+	}
+	if same {
+		return formatScale(scales[AnimationScales[0].Name])
+	}
+	return strings.Join(parts, ",")
+}
+
+func isAnimationScale(name string) bool {
+	for _, s := range AnimationScales {
+		if s.Name == name {
+			return true
+		}
+	}
+	return false
+}
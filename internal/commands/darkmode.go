@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"strings"
+)
+
+// NightModes lists the modes cmd uimode night accepts
+var NightModes = []string{"yes", "no", "auto", "custom"}
+
+// defaultNightMode is the mode a reset puts a device back to
+const defaultNightMode = "no"
+
+func SetDarkMode(ctx context.Context, cfg *config.Config, device adb.Device, enabled bool) error {
+	mode := "no"
+	if enabled {
+		mode = "yes"
+	}
+
+	return SetNightMode(ctx, cfg, device, mode)
+}
+
+// GetNightMode returns the device's night mode as cmd uimode reports it: yes, no, auto or custom
+func GetNightMode(ctx context.Context, cfg *config.Config, device adb.Device) (string, error) {
+	output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "cmd", "uimode", "night")
+	if err != nil {
+		return "", fmt.Errorf("failed to get night mode: %w", err)
+	}
+
+	// Output is "Night mode: no"
+	_, mode, found := strings.Cut(strings.TrimSpace(output), "Night mode: ")
+	if !found || mode == "" {
+		return "", fmt.Errorf("could not parse night mode from output: %s", output)
+	}
+	return mode, nil
+}
+
+// SetNightMode sets the device's night mode to yes, no, auto or custom
+func SetNightMode(ctx context.Context, cfg *config.Config, device adb.Device, mode string) error {
+	if err := recordChange(ctx, device, SettingTypeNightMode); err != nil {
+		return err
+	}
+	return adb.ExecuteCommandContext(ctx, cfg.GetADBPath(), device.Serial, "shell", "cmd", "uimode", "night", mode)
+}
+
+// ToggleDarkMode switches a device between dark and light mode
+// Auto and custom count as light, so toggling them turns dark mode on
+func ToggleDarkMode(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	handler := GetSettingHandler(SettingTypeNightMode)
+	info, err := handler.GetInfo(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	mode := "yes"
+	if info.Current == "yes" {
+		mode = "no"
+	}
+	return handler.SetValue(ctx, cfg, device, mode)
+}
+
+// RestoreNightMode puts a device's night mode back after an interrupted day-night capture
+// It deliberately ignores the caller's context, which is usually already cancelled
+func RestoreNightMode(cfg *config.Config, device adb.Device, mode string) {
+	ctx, cancel := context.WithTimeout(context.Background(), adb.ProbeTimeout)
+	defer cancel()
+
+	if err := SetNightMode(ctx, cfg, device, mode); err != nil {
+		logger.Error("Warning: failed to restore night mode %s: %v", mode, err)
+	}
+}
+
+// parseNightMode reads a night mode, accepting on and off for yes and no
+func parseNightMode(value string) (string, error) {
+	switch value {
+	case "on":
+		return "yes", nil
+	case "off":
+		return "no", nil
+	}
+	for _, mode := range NightModes {
+		if value == mode {
+			return mode, nil
+		}
+	}
+	return "", fmt.Errorf("invalid dark mode: %s (expected yes, no, auto, custom, on or off)", value)
+}
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeviceSpec is the screen of a real device, in its portrait orientation
+type DeviceSpec struct {
+	Name    string `json:"name"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	Density int    `json:"density"`
+	Cutout  string `json:"cutout,omitempty"` // "hole" or "notch"; empty for none
+}
+
+// DPSize returns the screen's width and height in dp
+func (s DeviceSpec) DPSize() (int, int) {
+	return pxToDP(s.Width, s.Density), pxToDP(s.Height, s.Density)
+}
+
+//go:embed devicespecs.json
+var deviceSpecsJSON []byte
+
+// DeviceSpecs is the catalog of devices emulate-screen can imitate
+var DeviceSpecs = loadDeviceSpecs()
+
+// emulatedSettingTypes are the settings emulate-screen changes
+var emulatedSettingTypes = []SettingType{SettingTypeScreenSize, SettingTypeDPI}
+
+// EmulatedScreen is what a device is set to so that it lays out like another
+type EmulatedScreen struct {
+	Size    string // wm size value, in the device's natural orientation
+	Density int
+}
+
+func loadDeviceSpecs() []DeviceSpec {
+	var specs []DeviceSpec
+	if err := json.Unmarshal(deviceSpecsJSON, &specs); err != nil {
+		panic(fmt.Sprintf("invalid device specs: %v", err))
+	}
+	return specs
+}
+
+// FindDeviceSpec looks up a device in the catalog by name, ignoring case
+func FindDeviceSpec(name string) (DeviceSpec, error) {
+	for _, spec := range DeviceSpecs {
+		if strings.EqualFold(spec.Name, strings.TrimSpace(name)) {
+			return spec, nil
+		}
+	}
+	return DeviceSpec{}, fmt.Errorf("unknown device: %s (expected %s)", name, strings.Join(DeviceSpecNames(), ", "))
+}
+
+// DeviceSpecNames lists the devices in the catalog
+func DeviceSpecNames() []string {
+	names := make([]string, len(DeviceSpecs))
+	for i, spec := range DeviceSpecs {
+		names[i] = spec.Name
+	}
+	return names
+}
+
+// FitScreen works out the size and density that give a device the dp dimensions of spec
+// The spec's pixels are scaled down, density with them, when they don't fit the physical screen,
+// and turned to match the physical screen's natural orientation
+func FitScreen(spec DeviceSpec, physicalWidth, physicalHeight int) EmulatedScreen {
+	width, height := spec.Width, spec.Height
+	if (physicalWidth > physicalHeight) != (width > height) {
+		width, height = height, width
+	}
+
+	scale := math.Min(1, math.Min(float64(physicalWidth)/float64(width), float64(physicalHeight)/float64(height)))
+	return EmulatedScreen{
+		Size:    fmt.Sprintf("%dx%d", int(math.Round(float64(width)*scale)), int(math.Round(float64(height)*scale))),
+		Density: int(math.Round(float64(spec.Density) * scale)),
+	}
+}
+
+// EmulateScreen sets a device's screen size and density to lay out like the named device
+// Both go through their settings, so history, reset and restore work as for any change.
+// The size and density from before the first emulation are saved for ResetEmulatedScreen
+func EmulateScreen(ctx context.Context, cfg *config.Config, device adb.Device, name string) error {
+	spec, err := FindDeviceSpec(name)
+	if err != nil {
+		return err
+	}
+	info, err := GetCurrentScreenSize(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	width, height, err := parseScreenSize(info.Physical)
+	if err != nil {
+		return err
+	}
+
+	screen := FitScreen(spec, width, height)
+	density := strconv.Itoa(screen.Density)
+	sizeHandler, dpiHandler := GetSettingHandler(SettingTypeScreenSize), GetSettingHandler(SettingTypeDPI)
+	if !cfg.Force {
+		if err := sizeHandler.Validate(screen.Size); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		if err := dpiHandler.Validate(density); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+	}
+
+	if err := saveScreenBeforeEmulation(ctx, cfg, device); err != nil {
+		return err
+	}
+	if err := sizeHandler.SetValue(ctx, cfg, device, screen.Size); err != nil {
+		return err
+	}
+	if err := dpiHandler.SetValue(ctx, cfg, device, density); err != nil {
+		return err
+	}
+
+	dpWidth, dpHeight := spec.DPSize()
+	logger.Success("%s now lays out like a %s (%dx%d dp)", device.Serial, spec.Name, dpWidth, dpHeight)
+	return nil
+}
+
+// EmulationPath returns where the screen a device had before emulate-screen is kept
+func EmulationPath(cfg *config.Config, device adb.Device) string {
+	return filepath.Join(cfg.ConfigDir, "emulations", deviceFileName(device.Serial)+".json")
+}
+
+// ResetEmulatedScreen puts back the screen size and density a device had before its first emulation
+// A device with nothing saved goes back to its physical size and density
+func ResetEmulatedScreen(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	path := EmulationPath(cfg, device)
+	saved, err := LoadSnapshot(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Info("No screen saved from before emulating on %s, going back to the physical size and density", device.Serial)
+		return ResetSettings(ctx, cfg, device, emulatedSettingTypes)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, settingType := range emulatedSettingTypes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := restoreSetting(ctx, cfg, device, settingType, saved.Settings[settingType]); err != nil {
+			return err
+		}
+	}
+	if err := forgetEmulation(cfg, device); err != nil {
+		return err
+	}
+
+	logger.Success("%s is back to the screen it had before emulating", device.Serial)
+	return nil
+}
+
+// forgetEmulation drops the screen saved before emulating, once a reset has left the emulation
+// The next emulate-screen then saves the screen the device has at that point
+func forgetEmulation(cfg *config.Config, device adb.Device) error {
+	path := EmulationPath(cfg, device)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// saveScreenBeforeEmulation keeps a device's screen size and density, unless an earlier emulation already did
+func saveScreenBeforeEmulation(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	path := EmulationPath(cfg, device)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	screen := &Snapshot{
+		Serial:   device.Serial,
+		Model:    device.Model,
+		TakenAt:  time.Now(),
+		Settings: make(map[SettingType]SnapshotSetting, len(emulatedSettingTypes)),
+	}
+	for _, settingType := range emulatedSettingTypes {
+		info, err := GetSettingHandler(settingType).GetInfo(ctx, cfg, device)
+		if err != nil {
+			return fmt.Errorf("failed to save the screen of %s: %w", device.Serial, err)
+		}
+		screen.Settings[settingType] = SnapshotSetting{Value: info.Current, Overridden: info.Overridden}
+	}
+	return SaveSnapshot(path, screen)
+}
+
+// pxToDP converts pixels at a density to dp, rounding down as Android does for screen dimensions
+func pxToDP(px, density int) int {
+	return px * 160 / density
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -15,9 +16,9 @@ type DPIInfo struct {
 	Current  int // The effective DPI (override if exists, otherwise physical)
 }
 
-func GetCurrentDPI(cfg *config.Config, device adb.Device) (*DPIInfo, error) {
+func GetCurrentDPI(ctx context.Context, cfg *config.Config, device adb.Device) (*DPIInfo, error) {
 	adbPath := cfg.GetADBPath()
-	output, err := adb.ExecuteCommandWithOutput(adbPath, device.Serial, "shell", "wm", "density")
+	output, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "wm", "density")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current DPI: %w", err)
 	}
@@ -63,9 +64,13 @@ func GetCurrentDPI(cfg *config.Config, device adb.Device) (*DPIInfo, error) {
 	return info, nil
 }
 
-func SetDPI(cfg *config.Config, device adb.Device, dpi int) error {
+func SetDPI(ctx context.Context, cfg *config.Config, device adb.Device, dpi int) error {
+	if err := recordChange(ctx, device, SettingTypeDPI); err != nil {
+		return err
+	}
+
 	adbPath := cfg.GetADBPath()
-	err := adb.ExecuteCommand(adbPath, device.Serial, "shell", "wm", "density", strconv.Itoa(dpi))
+	_, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "wm", "density", strconv.Itoa(dpi))
 	if err != nil {
 		return fmt.Errorf("failed to set DPI to %d: %w", dpi, err)
 	}
@@ -73,3 +78,18 @@ func SetDPI(cfg *config.Config, device adb.Device, dpi int) error {
 	logger.Success("DPI changed to %d on device %s", dpi, device.Serial)
 	return nil
 }
+
+// ResetDPI clears the density override, back to the physical density
+func ResetDPI(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeDPI); err != nil {
+		return err
+	}
+
+	_, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "wm", "density", "reset")
+	if err != nil {
+		return fmt.Errorf("failed to reset DPI: %w", err)
+	}
+
+	logger.Success("DPI reset to physical density on device %s", device.Serial)
+	return nil
+}
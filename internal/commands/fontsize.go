@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -10,13 +11,14 @@ import (
 )
 
 type FontSizeInfo struct {
-	Default float64
-	Current float64 // The effective font scale
+	Default    float64
+	Current    float64 // The effective font scale
+	Overridden bool    // Whether font_scale is set, rather than left at the system default
 }
 
-func GetCurrentFontSize(cfg *config.Config, device adb.Device) (*FontSizeInfo, error) {
+func GetCurrentFontSize(ctx context.Context, cfg *config.Config, device adb.Device) (*FontSizeInfo, error) {
 	adbPath := cfg.GetADBPath()
-	output, err := adb.ExecuteCommandWithOutput(adbPath, device.Serial, "shell", "settings", "get", "system", "font_scale")
+	output, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "get", "system", "font_scale")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current font size: %w", err)
 	}
@@ -35,15 +37,20 @@ func GetCurrentFontSize(cfg *config.Config, device adb.Device) (*FontSizeInfo, e
 	}
 
 	return &FontSizeInfo{
-		Default: 1.0, // Android default is always 1.0
-		Current: current,
+		Default:    1.0, // Android default is always 1.0
+		Current:    current,
+		Overridden: true,
 	}, nil
 }
 
-func SetFontSize(cfg *config.Config, device adb.Device, scale float64) error {
+func SetFontSize(ctx context.Context, cfg *config.Config, device adb.Device, scale float64) error {
+	if err := recordChange(ctx, device, SettingTypeFontSize); err != nil {
+		return err
+	}
+
 	adbPath := cfg.GetADBPath()
-	scaleStr := strconv.FormatFloat(scale, 'f', 1, 64)
-	err := adb.ExecuteCommand(adbPath, device.Serial, "shell", "settings", "put", "system", "font_scale", scaleStr)
+	scaleStr := formatScale(scale)
+	_, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "settings", "put", "system", "font_scale", scaleStr)
 	if err != nil {
 		return fmt.Errorf("failed to set font size to %s: %w", scaleStr, err)
 	}
@@ -51,3 +58,27 @@ func SetFontSize(cfg *config.Config, device adb.Device, scale float64) error {
 	logger.Success("Font size changed to %s on device %s", scaleStr, device.Serial)
 	return nil
 }
+
+// ResetFontSize deletes the font_scale setting, back to the system default
+func ResetFontSize(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeFontSize); err != nil {
+		return err
+	}
+
+	_, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "delete", "system", "font_scale")
+	if err != nil {
+		return fmt.Errorf("failed to reset font size: %w", err)
+	}
+
+	logger.Success("Font size reset to default on device %s", device.Serial)
+	return nil
+}
+
+// formatScale formats a font or animation scale with as many decimals as it needs, but at least one (1.0, 1.15)
+func formatScale(scale float64) string {
+	formatted := strconv.FormatFloat(scale, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
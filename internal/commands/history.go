@@ -0,0 +1,251 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MaxHistoryChanges is how many changes a device's history keeps; older ones are dropped
+const MaxHistoryChanges = 100
+
+// History is the list of setting changes made to one device
+// Changes after Position were undone and can be redone until a new change replaces them
+type History struct {
+	Serial   string          `json:"serial"`
+	Changes  []HistoryChange `json:"changes"`
+	Position int             `json:"position"` // Number of changes currently applied
+}
+
+// HistoryChange is one setting change, with the states before and after it
+type HistoryChange struct {
+	Setting   SettingType     `json:"setting"`
+	Previous  SnapshotSetting `json:"previous"`
+	New       SnapshotSetting `json:"new"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// history guards history files, and tracks devices whose history is being walked by undo or redo
+var history = struct {
+	sync.Mutex
+	applying map[string]bool
+}{applying: make(map[string]bool)}
+
+// historyHandler records every change made through a setting handler in the device's history
+type historyHandler struct {
+	SettingHandler
+	settingType SettingType
+}
+
+func (h *historyHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	return h.record(ctx, cfg, device, func() error {
+		return h.SettingHandler.SetValue(ctx, cfg, device, value)
+	})
+}
+
+func (h *historyHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return h.record(ctx, cfg, device, func() error {
+		return h.SettingHandler.Reset(ctx, cfg, device)
+	})
+}
+
+// record makes a change and adds it to the device's history, unless undo or redo is making it
+func (h *historyHandler) record(ctx context.Context, cfg *config.Config, device adb.Device, change func() error) error {
+	if historyApplying(device.Serial) {
+		return change()
+	}
+
+	// History is best effort; a setting that can't be read is still changed
+	previous, previousErr := h.state(ctx, cfg, device)
+	if err := change(); err != nil {
+		return err
+	}
+	if previousErr != nil {
+		return nil
+	}
+	current, err := h.state(ctx, cfg, device)
+	if err != nil {
+		return nil
+	}
+
+	entry := HistoryChange{Setting: h.settingType, Previous: previous, New: current, ChangedAt: time.Now()}
+	if err := recordHistory(cfg, device.Serial, entry); err != nil {
+		logger.Error("Warning: failed to save history: %v", err)
+	}
+	return nil
+}
+
+func (h *historyHandler) state(ctx context.Context, cfg *config.Config, device adb.Device) (SnapshotSetting, error) {
+	info, err := h.GetInfo(ctx, cfg, device)
+	if err != nil {
+		return SnapshotSetting{}, err
+	}
+	return SnapshotSetting{Value: info.Current, Overridden: info.Overridden}, nil
+}
+
+// HistoryPath returns where the history of a device is kept
+func HistoryPath(cfg *config.Config, serial string) string {
+	return filepath.Join(cfg.ConfigDir, "history", deviceFileName(serial)+".json")
+}
+
+// LoadHistory reads a device's history; a device without one has an empty history
+func LoadHistory(cfg *config.Config, serial string) (*History, error) {
+	data, err := os.ReadFile(HistoryPath(cfg, serial))
+	if errors.Is(err, os.ErrNotExist) {
+		return &History{Serial: serial}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse history of %s: %w", serial, err)
+	}
+	h.Position = min(max(h.Position, 0), len(h.Changes))
+	return &h, nil
+}
+
+// ClearHistory forgets every change made to a device
+func ClearHistory(cfg *config.Config, serial string) error {
+	history.Lock()
+	defer history.Unlock()
+
+	if err := os.Remove(HistoryPath(cfg, serial)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+	return nil
+}
+
+// Undo reverts the last applied change on a device and returns it
+func Undo(ctx context.Context, cfg *config.Config, device adb.Device) (HistoryChange, error) {
+	h, err := LoadHistory(cfg, device.Serial)
+	if err != nil {
+		return HistoryChange{}, err
+	}
+	if h.Position == 0 {
+		return HistoryChange{}, fmt.Errorf("nothing to undo on %s", device.Serial)
+	}
+	return h.Changes[h.Position-1], RevertHistoryTo(ctx, cfg, device, h.Position-1)
+}
+
+// Redo applies the last undone change on a device again and returns it
+func Redo(ctx context.Context, cfg *config.Config, device adb.Device) (HistoryChange, error) {
+	h, err := LoadHistory(cfg, device.Serial)
+	if err != nil {
+		return HistoryChange{}, err
+	}
+	if h.Position == len(h.Changes) {
+		return HistoryChange{}, fmt.Errorf("nothing to redo on %s", device.Serial)
+	}
+	return h.Changes[h.Position], RevertHistoryTo(ctx, cfg, device, h.Position+1)
+}
+
+// RevertHistoryTo undoes or redoes changes until the first position changes are applied
+// Position 0 is the state before the oldest change in the history
+func RevertHistoryTo(ctx context.Context, cfg *config.Config, device adb.Device, position int) error {
+	h, err := LoadHistory(cfg, device.Serial)
+	if err != nil {
+		return err
+	}
+	if position < 0 || position > len(h.Changes) {
+		return fmt.Errorf("invalid history position %d, expected 0 to %d", position, len(h.Changes))
+	}
+
+	setHistoryApplying(device.Serial, true)
+	defer setHistoryApplying(device.Serial, false)
+
+	// Position is saved after every step, so an interrupted walk leaves the history accurate
+	for h.Position != position {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var change HistoryChange
+		var state SnapshotSetting
+		var step int
+		if h.Position > position {
+			change, state, step = h.Changes[h.Position-1], h.Changes[h.Position-1].Previous, -1
+		} else {
+			change, state, step = h.Changes[h.Position], h.Changes[h.Position].New, 1
+		}
+
+		if err := restoreSetting(ctx, cfg, device, change.Setting, state); err != nil {
+			return fmt.Errorf("failed to set %s to %s: %w", change.Setting, state, err)
+		}
+		h.Position += step
+		if err := saveHistory(cfg, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordHistory appends a change after the applied ones, dropping any that were undone
+func recordHistory(cfg *config.Config, serial string, change HistoryChange) error {
+	history.Lock()
+	defer history.Unlock()
+
+	h, err := LoadHistory(cfg, serial)
+	if err != nil {
+		return err
+	}
+	h.Changes = append(h.Changes[:h.Position], change)
+	if len(h.Changes) > MaxHistoryChanges {
+		h.Changes = h.Changes[len(h.Changes)-MaxHistoryChanges:]
+	}
+	h.Position = len(h.Changes)
+	return saveHistory(cfg, h)
+}
+
+func saveHistory(cfg *config.Config, h *History) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	path := HistoryPath(cfg, h.Serial)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save history: %w", err)
+	}
+	return nil
+}
+
+func historyApplying(serial string) bool {
+	history.Lock()
+	defer history.Unlock()
+	return history.applying[serial]
+}
+
+func setHistoryApplying(serial string, applying bool) {
+	history.Lock()
+	defer history.Unlock()
+	if applying {
+		history.applying[serial] = true
+	} else {
+		delete(history.applying, serial)
+	}
+}
+
+// String describes a change, e.g. "dpi: 420 (default) → 480"
+func (c HistoryChange) String() string {
+	return fmt.Sprintf("%s: %s → %s", c.Setting, c.Previous, c.New)
+}
+
+// String describes a saved state, e.g. "480" or "420 (default)"
+func (s SnapshotSetting) String() string {
+	if !s.Overridden {
+		return s.Value + " (default)"
+	}
+	return s.Value
+}
@@ -0,0 +1,266 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Journal records the changes a "leave no trace" session made to one device, so they can be undone
+// Each entry is written before its change is made, so a crashed session can still be reverted
+type Journal struct {
+	Serial    string         `json:"serial"`
+	PID       int            `json:"pid"` // Process of the session that owns the journal
+	StartedAt time.Time      `json:"started_at"`
+	Entries   []JournalEntry `json:"entries"`
+}
+
+// JournalEntry is the state of a setting before one change
+type JournalEntry struct {
+	Setting   SettingType     `json:"setting"`
+	Previous  SnapshotSetting `json:"previous"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+// journal tracks whether changes are being recorded, and which devices are being reverted
+var journal = struct {
+	sync.Mutex
+	cfg       *config.Config
+	reverting map[string]bool
+}{reverting: make(map[string]bool)}
+
+// EnableJournal starts recording every setting change so RevertJournals can undo it
+func EnableJournal(cfg *config.Config) {
+	journal.Lock()
+	defer journal.Unlock()
+	journal.cfg = cfg
+}
+
+// DisableJournal stops recording setting changes; journals already written are kept
+func DisableJournal() {
+	journal.Lock()
+	defer journal.Unlock()
+	journal.cfg = nil
+}
+
+// JournalPath returns where the journal of a device is kept
+func JournalPath(cfg *config.Config, serial string) string {
+	return filepath.Join(cfg.ConfigDir, "journal", deviceFileName(serial)+".json")
+}
+
+// LoadJournal reads a device's journal, returning nil if it has none
+func LoadJournal(cfg *config.Config, serial string) (*Journal, error) {
+	data, err := os.ReadFile(JournalPath(cfg, serial))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var j Journal
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, fmt.Errorf("failed to parse journal of %s: %w", serial, err)
+	}
+	return &j, nil
+}
+
+// PendingJournals returns the journals left behind by sessions that are no longer running
+func PendingJournals(cfg *config.Config) ([]*Journal, error) {
+	paths, err := filepath.Glob(filepath.Join(cfg.ConfigDir, "journal", "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+
+	var pending []*Journal
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal: %w", err)
+		}
+		var j Journal
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+		}
+		if j.PID != os.Getpid() && processRunning(j.PID) {
+			continue
+		}
+		pending = append(pending, &j)
+	}
+	return pending, nil
+}
+
+// RevertJournals undoes the recorded changes on every connected device with a journal
+// Journals of devices that aren't connected are kept for the next time
+func RevertJournals(ctx context.Context, cfg *config.Config) error {
+	pending, err := PendingJournals(cfg)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, j := range pending {
+		device, connected := findDevice(devices, j.Serial)
+		if !connected || adb.StatusError(device) != nil {
+			logger.Info("%s isn't connected, its %d changes will be reverted when it is", j.Serial, len(j.Entries))
+			continue
+		}
+		if err := RevertJournal(ctx, cfg, device); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", j.Serial, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RevertJournal undoes a device's recorded changes in reverse order and removes its journal
+// If a change can't be undone, the ones still to undo stay in the journal
+func RevertJournal(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	j, err := LoadJournal(cfg, device.Serial)
+	if err != nil {
+		return err
+	}
+	if j == nil {
+		logger.Info("No changes to revert on %s", device.Serial)
+		return nil
+	}
+
+	setReverting(device.Serial, true)
+	defer setReverting(device.Serial, false)
+
+	logger.Info("Reverting %d changes on %s", len(j.Entries), device.Serial)
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := restoreSetting(ctx, cfg, device, j.Entries[i].Setting, j.Entries[i].Previous); err != nil {
+			return fmt.Errorf("failed to revert %s: %w", j.Entries[i].Setting, err)
+		}
+		j.Entries = j.Entries[:i]
+		if err := saveJournal(cfg, j); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(JournalPath(cfg, device.Serial)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	logger.Success("Reverted all changes on %s", device.Serial)
+	return nil
+}
+
+// recordChange journals the current state of a setting before it is changed
+// It does nothing unless the journal is enabled, or while the device is being reverted,
+// and fails once ctx is cancelled so the change is never made
+func recordChange(ctx context.Context, device adb.Device, settingType SettingType) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	journal.Lock()
+	cfg := journal.cfg
+	skip := cfg == nil || journal.reverting[device.Serial]
+	journal.Unlock()
+	if skip {
+		return nil
+	}
+
+	previous, err := currentState(ctx, cfg, device, settingType)
+	if err != nil {
+		return fmt.Errorf("failed to journal %s before changing it: %w", settingType, err)
+	}
+
+	// Devices change in parallel; keep each read-modify-write of a journal whole
+	journal.Lock()
+	defer journal.Unlock()
+
+	j, err := LoadJournal(cfg, device.Serial)
+	if err != nil {
+		return err
+	}
+	if j == nil {
+		j = &Journal{Serial: device.Serial, StartedAt: time.Now()}
+	}
+	j.PID = os.Getpid()
+	j.Entries = append(j.Entries, JournalEntry{Setting: settingType, Previous: previous, ChangedAt: time.Now()})
+	return saveJournal(cfg, j)
+}
+
+// currentState reads a setting the way a journal entry stores it
+func currentState(ctx context.Context, cfg *config.Config, device adb.Device, settingType SettingType) (SnapshotSetting, error) {
+	info, err := GetSettingHandler(settingType).GetInfo(ctx, cfg, device)
+	if err != nil {
+		return SnapshotSetting{}, err
+	}
+	return SnapshotSetting{Value: info.Current, Overridden: info.Overridden}, nil
+}
+
+func saveJournal(cfg *config.Config, j *Journal) error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	path := JournalPath(cfg, j.Serial)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	// Write and rename, so a crash mid-write can't leave a truncated journal
+	if err := os.WriteFile(path+".tmp", append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save journal: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to save journal: %w", err)
+	}
+	return nil
+}
+
+func setReverting(serial string, reverting bool) {
+	journal.Lock()
+	defer journal.Unlock()
+	if reverting {
+		journal.reverting[serial] = true
+	} else {
+		delete(journal.reverting, serial)
+	}
+}
+
+// processRunning reports whether a process with the given ID exists
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// findDevice returns the device with a serial from a device list
+func findDevice(devices []adb.Device, serial string) (adb.Device, bool) {
+	for _, device := range devices {
+		if device.Serial == serial {
+			return device, true
+		}
+	}
+	return adb.Device{}, false
+}
+
+// deviceFileName turns a serial into a file name; WiFi serials contain colons
+func deviceFileName(serial string) string {
+	return strings.NewReplacer(":", "_", "/", "_").Replace(serial)
+}
@@ -0,0 +1,302 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PseudoLocales maps the shortcuts for Android's pseudo-locales to their tags
+// en-XA adds accents and padding to English text, ar-XB mirrors it right to left
+var PseudoLocales = map[string]string{
+	"accents": "en-XA",
+	"rtl":     "ar-XB",
+}
+
+// localePropertyAPILevel is the first API level that keeps the locale in persist.sys.locale;
+// older ones split it into persist.sys.language and persist.sys.country
+const localePropertyAPILevel = 21
+
+// systemLocalesAPILevel is the first API level whose system_locales setting the shell can write without root;
+// the locale still only applies once the framework restarts
+const systemLocalesAPILevel = 33
+
+// frameworkRestartTimeout is how long a locale change waits for the UI to come back
+const frameworkRestartTimeout = 2 * time.Minute
+
+// localePattern matches language tags like "fr", "en-US" or "zh-Hans-CN"
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z]{4})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+type LocaleInfo struct {
+	Default    string // The locale the build ships with
+	Current    string
+	Overridden bool // Whether a locale was chosen, rather than left at the build default
+}
+
+func GetLocale(ctx context.Context, cfg *config.Config, device adb.Device) (*LocaleInfo, error) {
+	level, err := apiLevel(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	var current, defaultLocale string
+	if level >= systemLocalesAPILevel {
+		current, err = getSystemLocale(ctx, cfg, device)
+	}
+	if level >= localePropertyAPILevel {
+		if err == nil && current == "" {
+			current, err = getProp(ctx, cfg, device, "persist.sys.locale")
+		}
+		if err == nil {
+			defaultLocale, err = getProp(ctx, cfg, device, "ro.product.locale")
+		}
+		if err == nil && defaultLocale == "" {
+			defaultLocale, err = getLocaleProps(ctx, cfg, device, "ro.product.locale.language", "ro.product.locale.region")
+		}
+	} else {
+		current, err = getLocaleProps(ctx, cfg, device, "persist.sys.language", "persist.sys.country")
+		if err == nil {
+			defaultLocale, err = getLocaleProps(ctx, cfg, device, "ro.product.locale.language", "ro.product.locale.region")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get locale: %w", err)
+	}
+
+	info := &LocaleInfo{Default: defaultLocale, Current: current}
+	if current == "" {
+		info.Current = defaultLocale
+	}
+	info.Overridden = current != "" && current != defaultLocale
+	if info.Current == "" {
+		return nil, fmt.Errorf("could not read the locale of %s", device.Serial)
+	}
+	return info, nil
+}
+
+// SetLocale changes the system locale and, on rooted devices, restarts the Android framework so apps pick it up
+// Without root only API 33 and later take the locale, which then applies once the device restarts;
+// emulators and debuggable builds get root with "adb root"
+func SetLocale(ctx context.Context, cfg *config.Config, device adb.Device, locale string) error {
+	if err := recordChange(ctx, device, SettingTypeLocale); err != nil {
+		return err
+	}
+	if err := writeLocale(ctx, cfg, device, locale); err != nil {
+		return fmt.Errorf("failed to set locale to %s: %w", locale, err)
+	}
+
+	logger.Success("Locale changed to %s on device %s", locale, device.Serial)
+	return nil
+}
+
+// ResetLocale clears the chosen locale, back to the build default
+// The restart it needs is skipped when the locale is already the default
+func ResetLocale(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	info, err := GetLocale(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	if !info.Overridden {
+		logger.Success("Locale is already the default %s on device %s", info.Default, device.Serial)
+		return nil
+	}
+
+	if err := recordChange(ctx, device, SettingTypeLocale); err != nil {
+		return err
+	}
+	if err := writeLocale(ctx, cfg, device, ""); err != nil {
+		return fmt.Errorf("failed to reset locale: %w", err)
+	}
+
+	logger.Success("Locale reset to %s on device %s", info.Default, device.Serial)
+	return nil
+}
+
+// SetPseudoLocale switches a device to one of PseudoLocales by its shortcut, accents or rtl
+func SetPseudoLocale(ctx context.Context, cfg *config.Config, device adb.Device, name string) error {
+	return GetSettingHandler(SettingTypeLocale).SetValue(ctx, cfg, device, PseudoLocales[name])
+}
+
+// RestoreLocale puts back the locale a device had before a locale screenshot run
+// An interrupt stops the framework restart it waits for, unless ctx was already cancelled,
+// as after an interrupted run; the locale is then still restored, within frameworkRestartTimeout
+func RestoreLocale(ctx context.Context, cfg *config.Config, device adb.Device, original *LocaleInfo) {
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), frameworkRestartTimeout)
+		defer cancel()
+	}
+
+	var err error
+	if original.Overridden {
+		err = SetLocale(ctx, cfg, device, original.Current)
+	} else {
+		err = ResetLocale(ctx, cfg, device)
+	}
+	if err != nil {
+		logger.Error("Warning: failed to restore locale %s: %v", original.Current, err)
+	}
+}
+
+// ParseLocales reads a comma-separated list of locales, defaulting to both pseudo-locales
+func ParseLocales(value string) ([]string, error) {
+	if value == "" {
+		return []string{PseudoLocales["accents"], PseudoLocales["rtl"]}, nil
+	}
+
+	var locales []string
+	for _, part := range strings.Split(value, ",") {
+		locale, err := parseLocale(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		locales = append(locales, locale)
+	}
+	return locales, nil
+}
+
+// writeLocale sets the locale for the device's API level, empty to clear it
+// From API 33 it goes in the system_locales setting, which needs no root; rooted devices also get
+// the locale properties and a framework restart, so the locale applies right away
+func writeLocale(ctx context.Context, cfg *config.Config, device adb.Device, locale string) error {
+	level, err := apiLevel(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	root := hasRoot(ctx, cfg, device)
+	if level >= systemLocalesAPILevel {
+		if locale == "" {
+			_, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "delete", "system", "system_locales")
+		} else {
+			_, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "put", "system", "system_locales", locale)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write system_locales: %w", err)
+		}
+		if !root {
+			logger.Info("The locale applies once %s restarts; with \"adb root\" gadget restarts Android right away", device.Serial)
+			return nil
+		}
+	} else if !root {
+		return fmt.Errorf("changing the locale before API %d needs root, try \"adb root\"", systemLocalesAPILevel)
+	}
+
+	props := [][2]string{{"persist.sys.locale", locale}}
+	if level < localePropertyAPILevel {
+		language, country, _ := strings.Cut(locale, "-")
+		props = [][2]string{{"persist.sys.language", language}, {"persist.sys.country", country}}
+	}
+	for _, prop := range props {
+		name, value := prop[0], prop[1]
+		if value == "" {
+			value = "''" // The shell would drop an empty argument
+		}
+		if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "setprop", name, value); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	return restartFramework(ctx, cfg, device)
+}
+
+// hasRoot reports whether the device's shell runs as root, as it does after "adb root"
+func hasRoot(ctx context.Context, cfg *config.Config, device adb.Device) bool {
+	output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "id", "-u")
+	return err == nil && strings.TrimSpace(output) == "0"
+}
+
+// restartFramework restarts zygote and everything above it, then waits for the boot animation to finish
+func restartFramework(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	logger.Info("Restarting Android on %s to apply the locale...", device.Serial)
+	if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "stop", "&&", "start"); err != nil {
+		return fmt.Errorf("failed to restart Android: %w", err)
+	}
+
+	// The boot animation takes a moment to start; a device without one reports no state and has nothing to wait for
+	ctx, cancel := context.WithTimeout(ctx, frameworkRestartTimeout)
+	defer cancel()
+	if err := Wait(ctx, time.Second); err != nil {
+		return err
+	}
+	for {
+		state, err := getProp(ctx, cfg, device, "init.svc.bootanim")
+		if err != nil {
+			return fmt.Errorf("Android didn't finish restarting on %s: %w", device.Serial, err)
+		}
+		if state != "running" {
+			return nil
+		}
+		if err := Wait(ctx, 500*time.Millisecond); err != nil {
+			return fmt.Errorf("Android didn't finish restarting on %s: %w", device.Serial, err)
+		}
+	}
+}
+
+// apiLevel returns the device's API level, reading it when the device list didn't
+func apiLevel(ctx context.Context, cfg *config.Config, device adb.Device) (int, error) {
+	if device.APILevel > 0 {
+		return device.APILevel, nil
+	}
+	sdk, err := getProp(ctx, cfg, device, "ro.build.version.sdk")
+	if err != nil {
+		return 0, err
+	}
+	level, err := strconv.Atoi(sdk)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse API level from output: %s", sdk)
+	}
+	return level, nil
+}
+
+func getProp(ctx context.Context, cfg *config.Config, device adb.Device, prop string) (string, error) {
+	output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "getprop", prop)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// getSystemLocale returns the first locale of the system_locales setting, or nothing when it isn't set
+func getSystemLocale(ctx context.Context, cfg *config.Config, device adb.Device) (string, error) {
+	output, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "get", "system", "system_locales")
+	if err != nil {
+		return "", err
+	}
+	locale, _, _ := strings.Cut(strings.TrimSpace(output), ",")
+	if locale == "null" {
+		return "", nil
+	}
+	return locale, nil
+}
+
+// getLocaleProps joins a language and a country property into a tag, or returns just the language
+func getLocaleProps(ctx context.Context, cfg *config.Config, device adb.Device, languageProp, countryProp string) (string, error) {
+	language, err := getProp(ctx, cfg, device, languageProp)
+	if err != nil || language == "" {
+		return "", err
+	}
+	country, err := getProp(ctx, cfg, device, countryProp)
+	if err != nil || country == "" {
+		return language, err
+	}
+	return language + "-" + country, nil
+}
+
+// parseLocale reads a language tag, accepting "en_US" and the pseudo-locale shortcuts
+func parseLocale(value string) (string, error) {
+	if tag, ok := PseudoLocales[value]; ok {
+		return tag, nil
+	}
+	locale := strings.ReplaceAll(value, "_", "-")
+	if !localePattern.MatchString(locale) {
+		return "", fmt.Errorf("invalid locale: %s (expected a language tag such as en-US, or accents or rtl for a pseudo-locale)", value)
+	}
+	return locale, nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/logger"
+	"strings"
+	"sync"
+)
+
+// DefaultDeviceWorkers is how many devices a multi-device command drives at once
+const DefaultDeviceWorkers = 4
+
+// DeviceResult is the outcome of a command on one device
+type DeviceResult struct {
+	Device adb.Device
+	Err    error
+}
+
+// multiDeviceKey marks contexts of commands running on several devices at once
+type multiDeviceKey struct{}
+
+// RunOnDevices runs fn on every device in parallel, at most workers at a time
+// Results are returned in the order of devices
+func RunOnDevices(ctx context.Context, devices []adb.Device, workers int, fn func(ctx context.Context, device adb.Device) error) []DeviceResult {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx = context.WithValue(ctx, multiDeviceKey{}, true)
+
+	results := make([]DeviceResult, len(devices))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+	for i, device := range devices {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, device adb.Device) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = DeviceResult{Device: device, Err: fn(ctx, device)}
+		}(i, device)
+	}
+	wg.Wait()
+	return results
+}
+
+// SummarizeResults logs one line per device and returns an error if any device failed
+func SummarizeResults(action string, results []DeviceResult) error {
+	failed := 0
+	logger.Info("%s results:", action)
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			logger.Error("  ✗ %s: %v", result.Device.Serial, result.Err)
+		} else {
+			logger.Success("  ✓ %s", result.Device.Serial)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed on %d of %d devices", action, failed, len(results))
+	}
+	logger.Success("%s succeeded on %d devices", action, len(results))
+	return nil
+}
+
+// deviceFileTag returns a file name part that keeps files from different devices apart
+// Single-device runs keep the original file names
+func deviceFileTag(ctx context.Context, device adb.Device) string {
+	if multi, _ := ctx.Value(multiDeviceKey{}).(bool); !multi {
+		return ""
+	}
+	return "-" + strings.NewReplacer(":", "_", "/", "_").Replace(device.Serial)
+}
@@ -0,0 +1,196 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"strings"
+)
+
+// DebugOverlay is one of the drawing and input overlays in the developer options
+type DebugOverlay struct {
+	Name        string // Used in values and the overlay command, e.g. "layout-bounds"
+	DisplayName string
+	Property    string // System property that turns it on, read by apps when poked
+	Setting     string // System setting that turns it on, applied right away; used when Property is empty
+	On          string // Value that shows the overlay
+	Off         string
+}
+
+// DebugOverlays lists the overlays the overlays setting changes, in the order they are shown
+var DebugOverlays = []DebugOverlay{
+	{Name: "layout-bounds", DisplayName: "Layout bounds", Property: "debug.layout", On: "true", Off: "false"},
+	{Name: "overdraw", DisplayName: "GPU overdraw", Property: "debug.hwui.overdraw", On: "show", Off: "false"},
+	{Name: "gpu-profile", DisplayName: "GPU rendering bars", Property: "debug.hwui.profile", On: "visual_bars", Off: "false"},
+	{Name: "taps", DisplayName: "Show taps", Setting: "show_touches", On: "1", Off: "0"},
+	{Name: "pointer-location", DisplayName: "Pointer location", Setting: "pointer_location", On: "1", Off: "0"},
+}
+
+// noDebugOverlays is the overlays value with every overlay off
+const noDebugOverlays = "none"
+
+// sysPropsTransaction is IBinder.SYSPROPS_TRANSACTION ('_SPR'); the activity manager passes it on
+// to every app, which re-reads its debug properties and redraws without a restart
+const sysPropsTransaction = "1599295570"
+
+// GetDebugOverlays returns which overlays are showing, by name
+func GetDebugOverlays(ctx context.Context, cfg *config.Config, device adb.Device) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	for _, overlay := range DebugOverlays {
+		var output string
+		var err error
+		if overlay.Property != "" {
+			output, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "getprop", overlay.Property)
+		} else {
+			output, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "get", "system", overlay.Setting)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", overlay.DisplayName, err)
+		}
+		if strings.TrimSpace(output) == overlay.On {
+			enabled[overlay.Name] = true
+		}
+	}
+	return enabled, nil
+}
+
+// SetDebugOverlays shows exactly the given overlays and hides the others
+func SetDebugOverlays(ctx context.Context, cfg *config.Config, device adb.Device, enabled map[string]bool) error {
+	current, err := GetDebugOverlays(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	if err := recordChange(ctx, device, SettingTypeOverlays); err != nil {
+		return err
+	}
+
+	var changed []DebugOverlay
+	for _, overlay := range DebugOverlays {
+		if enabled[overlay.Name] != current[overlay.Name] {
+			changed = append(changed, overlay)
+		}
+	}
+	if err := writeDebugOverlays(ctx, cfg, device, changed, enabled); err != nil {
+		return err
+	}
+
+	logger.Success("Debug overlays changed to %s on device %s", formatDebugOverlays(enabled), device.Serial)
+	return nil
+}
+
+// ResetDebugOverlays hides every overlay
+func ResetDebugOverlays(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeOverlays); err != nil {
+		return err
+	}
+	if err := writeDebugOverlays(ctx, cfg, device, DebugOverlays, nil); err != nil {
+		return err
+	}
+
+	logger.Success("Debug overlays turned off on device %s", device.Serial)
+	return nil
+}
+
+// SetDebugOverlay shows or hides one overlay by name, leaving the others alone
+func SetDebugOverlay(ctx context.Context, cfg *config.Config, device adb.Device, name string, on bool) error {
+	if _, err := FindDebugOverlay(name); err != nil {
+		return err
+	}
+	enabled, err := GetDebugOverlays(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	enabled[name] = on
+	return GetSettingHandler(SettingTypeOverlays).SetValue(ctx, cfg, device, formatDebugOverlays(enabled))
+}
+
+// ToggleDebugOverlay shows an overlay that is hidden, or hides it
+func ToggleDebugOverlay(ctx context.Context, cfg *config.Config, device adb.Device, name string) error {
+	enabled, err := GetDebugOverlays(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	return SetDebugOverlay(ctx, cfg, device, name, !enabled[name])
+}
+
+// writeDebugOverlays writes the given overlays as on or off, then pokes running apps if a property changed
+func writeDebugOverlays(ctx context.Context, cfg *config.Config, device adb.Device, overlays []DebugOverlay, enabled map[string]bool) error {
+	poke := false
+	for _, overlay := range overlays {
+		value := overlay.Off
+		if enabled[overlay.Name] {
+			value = overlay.On
+		}
+
+		var err error
+		if overlay.Property != "" {
+			_, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "setprop", overlay.Property, value)
+			poke = true
+		} else {
+			_, err = adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "settings", "put", "system", overlay.Setting, value)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set %s to %s: %w", overlay.DisplayName, value, err)
+		}
+	}
+
+	if !poke {
+		return nil
+	}
+	if _, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "service", "call", "activity", sysPropsTransaction); err != nil {
+		return fmt.Errorf("failed to apply debug overlays: %w", err)
+	}
+	return nil
+}
+
+// parseDebugOverlays reads an overlays value: "none", or the overlays to show ("layout-bounds,taps")
+func parseDebugOverlays(value string) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	if value == noDebugOverlays || value == "off" {
+		return enabled, nil
+	}
+
+	for _, part := range strings.Split(value, ",") {
+		name := strings.TrimSpace(part)
+		if _, err := FindDebugOverlay(name); err != nil {
+			return nil, err
+		}
+		enabled[name] = true
+	}
+	return enabled, nil
+}
+
+// formatDebugOverlays writes overlays the way parseDebugOverlays reads them
+func formatDebugOverlays(enabled map[string]bool) string {
+	var names []string
+	for _, overlay := range DebugOverlays {
+		if enabled[overlay.Name] {
+			names = append(names, overlay.Name)
+		}
+	}
+	if len(names) == 0 {
+		return noDebugOverlays
+	}
+	return strings.Join(names, ",")
+}
+
+// FindDebugOverlay looks up an overlay by name
+func FindDebugOverlay(name string) (DebugOverlay, error) {
+	for _, overlay := range DebugOverlays {
+		if overlay.Name == name {
+			return overlay, nil
+		}
+	}
+	return DebugOverlay{}, fmt.Errorf("unknown debug overlay: %s (expected %s)", name, strings.Join(debugOverlayNames(), ", "))
+}
+
+func debugOverlayNames() []string {
+	names := make([]string, len(DebugOverlays))
+	for i, overlay := range DebugOverlays {
+		names[i] = overlay.Name
+	}
+	return names
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -9,12 +10,12 @@ import (
 )
 
 // PairWiFiDevice pairs with a WiFi device using a pairing code
-func PairWiFiDevice(cfg *config.Config, ipAndPort, pairingCode string) error {
+func PairWiFiDevice(ctx context.Context, cfg *config.Config, ipAndPort, pairingCode string) error {
 	adbPath := cfg.GetADBPath()
 
 	logger.Info("Pairing with %s using code %s...", ipAndPort, pairingCode)
 
-	output, err := adb.ExecuteGlobalCommandWithOutput(adbPath, "pair", ipAndPort, pairingCode)
+	output, err := adb.ExecuteGlobalCommandWithOutputContext(ctx, adbPath, "pair", ipAndPort, pairingCode)
 	if err != nil {
 		return fmt.Errorf("pairing command failed: %w", err)
 	}
@@ -30,7 +31,7 @@ func PairWiFiDevice(cfg *config.Config, ipAndPort, pairingCode string) error {
 		logger.Info("3. The tool will then set it to use port %d permanently", DefaultWiFiPort)
 		logger.Info("")
 
-		CleanupStaleWiFiConnections(cfg)
+		CleanupStaleWiFiConnections(ctx, cfg)
 
 		// For now, return success since pairing worked
 		return nil
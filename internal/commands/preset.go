@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Preset is a set of setting values applied in one step
+// A value is anything the setting takes, "default" to reset it, or for numbers a change
+// from the device default such as "+20%". The presets file keys settings by their command line names
+type Preset struct {
+	Description string
+	Settings    map[SettingType]string
+}
+
+// presetFile is a preset as the presets file writes it
+type presetFile struct {
+	Description string            `json:"description,omitempty"`
+	Settings    map[string]string `json:"settings"`
+}
+
+// MarshalJSON writes settings by their command line names
+func (p Preset) MarshalJSON() ([]byte, error) {
+	settings := make(map[string]string, len(p.Settings))
+	for settingType, value := range p.Settings {
+		settings[settingType.Name()] = value
+	}
+	return json.Marshal(presetFile{Description: p.Description, Settings: settings})
+}
+
+// UnmarshalJSON reads settings by their command line names or their SettingType form,
+// keeping unknown ones for ApplyPreset to report
+func (p *Preset) UnmarshalJSON(data []byte) error {
+	var file presetFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	p.Description = file.Description
+	p.Settings = make(map[SettingType]string, len(file.Settings))
+	for name, value := range file.Settings {
+		settingType, ok := ParseSettingName(name)
+		if !ok {
+			settingType = SettingType(name)
+		}
+		p.Settings[settingType] = value
+	}
+	return nil
+}
+
+// NamedPreset is a preset as listed, with where it comes from
+type NamedPreset struct {
+	Name    string
+	BuiltIn bool // Shipped with gadget rather than defined in the presets file
+	Preset
+}
+
+// presetDefault is the preset value that resets a setting
+const presetDefault = "default"
+
+// BuiltInPresets are offered even without a presets file; the file can replace them by name
+var BuiltInPresets = map[string]Preset{
+	"small-phone": {
+		Description: "A small, low-density phone",
+		Settings:    map[SettingType]string{SettingTypeScreenSize: "720x1280", SettingTypeDPI: "320"},
+	},
+	"a11y-max": {
+		Description: "Largest font and a display 20% larger than the default",
+		Settings:    map[SettingType]string{SettingTypeFontSize: "2.0", SettingTypeDPI: "+20%"},
+	},
+}
+
+// PresetsPath returns the file presets are defined in
+func PresetsPath(cfg *config.Config) string {
+	return filepath.Join(cfg.ConfigDir, "presets.json")
+}
+
+// LoadPresets returns the built-in presets and those in the presets file, by name
+func LoadPresets(cfg *config.Config) ([]NamedPreset, error) {
+	defined, err := loadPresetsFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var presets []NamedPreset
+	for name, preset := range BuiltInPresets {
+		if _, ok := defined[name]; !ok {
+			presets = append(presets, NamedPreset{Name: name, BuiltIn: true, Preset: preset})
+		}
+	}
+	for name, preset := range defined {
+		presets = append(presets, NamedPreset{Name: name, Preset: preset})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
+}
+
+// FindPreset looks up a preset by name
+func FindPreset(cfg *config.Config, name string) (*Preset, error) {
+	presets, err := LoadPresets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		if preset.Name == name {
+			return &preset.Preset, nil
+		}
+		names[i] = preset.Name
+	}
+	return nil, fmt.Errorf("unknown preset: %s (expected %s)", name, strings.Join(names, ", "))
+}
+
+// ApplyPreset changes every setting a preset names, in the order of SettingTypes
+// All values are checked before the first one is applied; cfg.Force lets values outside the limits through
+func ApplyPreset(ctx context.Context, cfg *config.Config, device adb.Device, name string) error {
+	preset, err := FindPreset(cfg, name)
+	if err != nil {
+		return err
+	}
+	for settingType := range preset.Settings {
+		if GetSettingHandler(settingType) == nil {
+			return fmt.Errorf("unknown setting %s in preset %s (expected %s)", settingType, name, strings.Join(settingNameList(), ", "))
+		}
+	}
+
+	// Relative values are worked out from the device's defaults first
+	values := make(map[SettingType]string, len(preset.Settings))
+	for _, settingType := range SettingTypes {
+		value, ok := preset.Settings[settingType]
+		if !ok || value == presetDefault {
+			continue
+		}
+		handler := GetSettingHandler(settingType)
+		if value, err = resolvePresetValue(ctx, cfg, device, handler, value); err != nil {
+			return fmt.Errorf("preset %s: %w", name, err)
+		}
+		if err := handler.Validate(value); err != nil && !cfg.Force {
+			return fmt.Errorf("preset %s: %w", name, err)
+		}
+		values[settingType] = value
+	}
+
+	for _, settingType := range SettingTypes {
+		if _, ok := preset.Settings[settingType]; !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		handler := GetSettingHandler(settingType)
+		if value, ok := values[settingType]; ok {
+			err = handler.SetValue(ctx, cfg, device, value)
+		} else {
+			err = handler.Reset(ctx, cfg, device)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Success("Applied preset %s to %s", name, device.Serial)
+	return nil
+}
+
+// SavePreset saves a device's current settings as a preset in the presets file,
+// replacing any preset of that name; settings at their default are saved as "default"
+func SavePreset(ctx context.Context, cfg *config.Config, device adb.Device, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("a preset needs a name")
+	}
+
+	snapshot, err := CaptureSnapshot(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	preset := Preset{
+		Description: fmt.Sprintf("Saved from %s on %s", device.Serial, snapshot.TakenAt.Format(time.DateTime)),
+		Settings:    make(map[SettingType]string, len(snapshot.Settings)),
+	}
+	for settingType, setting := range snapshot.Settings {
+		preset.Settings[settingType] = presetDefault
+		if setting.Overridden {
+			preset.Settings[settingType] = setting.Value
+		}
+	}
+
+	defined, err := loadPresetsFile(cfg)
+	if err != nil {
+		return err
+	}
+	defined[name] = preset
+	data, err := json.MarshalIndent(defined, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode presets: %w", err)
+	}
+	path := PresetsPath(cfg)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create presets directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save presets: %w", err)
+	}
+
+	logger.Success("Saved the settings of %s as preset %s in %s", device.Serial, name, path)
+	return nil
+}
+
+// FormatPresetSettings describes a preset's values in the order they are applied, e.g. "screen-size=720x1280, dpi=320"
+func FormatPresetSettings(preset Preset) string {
+	var parts []string
+	for _, settingType := range SettingTypes {
+		if value, ok := preset.Settings[settingType]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%s", settingType.Name(), value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// settingNameList lists the command line names of SettingTypes, in order
+func settingNameList() []string {
+	names := make([]string, len(SettingTypes))
+	for i, settingType := range SettingTypes {
+		names[i] = settingType.Name()
+	}
+	return names
+}
+
+// loadPresetsFile reads the presets file, which may not exist
+func loadPresetsFile(cfg *config.Config) (map[string]Preset, error) {
+	presets := make(map[string]Preset)
+	data, err := os.ReadFile(PresetsPath(cfg))
+	if errors.Is(err, os.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read presets: %w", err)
+	}
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse presets %s: %w", PresetsPath(cfg), err)
+	}
+	return presets, nil
+}
+
+// resolvePresetValue turns a change such as "+20%" into a value, from the setting's device default
+// Results are rounded to whole numbers, or to the setting's step
+func resolvePresetValue(ctx context.Context, cfg *config.Config, device adb.Device, handler SettingHandler, value string) (string, error) {
+	percent, ok := strings.CutSuffix(value, "%")
+	if !ok || (!strings.HasPrefix(percent, "+") && !strings.HasPrefix(percent, "-")) {
+		return value, nil
+	}
+	change, err := strconv.ParseFloat(percent, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid change: %s", value)
+	}
+
+	info, err := handler.GetInfo(ctx, cfg, device)
+	if err != nil {
+		return "", err
+	}
+	base, err := strconv.ParseFloat(info.Default, 64)
+	if err != nil {
+		return "", fmt.Errorf("%s can't be changed by %s, its default %s isn't a number", info.DisplayName, value, info.Default)
+	}
+
+	result := base * (1 + change/100)
+	if _, err := strconv.Atoi(info.Default); err == nil {
+		return strconv.Itoa(int(math.Round(result))), nil
+	}
+	if step := handler.Metadata().Step; step > 0 {
+		result = math.Round(result/step) * step
+	}
+	return formatScale(math.Round(result*100) / 100), nil
+}
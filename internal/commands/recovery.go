@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FixOptions controls how long FixDevices waits for devices to come back
+type FixOptions struct {
+	SettleTime           time.Duration // After reconnecting devices or restarting the server
+	AuthorizationTimeout time.Duration // For the user to accept authorization prompts
+	PollInterval         time.Duration // Between device list checks while waiting for authorization
+}
+
+// DefaultFixOptions gives devices a moment to reconnect and the user half a minute to accept prompts
+var DefaultFixOptions = FixOptions{
+	SettleTime:           2 * time.Second,
+	AuthorizationTimeout: 30 * time.Second,
+	PollInterval:         time.Second,
+}
+
+// FixDevices tries to bring unauthorized and offline devices back:
+// offline devices are reconnected, then the adb server is restarted if that didn't help,
+// and unauthorized devices are asked to show the authorization prompt again
+func FixDevices(ctx context.Context, cfg *config.Config, options FixOptions) error {
+	devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return adb.ErrNoDevices
+	}
+	if len(unhealthyDevices(devices)) == 0 {
+		logger.Success("All %d devices are ready", len(devices))
+		return nil
+	}
+
+	if offline := devicesWithStatus(devices, adb.StatusOffline); len(offline) > 0 {
+		logger.Info("Reconnecting %d offline devices...", len(offline))
+		if err := ReconnectOffline(ctx, cfg); err != nil {
+			logger.Error("Warning: reconnect failed: %v", err)
+		}
+		if devices, err = devicesAfter(ctx, cfg, options.SettleTime); err != nil {
+			return err
+		}
+
+		if offline := devicesWithStatus(devices, adb.StatusOffline); len(offline) > 0 {
+			logger.Info("%d devices still offline, restarting the adb server...", len(offline))
+			if err := RestartServer(ctx, cfg); err != nil {
+				return err
+			}
+			if devices, err = devicesAfter(ctx, cfg, options.SettleTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	if unauthorized := devicesWithStatus(devices, adb.StatusUnauthorized); len(unauthorized) > 0 {
+		for _, device := range unauthorized {
+			if err := RequestAuthorization(ctx, cfg, device); err != nil {
+				logger.Error("Warning: failed to re-request authorization on %s: %v", device.Serial, err)
+			}
+		}
+		logger.Info("Accept the \"Allow USB debugging?\" prompt on: %s", strings.Join(serials(unauthorized), ", "))
+
+		if devices, err = waitForAuthorization(ctx, cfg, options); err != nil {
+			return err
+		}
+	}
+
+	remaining := unhealthyDevices(devices)
+	if len(remaining) == 0 {
+		logger.Success("All %d devices are ready", len(devices))
+		return nil
+	}
+
+	var errs []error
+	for _, device := range remaining {
+		errs = append(errs, adb.StatusError(device))
+	}
+	return fmt.Errorf("%d of %d devices still not ready: %w", len(remaining), len(devices), errors.Join(errs...))
+}
+
+// ReconnectOffline asks the adb server to reconnect every offline device
+func ReconnectOffline(ctx context.Context, cfg *config.Config) error {
+	return adb.ExecuteGlobalCommandContext(ctx, cfg.GetADBPath(), "reconnect", "offline")
+}
+
+// RequestAuthorization drops a device's connection so it shows the authorization prompt again
+func RequestAuthorization(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return adb.ExecuteCommandContext(ctx, cfg.GetADBPath(), device.Serial, "reconnect")
+}
+
+// RegenerateADBKeys moves this computer's adb key pair aside and restarts the server,
+// which generates a new pair; every device then asks for authorization again
+// The old keys are kept with a timestamp suffix, e.g. adbkey.bak-20240102-150405; earlier backups are never overwritten
+func RegenerateADBKeys(ctx context.Context, cfg *config.Config) error {
+	// A remote server authorizes devices with the keys of its own machine
+	if adb.IsRemoteServer() {
+		return fmt.Errorf("the adb server at %s uses the keys of its own machine, regenerate them there", adb.ServerAddress())
+	}
+	keyDir, err := adbKeyDir()
+	if err != nil {
+		return err
+	}
+
+	suffix := keyBackupSuffix(keyDir, time.Now())
+	if err := StopServer(ctx, cfg); err != nil {
+		return err
+	}
+
+	for _, name := range []string{"adbkey", "adbkey.pub"} {
+		path := filepath.Join(keyDir, name)
+		if err := os.Rename(path, path+suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+	}
+	logger.Info("Moved adb keys in %s aside to adbkey%s", keyDir, suffix)
+
+	if err := StartServer(ctx, cfg); err != nil {
+		return err
+	}
+	logger.Success("Generated new adb keys, accept the authorization prompt on each device")
+	return nil
+}
+
+// keyBackupSuffix returns a backup suffix for the time that no key file in keyDir uses yet
+func keyBackupSuffix(keyDir string, now time.Time) string {
+	base := ".bak-" + now.Format("20060102-150405")
+	for n := 0; ; n++ {
+		suffix := base
+		if n > 0 {
+			suffix = fmt.Sprintf("%s-%d", base, n)
+		}
+		free := true
+		for _, name := range []string{"adbkey", "adbkey.pub"} {
+			if _, err := os.Lstat(filepath.Join(keyDir, name+suffix)); err == nil {
+				free = false
+			}
+		}
+		if free {
+			return suffix
+		}
+	}
+}
+
+// adbKeyDir returns the directory adb keeps its key pair in
+func adbKeyDir() (string, error) {
+	if dir := os.Getenv("ANDROID_USER_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find adb keys: %w", err)
+	}
+	return filepath.Join(home, ".android"), nil
+}
+
+// waitForAuthorization polls the device list until no device is unauthorized or the timeout passes
+func waitForAuthorization(ctx context.Context, cfg *config.Config, options FixOptions) ([]adb.Device, error) {
+	deadline := time.Now().Add(options.AuthorizationTimeout)
+	for {
+		devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+		if err != nil {
+			return nil, err
+		}
+		pending := devicesWithStatus(devices, adb.StatusUnauthorized)
+		if len(pending) == 0 || !time.Now().Before(deadline) {
+			return devices, nil
+		}
+		if err := Wait(ctx, min(options.PollInterval, time.Until(deadline))); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// devicesAfter waits for devices to settle and lists them again
+func devicesAfter(ctx context.Context, cfg *config.Config, settle time.Duration) ([]adb.Device, error) {
+	if err := Wait(ctx, settle); err != nil {
+		return nil, err
+	}
+	return adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
+}
+
+// unhealthyDevices returns the devices that reject commands because of their status
+func unhealthyDevices(devices []adb.Device) []adb.Device {
+	var unhealthy []adb.Device
+	for _, device := range devices {
+		if adb.StatusError(device) != nil {
+			unhealthy = append(unhealthy, device)
+		}
+	}
+	return unhealthy
+}
+
+// devicesWithStatus returns the devices in a status; unauthorized includes devices still authorizing
+func devicesWithStatus(devices []adb.Device, status string) []adb.Device {
+	var matching []adb.Device
+	for _, device := range devices {
+		if device.Status == status || (status == adb.StatusUnauthorized && device.Status == adb.StatusAuthorizing) {
+			matching = append(matching, device)
+		}
+	}
+	return matching
+}
+
+func serials(devices []adb.Device) []string {
+	result := make([]string, len(devices))
+	for i, device := range devices {
+		result[i] = device.Serial
+	}
+	return result
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -31,7 +33,8 @@ func StartScreenRecord(cfg *config.Config, device adb.Device) (*ScreenRecording,
 	remotePath := "/sdcard/" + remoteFilename
 
 	adbPath := cfg.GetADBPath()
-	cmd := exec.Command(adbPath, "-s", device.Serial, "shell", "screenrecord", remotePath)
+	args := append(adb.ServerArgs(), "-s", device.Serial, "shell", "screenrecord", remotePath)
+	cmd := exec.Command(adbPath, args...)
 
 	err := cmd.Start()
 	if err != nil {
@@ -50,58 +53,40 @@ func StartScreenRecord(cfg *config.Config, device adb.Device) (*ScreenRecording,
 }
 
 // StopAndSave stops the recording and saves it to local machine
-func (r *ScreenRecording) StopAndSave() error {
+// Cancelling ctx abandons the transfer; the recording itself is always stopped
+func (r *ScreenRecording) StopAndSave(ctx context.Context) error {
 	if r.Cmd != nil && r.Cmd.Process != nil {
 		err := r.Cmd.Process.Signal(syscall.SIGINT)
-		if err != nil {
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
 			return fmt.Errorf("failed to stop recording: %w", err)
 		}
 
 		r.Cmd.Wait()
 	}
 
-	time.Sleep(2 * time.Second)
-
-	adbPath := r.Config.GetADBPath()
-	checkCmd := exec.Command(adbPath, "-s", r.Device.Serial, "shell", "ls", "-la", r.RemotePath)
-	checkOutput, checkErr := checkCmd.CombinedOutput()
-	if checkErr != nil {
-		return fmt.Errorf("recording file not found on device: %s", string(checkOutput))
+	// Give screenrecord time to finalize the file
+	if err := Wait(ctx, 2*time.Second); err != nil {
+		return fmt.Errorf("saving recording cancelled: %w", err)
 	}
 
-	logger.Info("File on device: %s", string(checkOutput))
-
 	localDir := filepath.Dir(r.LocalPath)
 	if err := os.MkdirAll(localDir, 0755); err != nil {
 		return fmt.Errorf("failed to create local directory %s: %w", localDir, err)
 	}
 
-	// Try to pull the file from device - try different approaches
-	logger.Info("Attempting pull command: %s -s %s pull %s %s", adbPath, r.Device.Serial, r.RemotePath, r.LocalPath)
-	pullCmd := exec.Command(adbPath, "-s", r.Device.Serial, "pull", r.RemotePath, r.LocalPath)
-	pullCmd.Stderr = nil
-	pullCmd.Stdout = nil
-	pullOutput, err := pullCmd.CombinedOutput()
-
+	adbPath := r.Config.GetADBPath()
+	err := pullFile(ctx, adbPath, r.Device, r.RemotePath, r.LocalPath)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("saving recording cancelled, file left on device at %s: %w", r.RemotePath, ctx.Err())
+	}
+	if adb.IsRemoteFileNotFound(err) {
+		return fmt.Errorf("recording file not found on device: %w", err)
+	}
 	if err != nil {
-		logger.Error("Pull attempt 1 failed. Error: %v", err)
-		logger.Error("Pull attempt 1 output: %q", string(pullOutput))
-
-		// Second try: without device serial (if only one device)
-		pullCmd2 := exec.Command(adbPath, "pull", r.RemotePath, r.LocalPath)
-		pullOutput2, err2 := pullCmd2.CombinedOutput()
-
-		if err2 != nil {
-			logger.Error("Pull attempt 2 failed. Error: %v", err2)
-			logger.Error("Pull attempt 2 output: %q", string(pullOutput2))
-
-			return fmt.Errorf("both pull attempts failed. First: %v (output: %q), Second: %v (output: %q)",
-				err, string(pullOutput), err2, string(pullOutput2))
-		}
+		return fmt.Errorf("failed to pull recording, file left on device at %s: %w", r.RemotePath, err)
 	}
 
-	cleanCmd := exec.Command(adbPath, "-s", r.Device.Serial, "shell", "rm", r.RemotePath)
-	cleanCmd.Run() // Ignore cleanup errors
+	CleanupRemoteFile(adbPath, r.Device.Serial, r.RemotePath)
 
 	logger.Success("Screen recording saved to: %s", r.LocalPath)
 	return nil
@@ -1,20 +1,23 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
 	"gadget/internal/logger"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
-func TakeScreenshot(cfg *config.Config, device adb.Device) error {
-	return takeScreenshot(cfg, device, "")
+func TakeScreenshot(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return takeScreenshot(ctx, cfg, device, "")
 }
 
-func takeScreenshot(cfg *config.Config, device adb.Device, suffix string) error {
+func takeScreenshot(ctx context.Context, cfg *config.Config, device adb.Device, suffix string) error {
 	timestamp := time.Now().Format("2006-01-02_15-04-05")
+	timestamp += deviceFileTag(ctx, device)
 	var filename string
 	if suffix == "" {
 		filename = fmt.Sprintf("android-img-%s.png", timestamp)
@@ -25,74 +28,118 @@ func takeScreenshot(cfg *config.Config, device adb.Device, suffix string) error
 	remotePath := "/sdcard/screenshot.png"
 	adbPath := cfg.GetADBPath()
 
-	err := adb.ExecuteCommand(adbPath, device.Serial, "shell", "screencap", remotePath)
+	err := adb.ExecuteCommandContext(ctx, adbPath, device.Serial, "shell", "screencap", remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to take screenshot: %w", err)
 	}
 
-	err = adb.ExecuteCommand(adbPath, device.Serial, "pull", remotePath, localPath)
+	err = pullFile(ctx, adbPath, device, remotePath, localPath)
+	CleanupRemoteFile(adbPath, device.Serial, remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to pull screenshot: %w", err)
 	}
 
-	adb.ExecuteCommand(adbPath, device.Serial, "shell", "rm", remotePath)
-
 	logger.Success("Screenshot saved to: %s", localPath)
 	return nil
 }
 
-func SetDarkMode(cfg *config.Config, device adb.Device, enabled bool) error {
-	adbPath := cfg.GetADBPath()
-	mode := "no"
-	if enabled {
-		mode = "yes"
-	}
-
-	return adb.ExecuteCommand(adbPath, device.Serial, "shell", "cmd", "uimode", "night", mode)
+func TakeDayNightScreenshots(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return takeDayNightScreenshots(ctx, cfg, device)
 }
 
-func TakeDayNightScreenshots(cfg *config.Config, device adb.Device) error {
-	return takeDayNightScreenshots(cfg, device)
-}
-
-func takeDayNightScreenshots(cfg *config.Config, device adb.Device) error {
+func takeDayNightScreenshots(ctx context.Context, cfg *config.Config, device adb.Device) error {
 	logger.Info("Taking day and night screenshots of %s", device.Serial)
 
+	// The device is put back in whatever mode it was in, not left in light mode
+	originalMode, err := GetNightMode(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
 	logger.Info("Setting light mode...")
-	err := SetDarkMode(cfg, device, false)
+	err = SetDarkMode(ctx, cfg, device, false)
 	if err != nil {
 		return fmt.Errorf("failed to set light mode: %w", err)
 	}
 
-	time.Sleep(2 * time.Second) // Wait for UI to update
+	if err := Wait(ctx, 2*time.Second); err != nil { // Wait for UI to update
+		RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
 
 	logger.Info("Taking day screenshot...")
-	err = takeScreenshot(cfg, device, "day")
+	err = takeScreenshot(ctx, cfg, device, "day")
 	if err != nil {
+		RestoreNightMode(cfg, device, originalMode)
 		return fmt.Errorf("failed to take day screenshot: %w", err)
 	}
 
 	logger.Info("Setting dark mode...")
-	err = SetDarkMode(cfg, device, true)
+	err = SetDarkMode(ctx, cfg, device, true)
 	if err != nil {
+		RestoreNightMode(cfg, device, originalMode)
 		return fmt.Errorf("failed to set dark mode: %w", err)
 	}
 
-	time.Sleep(2 * time.Second) // Wait for UI to update
+	if err := Wait(ctx, 2*time.Second); err != nil { // Wait for UI to update
+		RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
 
 	logger.Info("Taking night screenshot...")
-	err = takeScreenshot(cfg, device, "night")
+	err = takeScreenshot(ctx, cfg, device, "night")
 	if err != nil {
+		RestoreNightMode(cfg, device, originalMode)
 		return fmt.Errorf("failed to take night screenshot: %w", err)
 	}
 
-	logger.Info("Restoring light mode...")
-	time.Sleep(2 * time.Second)
-	err = SetDarkMode(cfg, device, false)
+	logger.Info("Restoring night mode %s...", originalMode)
+	if err := Wait(ctx, 2*time.Second); err != nil {
+		RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
+	err = SetNightMode(ctx, cfg, device, originalMode)
+	if err != nil {
+		logger.Error("Warning: failed to restore night mode %s: %v", originalMode, err)
+	}
+
+	return nil
+}
+
+// TakeLocaleScreenshots takes a screenshot in each locale, named after it, then puts the original locale back
+func TakeLocaleScreenshots(ctx context.Context, cfg *config.Config, device adb.Device, locales []string) error {
+	logger.Info("Taking screenshots of %s in %s", device.Serial, strings.Join(locales, ", "))
+
+	// Each locale has to apply before its screenshot, which takes a framework restart
+	if !hasRoot(ctx, cfg, device) {
+		return fmt.Errorf("locale screenshots restart Android, which needs root on %s, try \"adb root\"", device.Serial)
+	}
+	original, err := GetLocale(ctx, cfg, device)
 	if err != nil {
-		logger.Error("Warning: failed to restore light mode: %v", err)
+		return err
 	}
 
+	for _, locale := range locales {
+		logger.Info("Setting locale %s...", locale)
+		if err := SetLocale(ctx, cfg, device, locale); err != nil {
+			RestoreLocale(ctx, cfg, device, original)
+			return err
+		}
+
+		if err := Wait(ctx, 2*time.Second); err != nil { // Wait for the launcher to redraw
+			RestoreLocale(ctx, cfg, device, original)
+			return err
+		}
+
+		logger.Info("Taking %s screenshot...", locale)
+		if err := takeScreenshot(ctx, cfg, device, locale); err != nil {
+			RestoreLocale(ctx, cfg, device, original)
+			return fmt.Errorf("failed to take %s screenshot: %w", locale, err)
+		}
+	}
+
+	logger.Info("Restoring locale %s...", original.Current)
+	RestoreLocale(ctx, cfg, device, original)
 	return nil
 }
 
@@ -100,3 +147,16 @@ func takeDayNightScreenshots(cfg *config.Config, device adb.Device) error {
 func CleanupRemoteFile(adbPath, serial, remotePath string) {
 	adb.ExecuteCommand(adbPath, serial, "shell", "rm", remotePath)
 }
+
+// Wait pauses for the given duration, returning early with the context's error if it is cancelled
+func Wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
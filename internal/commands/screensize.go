@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -10,13 +11,14 @@ import (
 )
 
 type ScreenSizeInfo struct {
-	Physical string
-	Current  string // The effective screen size (override if exists, otherwise physical)
+	Physical   string
+	Current    string // The effective screen size (override if exists, otherwise physical)
+	Overridden bool   // Whether an override size is set
 }
 
-func GetCurrentScreenSize(cfg *config.Config, device adb.Device) (*ScreenSizeInfo, error) {
+func GetCurrentScreenSize(ctx context.Context, cfg *config.Config, device adb.Device) (*ScreenSizeInfo, error) {
 	adbPath := cfg.GetADBPath()
-	output, err := adb.ExecuteCommandWithOutput(adbPath, device.Serial, "shell", "wm", "size")
+	output, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "wm", "size")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current screen size: %w", err)
 	}
@@ -33,6 +35,7 @@ func GetCurrentScreenSize(cfg *config.Config, device adb.Device) (*ScreenSizeInf
 			parts := strings.Fields(line)
 			if len(parts) >= 3 {
 				info.Current = parts[2]
+				info.Overridden = true
 			}
 		} else if strings.Contains(line, "Physical size:") {
 			parts := strings.Fields(line)
@@ -54,22 +57,17 @@ func GetCurrentScreenSize(cfg *config.Config, device adb.Device) (*ScreenSizeInf
 	return info, nil
 }
 
-func SetScreenSize(cfg *config.Config, device adb.Device, size string) error {
-	// Validate format (should be like "1080x1920")
-	parts := strings.Split(size, "x")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid screen size format: %s (expected format: 1080x1920)", size)
+func SetScreenSize(ctx context.Context, cfg *config.Config, device adb.Device, size string) error {
+	if _, _, err := parseScreenSize(size); err != nil {
+		return err
 	}
 
-	// Validate that both parts are numbers
-	for _, part := range parts {
-		if _, err := strconv.Atoi(part); err != nil {
-			return fmt.Errorf("invalid screen size format: %s (both width and height must be numbers)", size)
-		}
+	if err := recordChange(ctx, device, SettingTypeScreenSize); err != nil {
+		return err
 	}
 
 	adbPath := cfg.GetADBPath()
-	err := adb.ExecuteCommand(adbPath, device.Serial, "shell", "wm", "size", size)
+	_, err := adb.ExecuteShellContext(ctx, adbPath, device.Serial, "wm", "size", size)
 	if err != nil {
 		return fmt.Errorf("failed to set screen size to %s: %w", size, err)
 	}
@@ -77,3 +75,36 @@ func SetScreenSize(cfg *config.Config, device adb.Device, size string) error {
 	logger.Success("Screen size changed to %s on device %s", size, device.Serial)
 	return nil
 }
+
+// ResetScreenSize clears the size override, back to the physical size
+func ResetScreenSize(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := recordChange(ctx, device, SettingTypeScreenSize); err != nil {
+		return err
+	}
+
+	_, err := adb.ExecuteShellContext(ctx, cfg.GetADBPath(), device.Serial, "wm", "size", "reset")
+	if err != nil {
+		return fmt.Errorf("failed to reset screen size: %w", err)
+	}
+
+	logger.Success("Screen size reset to physical size on device %s", device.Serial)
+	return nil
+}
+
+// parseScreenSize reads width and height from a size like "1080x1920"
+func parseScreenSize(size string) (int, int, error) {
+	parts := strings.Split(size, "x")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid screen size format: %s (expected format: 1080x1920)", size)
+	}
+
+	width, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid screen size format: %s (both width and height must be numbers)", size)
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid screen size format: %s (both width and height must be numbers)", size)
+	}
+	return width, height, nil
+}
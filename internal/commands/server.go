@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+)
+
+// StartServer starts the adb server if it isn't running
+func StartServer(ctx context.Context, cfg *config.Config) error {
+	if err := adb.ExecuteGlobalCommandContext(ctx, cfg.GetADBPath(), "start-server"); err != nil {
+		return fmt.Errorf("failed to start adb server: %w", err)
+	}
+	return nil
+}
+
+// StopServer stops the adb server, disconnecting every device
+func StopServer(ctx context.Context, cfg *config.Config) error {
+	if err := adb.ExecuteGlobalCommandContext(ctx, cfg.GetADBPath(), "kill-server"); err != nil {
+		return fmt.Errorf("failed to stop adb server: %w", err)
+	}
+	adb.CloseShellSessions()
+	return nil
+}
+
+// RestartServer stops and starts the adb server, reconnecting every device
+func RestartServer(ctx context.Context, cfg *config.Config) error {
+	if err := StopServer(ctx, cfg); err != nil {
+		return err
+	}
+	return StartServer(ctx, cfg)
+}
+
+// ShowServerVersion logs the adb binary and server versions and fails if they don't match
+func ShowServerVersion(ctx context.Context, cfg *config.Config) error {
+	client, server, err := adb.CheckServerVersion(ctx, cfg.GetADBPath())
+	if client != 0 {
+		logger.Info("adb binary: protocol version %d (%s)", client, cfg.GetADBPath())
+	}
+
+	var connErr *adb.ConnectionError
+	if errors.As(err, &connErr) {
+		return fmt.Errorf("adb server not running at %s", adb.ServerAddress())
+	}
+	if server != 0 {
+		logger.Info("adb server: protocol version %d (%s)", server, adb.ServerAddress())
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Success("adb binary and server versions match")
+	return nil
+}
@@ -1,10 +1,15 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
+	"gadget/internal/logger"
+	"math"
 	"strconv"
+	"strings"
 )
 
 // SettingType represents different types of device settings
@@ -14,6 +19,124 @@ const (
 	SettingTypeDPI        SettingType = "dpi"
 	SettingTypeFontSize   SettingType = "fontsize"
 	SettingTypeScreenSize SettingType = "screensize"
+	SettingTypeAnimations SettingType = "animations"
+	SettingTypeNightMode  SettingType = "nightmode"
+	SettingTypeLocale     SettingType = "locale"
+	SettingTypeOverlays   SettingType = "overlays"
+
+	SettingTypeTalkBack         SettingType = "talkback"
+	SettingTypeHighContrastText SettingType = "highcontrasttext"
+	SettingTypeColorInversion   SettingType = "colorinversion"
+	SettingTypeColorCorrection  SettingType = "colorcorrection"
+)
+
+// SettingTypes lists every setting gadget can change, in the order they are applied
+var SettingTypes = []SettingType{
+	SettingTypeScreenSize, SettingTypeDPI, SettingTypeFontSize, SettingTypeAnimations, SettingTypeNightMode,
+	SettingTypeTalkBack, SettingTypeHighContrastText, SettingTypeColorInversion, SettingTypeColorCorrection,
+	SettingTypeOverlays, SettingTypeLocale,
+}
+
+// settingNames are the names the command line and presets use for settings
+var settingNames = map[SettingType]string{
+	SettingTypeDPI:              "dpi",
+	SettingTypeFontSize:         "font-size",
+	SettingTypeScreenSize:       "screen-size",
+	SettingTypeAnimations:       "animations",
+	SettingTypeNightMode:        "dark-mode",
+	SettingTypeLocale:           "locale",
+	SettingTypeOverlays:         "overlays",
+	SettingTypeTalkBack:         "talkback",
+	SettingTypeHighContrastText: "high-contrast-text",
+	SettingTypeColorInversion:   "color-inversion",
+	SettingTypeColorCorrection:  "color-correction",
+}
+
+// Name returns the setting's name on the command line, e.g. "font-size"
+func (t SettingType) Name() string {
+	if name, ok := settingNames[t]; ok {
+		return name
+	}
+	return string(t)
+}
+
+// ParseSettingName looks up a setting by its command line name, also accepting the SettingType form such as "fontsize"
+func ParseSettingName(name string) (SettingType, bool) {
+	for settingType, settingName := range settingNames {
+		if name == settingName || name == string(settingType) {
+			return settingType, true
+		}
+	}
+	return "", false
+}
+
+// AccessibilitySettingTypes lists the accessibility features among SettingTypes
+var AccessibilitySettingTypes = []SettingType{
+	SettingTypeTalkBack, SettingTypeHighContrastText, SettingTypeColorInversion, SettingTypeColorCorrection,
+}
+
+// ErrValueOutOfRange is returned by Validate for values a setting only takes when forced
+var ErrValueOutOfRange = errors.New("value out of range")
+
+// SettingMetadata describes the values a setting accepts
+type SettingMetadata struct {
+	Min         float64 // For screen sizes, the limits apply to width and height
+	Max         float64
+	Step        float64 // Values must be a multiple of Step; zero allows any value
+	Unit        string
+	Suggestions []string // Common values, smallest first
+	Choices     []string // Every value of a setting that isn't a number; the limits are unused
+}
+
+var (
+	// Density buckets from ldpi to xxxhdpi, plus the 420 and 560 common on phones
+	dpiMetadata = SettingMetadata{
+		Min:         120,
+		Max:         640,
+		Unit:        "dpi",
+		Suggestions: []string{"120", "160", "213", "240", "320", "420", "480", "560", "640"},
+	}
+	// The scales Android offers in its font size settings; the scale has no unit
+	fontSizeMetadata = SettingMetadata{
+		Min:         0.5,
+		Max:         2.0,
+		Step:        0.05,
+		Suggestions: []string{"0.85", "1.0", "1.15", "1.3", "1.5", "1.8", "2.0"},
+	}
+	screenSizeMetadata = SettingMetadata{
+		Min:         240,
+		Max:         8192,
+		Unit:        "px",
+		Suggestions: []string{"720x1280", "1080x1920", "1080x2400", "1440x3120"},
+	}
+	// The scales the developer options offer; the limits apply to each of the three scales
+	animationsMetadata = SettingMetadata{
+		Min:         0,
+		Max:         10,
+		Step:        0.5,
+		Suggestions: []string{"off", "0.5", "1.0", "1.5", "2.0", "5.0", "10.0"},
+	}
+	nightModeMetadata = SettingMetadata{
+		Suggestions: NightModes,
+		Choices:     NightModes,
+	}
+	switchMetadata = SettingMetadata{
+		Suggestions: SwitchValues,
+		Choices:     SwitchValues,
+	}
+	colorCorrectionMetadata = SettingMetadata{
+		Suggestions: colorCorrectionChoices,
+		Choices:     colorCorrectionChoices,
+	}
+	// Any combination of overlays is allowed; the choices are the ones it is made of
+	overlaysMetadata = SettingMetadata{
+		Suggestions: append([]string{noDebugOverlays}, debugOverlayNames()...),
+		Choices:     append([]string{noDebugOverlays}, debugOverlayNames()...),
+	}
+	// Any language tag is allowed; the suggestions end with the pseudo-locales
+	localeMetadata = SettingMetadata{
+		Suggestions: []string{"en-US", "en-GB", "de-DE", "fr-FR", "es-ES", "ja-JP", "zh-CN", "ar-EG", "he-IL", "en-XA", "ar-XB"},
+	}
 )
 
 type SettingInfo struct {
@@ -22,16 +145,45 @@ type SettingInfo struct {
 	Current     string
 	Default     string
 	InputPrompt string
+	Overridden  bool // Whether Current was set on the device rather than being its default
 }
 
 // SettingHandler defines the interface for device settings
 type SettingHandler interface {
-	GetInfo(cfg *config.Config, device adb.Device) (*SettingInfo, error)
-	SetValue(cfg *config.Config, device adb.Device, value string) error
+	GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error)
+	SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error
+	Reset(ctx context.Context, cfg *config.Config, device adb.Device) error // Clears any override, back to the device default
+	Metadata() SettingMetadata
+	// Validate checks a value is well formed and within the metadata limits
+	// SetValue only checks the format, so values outside the limits can still be forced
+	Validate(value string) error
 }
 
 // GetSettingHandler returns the appropriate handler for a setting type
+// Changes made through it are kept in the device's history
 func GetSettingHandler(settingType SettingType) SettingHandler {
+	handler := newSettingHandler(settingType)
+	if handler == nil {
+		return nil
+	}
+	return &historyHandler{SettingHandler: handler, settingType: settingType}
+}
+
+// ResetSettings resets each setting in turn, stopping at the first that fails or when ctx is cancelled
+func ResetSettings(ctx context.Context, cfg *config.Config, device adb.Device, settingTypes []SettingType) error {
+	for _, settingType := range settingTypes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := GetSettingHandler(settingType).Reset(ctx, cfg, device); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newSettingHandler returns the handler that reads and changes a setting on the device
+func newSettingHandler(settingType SettingType) SettingHandler {
 	switch settingType {
 	case SettingTypeDPI:
 		return &dpiHandler{}
@@ -39,6 +191,22 @@ func GetSettingHandler(settingType SettingType) SettingHandler {
 		return &fontSizeHandler{}
 	case SettingTypeScreenSize:
 		return &screenSizeHandler{}
+	case SettingTypeAnimations:
+		return &animationsHandler{}
+	case SettingTypeNightMode:
+		return &nightModeHandler{}
+	case SettingTypeLocale:
+		return &localeHandler{}
+	case SettingTypeOverlays:
+		return &overlaysHandler{}
+	case SettingTypeTalkBack:
+		return &talkBackHandler{}
+	case SettingTypeHighContrastText:
+		return &accessibilitySwitchHandler{highContrastTextSwitch}
+	case SettingTypeColorInversion:
+		return &accessibilitySwitchHandler{colorInversionSwitch}
+	case SettingTypeColorCorrection:
+		return &colorCorrectionHandler{}
 	default:
 		return nil
 	}
@@ -46,8 +214,8 @@ func GetSettingHandler(settingType SettingType) SettingHandler {
 
 type dpiHandler struct{}
 
-func (h *dpiHandler) GetInfo(cfg *config.Config, device adb.Device) (*SettingInfo, error) {
-	dpiInfo, err := GetCurrentDPI(cfg, device)
+func (h *dpiHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	dpiInfo, err := GetCurrentDPI(ctx, cfg, device)
 	if err != nil {
 		return nil, err
 	}
@@ -58,21 +226,49 @@ func (h *dpiHandler) GetInfo(cfg *config.Config, device adb.Device) (*SettingInf
 		Current:     fmt.Sprintf("%d", dpiInfo.Current),
 		Default:     fmt.Sprintf("%d", dpiInfo.Physical),
 		InputPrompt: "Enter new DPI:",
+		Overridden:  dpiInfo.Override > 0,
 	}, nil
 }
 
-func (h *dpiHandler) SetValue(cfg *config.Config, device adb.Device, value string) error {
+func (h *dpiHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	dpi, err := parseDPI(value)
+	if err != nil {
+		return err
+	}
+	return SetDPI(ctx, cfg, device, dpi)
+}
+
+func (h *dpiHandler) Metadata() SettingMetadata {
+	return dpiMetadata
+}
+
+func (h *dpiHandler) Validate(value string) error {
+	dpi, err := parseDPI(value)
+	if err != nil {
+		return err
+	}
+	return dpiMetadata.check("DPI", float64(dpi))
+}
+
+func parseDPI(value string) (int, error) {
 	dpi, err := strconv.Atoi(value)
 	if err != nil {
-		return fmt.Errorf("invalid DPI value: %s", value)
+		return 0, fmt.Errorf("invalid DPI value: %s", value)
 	}
-	return SetDPI(cfg, device, dpi)
+	return dpi, nil
+}
+
+func (h *dpiHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := ResetDPI(ctx, cfg, device); err != nil {
+		return err
+	}
+	return forgetEmulation(cfg, device)
 }
 
 type fontSizeHandler struct{}
 
-func (h *fontSizeHandler) GetInfo(cfg *config.Config, device adb.Device) (*SettingInfo, error) {
-	fontInfo, err := GetCurrentFontSize(cfg, device)
+func (h *fontSizeHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	fontInfo, err := GetCurrentFontSize(ctx, cfg, device)
 	if err != nil {
 		return nil, err
 	}
@@ -80,24 +276,49 @@ func (h *fontSizeHandler) GetInfo(cfg *config.Config, device adb.Device) (*Setti
 	return &SettingInfo{
 		Type:        SettingTypeFontSize,
 		DisplayName: "Font size",
-		Current:     fmt.Sprintf("%.1f", fontInfo.Current),
-		Default:     fmt.Sprintf("%.1f", fontInfo.Default),
+		Current:     formatScale(fontInfo.Current),
+		Default:     formatScale(fontInfo.Default),
 		InputPrompt: "Enter new font size (e.g., 1.2):",
+		Overridden:  fontInfo.Overridden,
 	}, nil
 }
 
-func (h *fontSizeHandler) SetValue(cfg *config.Config, device adb.Device, value string) error {
-	scale, err := strconv.ParseFloat(value, 64)
+func (h *fontSizeHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	scale, err := parseFontScale(value)
+	if err != nil {
+		return err
+	}
+	return SetFontSize(ctx, cfg, device, scale)
+}
+
+func (h *fontSizeHandler) Metadata() SettingMetadata {
+	return fontSizeMetadata
+}
+
+func (h *fontSizeHandler) Validate(value string) error {
+	scale, err := parseFontScale(value)
 	if err != nil {
-		return fmt.Errorf("invalid font size value: %s", value)
+		return err
+	}
+	return fontSizeMetadata.check("Font size", scale)
+}
+
+func parseFontScale(value string) (float64, error) {
+	scale, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		return 0, fmt.Errorf("invalid font size value: %s", value)
 	}
-	return SetFontSize(cfg, device, scale)
+	return scale, nil
+}
+
+func (h *fontSizeHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return ResetFontSize(ctx, cfg, device)
 }
 
 type screenSizeHandler struct{}
 
-func (h *screenSizeHandler) GetInfo(cfg *config.Config, device adb.Device) (*SettingInfo, error) {
-	screenInfo, err := GetCurrentScreenSize(cfg, device)
+func (h *screenSizeHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	screenInfo, err := GetCurrentScreenSize(ctx, cfg, device)
 	if err != nil {
 		return nil, err
 	}
@@ -108,9 +329,364 @@ func (h *screenSizeHandler) GetInfo(cfg *config.Config, device adb.Device) (*Set
 		Current:     screenInfo.Current,
 		Default:     screenInfo.Physical,
 		InputPrompt: "Enter new screen size (e.g., 1080x1920):",
+		Overridden:  screenInfo.Overridden,
+	}, nil
+}
+
+func (h *screenSizeHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	return SetScreenSize(ctx, cfg, device, value)
+}
+
+func (h *screenSizeHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := ResetScreenSize(ctx, cfg, device); err != nil {
+		return err
+	}
+	return forgetEmulation(cfg, device)
+}
+
+func (h *screenSizeHandler) Metadata() SettingMetadata {
+	return screenSizeMetadata
+}
+
+func (h *screenSizeHandler) Validate(value string) error {
+	width, height, err := parseScreenSize(value)
+	if err != nil {
+		return err
+	}
+	if err := screenSizeMetadata.check("Screen width", float64(width)); err != nil {
+		return err
+	}
+	return screenSizeMetadata.check("Screen height", float64(height))
+}
+
+type animationsHandler struct{}
+
+func (h *animationsHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	animationInfo, err := GetAnimationScales(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeAnimations,
+		DisplayName: "Animation scale",
+		Current:     formatAnimationScales(animationInfo.Scales),
+		Default:     formatScale(defaultAnimationScale),
+		InputPrompt: "Enter new animation scale (e.g., off, 0.5 or window=0.5,animator=0):",
+		Overridden:  animationInfo.Overridden,
+	}, nil
+}
+
+func (h *animationsHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	scales, err := parseAnimationScales(value)
+	if err != nil {
+		return err
+	}
+	return SetAnimationScales(ctx, cfg, device, scales)
+}
+
+func (h *animationsHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return ResetAnimationScales(ctx, cfg, device)
+}
+
+func (h *animationsHandler) Metadata() SettingMetadata {
+	return animationsMetadata
+}
+
+func (h *animationsHandler) Validate(value string) error {
+	scales, err := parseAnimationScales(value)
+	if err != nil {
+		return err
+	}
+	for _, s := range AnimationScales {
+		if scale, ok := scales[s.Name]; ok {
+			if err := animationsMetadata.check(s.Name+" animation scale", scale); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type nightModeHandler struct{}
+
+func (h *nightModeHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	mode, err := GetNightMode(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeNightMode,
+		DisplayName: "Dark mode",
+		Current:     mode,
+		Default:     defaultNightMode,
+		InputPrompt: "Enter dark mode (yes, no, auto or custom):",
+		Overridden:  mode != defaultNightMode,
 	}, nil
 }
 
-func (h *screenSizeHandler) SetValue(cfg *config.Config, device adb.Device, value string) error {
-	return SetScreenSize(cfg, device, value)
+func (h *nightModeHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	mode, err := parseNightMode(value)
+	if err != nil {
+		return err
+	}
+	if err := SetNightMode(ctx, cfg, device, mode); err != nil {
+		return fmt.Errorf("failed to set dark mode to %s: %w", mode, err)
+	}
+
+	logger.Success("Dark mode changed to %s on device %s", mode, device.Serial)
+	return nil
+}
+
+func (h *nightModeHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	if err := SetNightMode(ctx, cfg, device, defaultNightMode); err != nil {
+		return fmt.Errorf("failed to reset dark mode: %w", err)
+	}
+
+	logger.Success("Dark mode reset to %s on device %s", defaultNightMode, device.Serial)
+	return nil
+}
+
+func (h *nightModeHandler) Metadata() SettingMetadata {
+	return nightModeMetadata
+}
+
+func (h *nightModeHandler) Validate(value string) error {
+	_, err := parseNightMode(value)
+	return err
+}
+
+type overlaysHandler struct{}
+
+func (h *overlaysHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	enabled, err := GetDebugOverlays(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeOverlays,
+		DisplayName: "Debug overlays",
+		Current:     formatDebugOverlays(enabled),
+		Default:     noDebugOverlays,
+		InputPrompt: "Enter the overlays to show (e.g., layout-bounds,taps, or none):",
+		Overridden:  len(enabled) > 0,
+	}, nil
+}
+
+func (h *overlaysHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	enabled, err := parseDebugOverlays(value)
+	if err != nil {
+		return err
+	}
+	return SetDebugOverlays(ctx, cfg, device, enabled)
+}
+
+func (h *overlaysHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return ResetDebugOverlays(ctx, cfg, device)
+}
+
+func (h *overlaysHandler) Metadata() SettingMetadata {
+	return overlaysMetadata
+}
+
+func (h *overlaysHandler) Validate(value string) error {
+	_, err := parseDebugOverlays(value)
+	return err
+}
+
+type talkBackHandler struct{}
+
+func (h *talkBackHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	on, err := GetTalkBack(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeTalkBack,
+		DisplayName: "TalkBack",
+		Current:     formatSwitch(on),
+		Default:     "off",
+		InputPrompt: "Turn TalkBack on or off:",
+		Overridden:  on,
+	}, nil
+}
+
+func (h *talkBackHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	on, err := ParseSwitch(value)
+	if err != nil {
+		return err
+	}
+	return SetTalkBack(ctx, cfg, device, on)
+}
+
+func (h *talkBackHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return SetTalkBack(ctx, cfg, device, false)
+}
+
+func (h *talkBackHandler) Metadata() SettingMetadata {
+	return switchMetadata
+}
+
+func (h *talkBackHandler) Validate(value string) error {
+	_, err := ParseSwitch(value)
+	return err
+}
+
+// accessibilitySwitchHandler handles the accessibility features that are a single secure setting
+type accessibilitySwitchHandler struct {
+	accessibilitySwitch
+}
+
+func (h *accessibilitySwitchHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	on, err := h.get(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        h.settingType,
+		DisplayName: h.displayName,
+		Current:     formatSwitch(on),
+		Default:     "off",
+		InputPrompt: fmt.Sprintf("Turn %s on or off:", strings.ToLower(h.displayName)),
+		Overridden:  on,
+	}, nil
+}
+
+func (h *accessibilitySwitchHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	on, err := ParseSwitch(value)
+	if err != nil {
+		return err
+	}
+	return h.set(ctx, cfg, device, on)
+}
+
+func (h *accessibilitySwitchHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return h.reset(ctx, cfg, device)
+}
+
+func (h *accessibilitySwitchHandler) Metadata() SettingMetadata {
+	return switchMetadata
+}
+
+func (h *accessibilitySwitchHandler) Validate(value string) error {
+	_, err := ParseSwitch(value)
+	return err
+}
+
+type colorCorrectionHandler struct{}
+
+func (h *colorCorrectionHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	mode, err := GetColorCorrection(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeColorCorrection,
+		DisplayName: "Color correction",
+		Current:     mode,
+		Default:     "off",
+		InputPrompt: "Enter color correction (deuteranomaly, protanomaly, tritanomaly, monochromacy or off):",
+		Overridden:  mode != "off",
+	}, nil
+}
+
+func (h *colorCorrectionHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	mode, err := parseColorCorrection(value)
+	if err != nil {
+		return err
+	}
+	return SetColorCorrection(ctx, cfg, device, mode)
+}
+
+func (h *colorCorrectionHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return ResetColorCorrection(ctx, cfg, device)
+}
+
+func (h *colorCorrectionHandler) Metadata() SettingMetadata {
+	return colorCorrectionMetadata
+}
+
+func (h *colorCorrectionHandler) Validate(value string) error {
+	_, err := parseColorCorrection(value)
+	return err
+}
+
+type localeHandler struct{}
+
+func (h *localeHandler) GetInfo(ctx context.Context, cfg *config.Config, device adb.Device) (*SettingInfo, error) {
+	localeInfo, err := GetLocale(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SettingInfo{
+		Type:        SettingTypeLocale,
+		DisplayName: "Locale",
+		Current:     localeInfo.Current,
+		Default:     localeInfo.Default,
+		InputPrompt: "Enter new locale (e.g., fr-FR, or en-XA and ar-XB for pseudo-locales):",
+		Overridden:  localeInfo.Overridden,
+	}, nil
+}
+
+func (h *localeHandler) SetValue(ctx context.Context, cfg *config.Config, device adb.Device, value string) error {
+	locale, err := parseLocale(value)
+	if err != nil {
+		return err
+	}
+	return SetLocale(ctx, cfg, device, locale)
+}
+
+func (h *localeHandler) Reset(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	return ResetLocale(ctx, cfg, device)
+}
+
+func (h *localeHandler) Metadata() SettingMetadata {
+	return localeMetadata
+}
+
+func (h *localeHandler) Validate(value string) error {
+	_, err := parseLocale(value)
+	return err
+}
+
+// Range describes the allowed values, e.g. "120–640 dpi", "0.5–2 in steps of 0.05" or "yes, no, auto, custom"
+// It is empty for settings without limits
+func (m SettingMetadata) Range() string {
+	if len(m.Choices) > 0 {
+		return strings.Join(m.Choices, ", ")
+	}
+	if m.Min == 0 && m.Max == 0 {
+		return ""
+	}
+	limits := formatLimit(m.Min) + "–" + formatLimit(m.Max)
+	if m.Unit != "" {
+		limits += " " + m.Unit
+	}
+	if m.Step > 0 {
+		limits += " in steps of " + formatLimit(m.Step)
+	}
+	return limits
+}
+
+// check returns ErrValueOutOfRange if a number is outside the limits or between two steps
+func (m SettingMetadata) check(name string, number float64) error {
+	outside := number < m.Min || number > m.Max
+	// Allow for binary rounding, 1.15 is not exactly 23 steps of 0.05
+	if steps := number / m.Step; m.Step > 0 && math.Abs(steps-math.Round(steps)) > 1e-9 {
+		outside = true
+	}
+	if outside {
+		return fmt.Errorf("%w: %s %s must be within %s", ErrValueOutOfRange, name, formatLimit(number), m.Range())
+	}
+	return nil
+}
+
+func formatLimit(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
 }
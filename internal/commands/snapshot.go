@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Snapshot holds every setting gadget can change on a device, so the device can be put back later
+type Snapshot struct {
+	Serial    string                          `json:"serial"`
+	Model     string                          `json:"model,omitempty"`
+	TakenAt   time.Time                       `json:"taken_at"`
+	Settings  map[SettingType]SnapshotSetting `json:"settings"`
+	NightMode string                          `json:"night_mode,omitempty"` // Only in older snapshots, moved into Settings when loaded
+}
+
+// SnapshotSetting is the saved value of one setting
+type SnapshotSetting struct {
+	Value      string `json:"value"`
+	Overridden bool   `json:"overridden"` // False means the device default, restored with a reset
+}
+
+// SnapshotPath returns where the snapshot of a device is kept
+func SnapshotPath(cfg *config.Config, device adb.Device) string {
+	return filepath.Join(cfg.ConfigDir, "snapshots", deviceFileName(device.Serial)+".json")
+}
+
+// TakeSnapshot captures a device's settings and saves them to its snapshot file
+func TakeSnapshot(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	snapshot, err := CaptureSnapshot(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	path := SnapshotPath(cfg, device)
+	if err := SaveSnapshot(path, snapshot); err != nil {
+		return err
+	}
+
+	logger.Success("Saved snapshot of %s to %s", device.Serial, path)
+	return nil
+}
+
+// RestoreSnapshot puts a device back the way its snapshot file recorded it
+func RestoreSnapshot(ctx context.Context, cfg *config.Config, device adb.Device) error {
+	snapshot, err := LoadSnapshot(SnapshotPath(cfg, device))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no snapshot of %s, take one with \"gadget snapshot\" first", device.Serial)
+		}
+		return err
+	}
+	return ApplySnapshot(ctx, cfg, device, snapshot)
+}
+
+// CaptureSnapshot reads every setting gadget can change from a device
+func CaptureSnapshot(ctx context.Context, cfg *config.Config, device adb.Device) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Serial:   device.Serial,
+		Model:    device.Model,
+		TakenAt:  time.Now(),
+		Settings: make(map[SettingType]SnapshotSetting, len(SettingTypes)),
+	}
+
+	for _, settingType := range SettingTypes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := GetSettingHandler(settingType).GetInfo(ctx, cfg, device)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", settingType, err)
+		}
+		snapshot.Settings[settingType] = SnapshotSetting{Value: info.Current, Overridden: info.Overridden}
+	}
+
+	return snapshot, nil
+}
+
+// ApplySnapshot changes the settings that differ from a snapshot, leaving the rest alone
+func ApplySnapshot(ctx context.Context, cfg *config.Config, device adb.Device, snapshot *Snapshot) error {
+	current, err := CaptureSnapshot(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+
+	restored := 0
+	for _, settingType := range SettingTypes {
+		saved, ok := snapshot.Settings[settingType]
+		if !ok || saved.matches(current.Settings[settingType]) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := restoreSetting(ctx, cfg, device, settingType, saved); err != nil {
+			return err
+		}
+		restored++
+	}
+
+	if restored == 0 {
+		logger.Success("%s already matches its snapshot from %s", device.Serial, snapshot.TakenAt.Format(time.DateTime))
+		return nil
+	}
+	logger.Success("Restored %d settings on %s from its snapshot from %s", restored, device.Serial, snapshot.TakenAt.Format(time.DateTime))
+	return nil
+}
+
+// SaveSnapshot writes a snapshot to a JSON file
+func SaveSnapshot(path string, snapshot *Snapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot reads a snapshot from a JSON file
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	// Night mode used to be kept apart from the settings with handlers
+	if _, ok := snapshot.Settings[SettingTypeNightMode]; !ok && snapshot.NightMode != "" {
+		if snapshot.Settings == nil {
+			snapshot.Settings = make(map[SettingType]SnapshotSetting)
+		}
+		snapshot.Settings[SettingTypeNightMode] = SnapshotSetting{Value: snapshot.NightMode, Overridden: snapshot.NightMode != defaultNightMode}
+	}
+	snapshot.NightMode = ""
+	return &snapshot, nil
+}
+
+// restoreSetting sets a saved value, or resets the setting if the device was on its default
+func restoreSetting(ctx context.Context, cfg *config.Config, device adb.Device, settingType SettingType, saved SnapshotSetting) error {
+	handler := GetSettingHandler(settingType)
+	if saved.Overridden {
+		return handler.SetValue(ctx, cfg, device, saved.Value)
+	}
+	return handler.Reset(ctx, cfg, device)
+}
+
+// matches reports whether two saved values leave the device in the same state
+// Any two defaults match, whatever the value, since the default is what a reset restores
+func (s SnapshotSetting) matches(other SnapshotSetting) bool {
+	if !s.Overridden || !other.Overridden {
+		return s.Overridden == other.Overridden
+	}
+	return s.Value == other.Value
+}
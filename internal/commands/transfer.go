@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/logger"
+	"path/filepath"
+	"time"
+)
+
+// progressInterval limits how often transfer progress is logged
+const progressInterval = 100 * time.Millisecond
+
+// pullFile copies a file from the device, reporting progress to the logger
+func pullFile(ctx context.Context, adbPath string, device adb.Device, remotePath, localPath string) error {
+	label := fmt.Sprintf("Pulling %s", filepath.Base(localPath))
+	return adb.PullFile(ctx, adbPath, device.Serial, remotePath, localPath, transferProgress(device, label))
+}
+
+// transferProgress returns a progress callback that logs throttled "label: 45% (1.2 MB / 2.7 MB)" updates
+func transferProgress(device adb.Device, label string) adb.ProgressFunc {
+	var last time.Time
+	return func(transferred, total int64) {
+		done := total >= 0 && transferred >= total
+		if !done && time.Since(last) < progressInterval {
+			return
+		}
+		last = time.Now()
+
+		if total <= 0 {
+			logger.Progress("%s on %s: %s", label, device.Serial, formatBytes(transferred))
+			return
+		}
+		logger.Progress("%s on %s: %d%% (%s / %s)", label, device.Serial,
+			transferred*100/total, formatBytes(transferred), formatBytes(total))
+	}
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.2 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
@@ -14,7 +16,7 @@ const DefaultWiFiPort = 4444
 
 // ConnectWiFi attempts to connect to a device over WiFi
 // For modern Android (11+), this requires pairing first
-func ConnectWiFi(cfg *config.Config, ipAndPort string) error {
+func ConnectWiFi(ctx context.Context, cfg *config.Config, ipAndPort string) error {
 	adbPath := cfg.GetADBPath()
 	ip, port, err := ParseIPAndPort(ipAndPort)
 	if err != nil {
@@ -29,33 +31,35 @@ func ConnectWiFi(cfg *config.Config, ipAndPort string) error {
 
 	// Try connecting to the specified address
 	logger.Info("Attempting to connect to %s...", ipAndPort)
-	output, err := adb.ExecuteGlobalCommandWithOutput(adbPath, "connect", ipAndPort)
+	output, err := adb.ExecuteGlobalCommandWithOutputContext(ctx, adbPath, "connect", ipAndPort)
 	if err == nil && strings.Contains(output, "connected to") {
 		logger.Success("Successfully connected to %s", ipAndPort)
 
 		// If we connected to a non-standard port, try to switch to our standard port
 		if port != DefaultWiFiPort {
 			logger.Info("Switching device to standard port %d...", DefaultWiFiPort)
-			switchErr := adb.ExecuteCommand(adbPath, ipAndPort, "tcpip", fmt.Sprintf("%d", DefaultWiFiPort))
+			switchErr := adb.ExecuteCommandContext(ctx, adbPath, ipAndPort, "tcpip", fmt.Sprintf("%d", DefaultWiFiPort))
 			if switchErr != nil {
 				logger.Error("Warning: failed to switch to standard port: %v", switchErr)
 				logger.Info("Device will remain on port %d", port)
 				return nil
 			}
 
-			time.Sleep(2 * time.Second)
+			if err := Wait(ctx, 2*time.Second); err != nil {
+				return err
+			}
 
 			// Try connecting to the standard port
 			standardAddress := fmt.Sprintf("%s:%d", ip, DefaultWiFiPort)
 			logger.Info("Connecting to standard port %s...", standardAddress)
 
-			standardOutput, standardErr := adb.ExecuteGlobalCommandWithOutput(adbPath, "connect", standardAddress)
+			standardOutput, standardErr := adb.ExecuteGlobalCommandWithOutputContext(ctx, adbPath, "connect", standardAddress)
 			if standardErr == nil && strings.Contains(standardOutput, "connected to") {
 				logger.Success("Successfully switched to standard port %s", standardAddress)
 
 				// Disconnect from the original port
 				logger.Info("Disconnecting from temporary port %s...", ipAndPort)
-				adb.ExecuteGlobalCommand(adbPath, "disconnect", ipAndPort)
+				adb.ExecuteGlobalCommandContext(ctx, adbPath, "disconnect", ipAndPort)
 
 				return nil
 			} else {
@@ -64,11 +68,15 @@ func ConnectWiFi(cfg *config.Config, ipAndPort string) error {
 		}
 
 		// Clean up any stale mDNS WiFi connections after successful connection
-		CleanupStaleWiFiConnections(cfg)
+		CleanupStaleWiFiConnections(ctx, cfg)
 
 		return nil
 	}
 
+	if ctx.Err() != nil {
+		return fmt.Errorf("connecting to %s was cancelled: %w", ipAndPort, ctx.Err())
+	}
+
 	// Log the actual error for debugging
 	if err != nil {
 		logger.Error("Connection command failed: %v", err)
@@ -80,7 +88,7 @@ func ConnectWiFi(cfg *config.Config, ipAndPort string) error {
 }
 
 // DisconnectWiFi disconnects from a WiFi device
-func DisconnectWiFi(cfg *config.Config, ipAndPort string) error {
+func DisconnectWiFi(ctx context.Context, cfg *config.Config, ipAndPort string) error {
 	adbPath := cfg.GetADBPath()
 	ip, port, err := ParseIPAndPort(ipAndPort)
 	if err != nil {
@@ -96,16 +104,15 @@ func DisconnectWiFi(cfg *config.Config, ipAndPort string) error {
 	logger.Info("Disconnecting from %s...", ipAndPort)
 
 	// Check what devices are currently connected first
-	output, err := adb.ExecuteGlobalCommandWithOutput(adbPath, "devices")
+	output, err := adb.ExecuteGlobalCommandWithOutputContext(ctx, adbPath, "devices")
 	if err == nil {
 		logger.Info("Currently connected devices:\n%s", output)
 	}
 
-	err = adb.ExecuteGlobalCommand(adbPath, "disconnect", ipAndPort)
+	err = adb.ExecuteGlobalCommandContext(ctx, adbPath, "disconnect", ipAndPort)
 	if err != nil {
-		// Check if the error is because the device wasn't connected
-		if strings.Contains(err.Error(), "exit status 1") {
-			return fmt.Errorf("device %s was not connected", ipAndPort)
+		if errors.Is(err, adb.ErrDeviceNotFound) {
+			return fmt.Errorf("device %s was not connected: %w", ipAndPort, err)
 		}
 		return fmt.Errorf("failed to disconnect from %s: %w", ipAndPort, err)
 	}
@@ -113,17 +120,17 @@ func DisconnectWiFi(cfg *config.Config, ipAndPort string) error {
 	logger.Success("Disconnected from %s", ipAndPort)
 
 	// Clean up any stale mDNS WiFi connections
-	CleanupStaleWiFiConnections(cfg)
+	CleanupStaleWiFiConnections(ctx, cfg)
 
 	return nil
 }
 
 // CleanupStaleWiFiConnections removes stale mDNS WiFi connections
-func CleanupStaleWiFiConnections(cfg *config.Config) {
+func CleanupStaleWiFiConnections(ctx context.Context, cfg *config.Config) {
 	adbPath := cfg.GetADBPath()
 
 	// Get current devices list
-	output, err := adb.ExecuteGlobalCommandWithOutput(adbPath, "devices")
+	output, err := adb.ExecuteGlobalCommandWithOutputContext(ctx, adbPath, "devices")
 	if err != nil {
 		return
 	}
@@ -138,7 +145,7 @@ func CleanupStaleWiFiConnections(cfg *config.Config) {
 			if len(parts) >= 2 {
 				deviceId := parts[0]
 				logger.Info("Cleaning up stale WiFi connection: %s", deviceId)
-				adb.ExecuteGlobalCommand(adbPath, "disconnect", deviceId)
+				adb.ExecuteGlobalCommandContext(ctx, adbPath, "disconnect", deviceId)
 			}
 		}
 	}
@@ -0,0 +1,217 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/config"
+	"gadget/internal/logger"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WindowSizeClass is a range of window widths or heights in dp that adaptive layouts switch on
+type WindowSizeClass struct {
+	Name    string
+	Min     int // Smallest dp in the class
+	Max     int // First dp past the class; 0 for no limit
+	Typical int // dp used when the screen has to be resized into the class
+}
+
+// WidthClasses are the Jetpack window width size classes
+var WidthClasses = []WindowSizeClass{
+	{Name: "compact", Min: 0, Max: 600, Typical: 360},
+	{Name: "medium", Min: 600, Max: 840, Typical: 720},
+	{Name: "expanded", Min: 840, Typical: 1024},
+}
+
+// HeightClasses are the Jetpack window height size classes
+var HeightClasses = []WindowSizeClass{
+	{Name: "compact", Min: 0, Max: 480, Typical: 400},
+	{Name: "medium", Min: 480, Max: 900, Typical: 720},
+	{Name: "expanded", Min: 900, Typical: 1024},
+}
+
+func (c WindowSizeClass) contains(dp int) bool {
+	return dp >= c.Min && (c.Max == 0 || dp < c.Max)
+}
+
+// WindowMetrics is a screen size and density with the dp dimensions and classes they give
+type WindowMetrics struct {
+	Size        string
+	Density     int
+	DPWidth     int
+	DPHeight    int
+	WidthClass  string
+	HeightClass string
+}
+
+func newWindowMetrics(width, height, density int) WindowMetrics {
+	metrics := WindowMetrics{
+		Size:     fmt.Sprintf("%dx%d", width, height),
+		Density:  density,
+		DPWidth:  pxToDP(width, density),
+		DPHeight: pxToDP(height, density),
+	}
+	metrics.WidthClass = classify(WidthClasses, metrics.DPWidth).Name
+	metrics.HeightClass = classify(HeightClasses, metrics.DPHeight).Name
+	return metrics
+}
+
+// String describes the metrics, e.g. "411x914 dp (compact width, expanded height)"
+func (m WindowMetrics) String() string {
+	return fmt.Sprintf("%dx%d dp (%s width, %s height)", m.DPWidth, m.DPHeight, m.WidthClass, m.HeightClass)
+}
+
+// GetWindowMetrics returns the dp dimensions and size classes of a device's current screen size and DPI,
+// in its natural orientation
+func GetWindowMetrics(ctx context.Context, cfg *config.Config, device adb.Device) (*WindowMetrics, error) {
+	size, err := GetCurrentScreenSize(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+	dpi, err := GetCurrentDPI(ctx, cfg, device)
+	if err != nil {
+		return nil, err
+	}
+	width, height, err := parseScreenSize(size.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := newWindowMetrics(width, height, dpi.Current)
+	return &metrics, nil
+}
+
+// SetWindowClass changes a device's screen size and DPI so its window lands in the given classes
+// An empty height class accepts any height. Only the DPI is changed when that is enough, staying as close
+// to the physical density as possible; otherwise the screen is resized to typical dp of the classes
+func SetWindowClass(ctx context.Context, cfg *config.Config, device adb.Device, widthClass, heightClass string) error {
+	width, err := findWindowSizeClass(WidthClasses, widthClass)
+	if err != nil {
+		return err
+	}
+	var height *WindowSizeClass
+	if heightClass != "" {
+		class, err := findWindowSizeClass(HeightClasses, heightClass)
+		if err != nil {
+			return err
+		}
+		height = &class
+	}
+
+	sizeInfo, err := GetCurrentScreenSize(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	dpiInfo, err := GetCurrentDPI(ctx, cfg, device)
+	if err != nil {
+		return err
+	}
+	physicalWidth, physicalHeight, err := parseScreenSize(sizeInfo.Physical)
+	if err != nil {
+		return err
+	}
+
+	metrics := fitWindowClass(physicalWidth, physicalHeight, dpiInfo.Physical, width, height)
+	density := strconv.Itoa(metrics.Density)
+	sizeHandler, dpiHandler := GetSettingHandler(SettingTypeScreenSize), GetSettingHandler(SettingTypeDPI)
+	if !cfg.Force {
+		if err := sizeHandler.Validate(metrics.Size); err != nil {
+			return fmt.Errorf("%s window: %w", strings.TrimSpace(widthClass+" "+heightClass), err)
+		}
+		if err := dpiHandler.Validate(density); err != nil {
+			return fmt.Errorf("%s window: %w", strings.TrimSpace(widthClass+" "+heightClass), err)
+		}
+	}
+
+	// Values that match the physical screen clear the override instead
+	if metrics.Size != sizeInfo.Physical {
+		err = sizeHandler.SetValue(ctx, cfg, device, metrics.Size)
+	} else if sizeInfo.Overridden {
+		err = sizeHandler.Reset(ctx, cfg, device)
+	}
+	if err != nil {
+		return err
+	}
+	if metrics.Density != dpiInfo.Physical {
+		err = dpiHandler.SetValue(ctx, cfg, device, density)
+	} else if dpiInfo.Override > 0 {
+		err = dpiHandler.Reset(ctx, cfg, device)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Success("%s is now %s", device.Serial, metrics)
+	return nil
+}
+
+// fitWindowClass picks the screen size and density for the classes, given the physical screen
+func fitWindowClass(physicalWidth, physicalHeight, physicalDensity int, width WindowSizeClass, height *WindowSizeClass) WindowMetrics {
+	// The physical size at the allowed density closest to the physical one
+	var best *WindowMetrics
+	for density := int(dpiMetadata.Min); density <= int(dpiMetadata.Max); density++ {
+		metrics := newWindowMetrics(physicalWidth, physicalHeight, density)
+		if metrics.WidthClass != width.Name || (height != nil && metrics.HeightClass != height.Name) {
+			continue
+		}
+		if best == nil || abs(density-physicalDensity) < abs(best.Density-physicalDensity) {
+			best = &metrics
+		}
+	}
+	if best != nil {
+		return *best
+	}
+
+	// The screen's aspect ratio can't give both classes, so it is resized to their typical dp,
+	// keeping the pixels within the physical screen; without a height class the aspect ratio is kept
+	dpWidth := width.Typical
+	dpHeight := dpWidth * physicalHeight / physicalWidth
+	if height != nil {
+		dpHeight = height.Typical
+	}
+	density := min(physicalDensity, physicalWidth*160/dpWidth, physicalHeight*160/dpHeight)
+	return newWindowMetrics(dpToPX(dpWidth, density), dpToPX(dpHeight, density), density)
+}
+
+// classify returns the class a dp dimension falls in
+func classify(classes []WindowSizeClass, dp int) WindowSizeClass {
+	for _, class := range classes {
+		if class.contains(dp) {
+			return class
+		}
+	}
+	return classes[len(classes)-1]
+}
+
+// findWindowSizeClass looks up a class by name
+func findWindowSizeClass(classes []WindowSizeClass, name string) (WindowSizeClass, error) {
+	names := make([]string, len(classes))
+	for i, class := range classes {
+		if class.Name == name {
+			return class, nil
+		}
+		names[i] = class.Name
+	}
+	return WindowSizeClass{}, fmt.Errorf("invalid window class: %s (expected %s)", name, strings.Join(names, ", "))
+}
+
+// IsWindowSizeClass reports whether a name is a window size class
+func IsWindowSizeClass(name string) bool {
+	_, err := findWindowSizeClass(WidthClasses, name)
+	return err == nil
+}
+
+// dpToPX converts dp to pixels at a density, rounded so the dp come back the same from pxToDP
+func dpToPX(dp, density int) int {
+	return int(math.Ceil(float64(dp) * float64(density) / 160))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
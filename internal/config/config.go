@@ -1,8 +1,12 @@
 package config
 
 import (
+	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
@@ -10,6 +14,12 @@ type Config struct {
 	AndroidHome   string
 	MediaPath     string
 	ADBStaticPort int
+	DeviceInfoTTL time.Duration // How long battery level and IP address are cached
+	ADBServerHost string        // Host of the adb server, empty for the local one
+	ADBServerPort int           // Port of the adb server, zero for the default 5037
+	ConfigDir     string        // Where gadget keeps snapshots and other state
+	NoTrace       bool          // Revert every setting change when the session ends
+	Force         bool          // Apply setting values outside their allowed range
 }
 
 // NewConfig creates a new configuration with default values
@@ -29,11 +39,58 @@ func NewConfig() *Config {
 	home, _ := os.UserHomeDir()
 	mediaPath := filepath.Join(home, "Downloads")
 
+	// Volatile device info is re-probed after this long, override with e.g. GADGET_DEVICE_INFO_TTL=1m
+	deviceInfoTTL := 30 * time.Second
+	if ttl, err := time.ParseDuration(os.Getenv("GADGET_DEVICE_INFO_TTL")); err == nil && ttl > 0 {
+		deviceInfoTTL = ttl
+	}
+
+	// Same variables the adb binary reads, so gadget and adb agree on the server
+	serverHost, serverPort := ParseADBServerSocket(os.Getenv("ADB_SERVER_SOCKET"))
+	if port, err := strconv.Atoi(os.Getenv("ANDROID_ADB_SERVER_PORT")); err == nil && port > 0 {
+		serverPort = port
+	}
+
+	// Snapshots and other state live in the user config directory, override with GADGET_CONFIG_DIR
+	configDir := os.Getenv("GADGET_CONFIG_DIR")
+	if configDir == "" {
+		userConfigDir, err := os.UserConfigDir()
+		if err != nil {
+			userConfigDir = filepath.Join(home, ".config")
+		}
+		configDir = filepath.Join(userConfigDir, "gadget")
+	}
+
 	return &Config{
 		AndroidHome:   androidHome,
 		MediaPath:     mediaPath,
 		ADBStaticPort: 4444,
+		DeviceInfoTTL: deviceInfoTTL,
+		ADBServerHost: serverHost,
+		ADBServerPort: serverPort,
+		ConfigDir:     configDir,
+		NoTrace:       os.Getenv("GADGET_NO_TRACE") == "1",
+	}
+}
+
+// ParseADBServerSocket reads host and port from an ADB_SERVER_SOCKET value such as "tcp:buildbox:5037"
+// Anything it doesn't understand yields the defaults
+func ParseADBServerSocket(socket string) (string, int) {
+	address, ok := strings.CutPrefix(socket, "tcp:")
+	if !ok {
+		return "", 0
+	}
+
+	host, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		// "tcp:5037" names only a port
+		host, portText = "", address
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil || port <= 0 {
+		return "", 0
 	}
+	return host, port
 }
 
 // GetADBPath returns the path to adb executable
@@ -3,10 +3,14 @@ package logger
 import (
 	"fmt"
 	"os"
+	"sync"
 )
 
 // CLIRenderer renders log entries to the terminal with colors
-type CLIRenderer struct{}
+type CLIRenderer struct {
+	mu          sync.Mutex
+	progressing bool // A progress line is showing and the next entry must start on a new line
+}
 
 // ANSI color codes
 const (
@@ -17,6 +21,9 @@ const (
 	ColorGray   = "\033[90m"
 )
 
+// clearLine erases the rest of the current terminal line
+const clearLine = "\033[K"
+
 // NewCLIRenderer creates a new CLI renderer
 func NewCLIRenderer() *CLIRenderer {
 	return &CLIRenderer{}
@@ -24,6 +31,20 @@ func NewCLIRenderer() *CLIRenderer {
 
 // Render outputs the log entry to the terminal with appropriate colors
 func (r *CLIRenderer) Render(entry LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Progress overwrites itself in place instead of adding a line per update
+	if entry.Level == LogLevelProgress {
+		fmt.Printf("\r%s%s%s%s", ColorGray, entry.Message, ColorReset, clearLine)
+		r.progressing = true
+		return
+	}
+	if r.progressing {
+		fmt.Print("\n")
+		r.progressing = false
+	}
+
 	var color string
 	switch entry.Level {
 	case LogLevelError:
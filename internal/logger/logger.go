@@ -12,6 +12,7 @@ const (
 	LogLevelInfo LogLevel = iota
 	LogLevelSuccess
 	LogLevelError
+	LogLevelProgress // Transient status such as transfer progress, replaced by the next progress entry
 )
 
 func (l LogLevel) String() string {
@@ -22,6 +23,8 @@ func (l LogLevel) String() string {
 		return "SUCCESS"
 	case LogLevelError:
 		return "ERROR"
+	case LogLevelProgress:
+		return "PROGRESS"
 	default:
 		return "UNKNOWN"
 	}
@@ -37,6 +40,7 @@ type Logger interface {
 	Info(format string, args ...interface{})
 	Error(format string, args ...interface{})
 	Success(format string, args ...interface{})
+	Progress(format string, args ...interface{})
 }
 
 // Renderer interface defines how log entries are displayed
@@ -71,6 +75,10 @@ func (l *GlobalLogger) Success(format string, args ...interface{}) {
 	l.log(LogLevelSuccess, format, args...)
 }
 
+func (l *GlobalLogger) Progress(format string, args ...interface{}) {
+	l.log(LogLevelProgress, format, args...)
+}
+
 func (l *GlobalLogger) log(level LogLevel, format string, args ...interface{}) {
 	l.mu.RLock()
 	renderer := l.renderer
@@ -101,3 +109,7 @@ func Error(format string, args ...interface{}) {
 func Success(format string, args ...interface{}) {
 	globalLogger.Success(format, args...)
 }
+
+func Progress(format string, args ...interface{}) {
+	globalLogger.Progress(format, args...)
+}
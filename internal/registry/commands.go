@@ -19,13 +19,33 @@ func GetAvailableCommands() []Command {
 	return []Command{
 		{"screenshot", "Screenshot", "Take a screenshot", "Media"},
 		{"screenshot-day-night", "Screenshot day-night", "Take screenshots in day and night mode", "Media"},
+		{"screenshot-locales", "Screenshot locales", "Take a screenshot per locale, by default in the en-XA and ar-XB pseudo-locales", "Media"},
 		{"screen-record", "Screen record", "Record the screen", "Media"},
 		{"dpi", "DPI", "View or change device DPI", "Device settings"},
 		{"font-size", "Font size", "View or change device font size", "Device settings"},
 		{"screen-size", "Screen size", "View or change device screen size", "Device settings"},
+		{"animations", "Animations", "View, change or turn off the window, transition and animator scales", "Device settings"},
+		{"dark-mode", "Dark mode", "View or change the UI night mode: yes, no, auto or custom", "Device settings"},
+		{"locale", "Locale", "View or change the system locale, or use accents or rtl for a pseudo-locale", "Device settings"},
+		{"overlay", "Debug overlay", "Show or hide layout bounds, GPU overdraw, GPU rendering bars, taps or the pointer location", "Device settings"},
+		{"reset", "Reset", "Reset dpi, font-size, screen-size, animations, dark-mode, locale, overlays, accessibility or all of them to the device defaults", "Device settings"},
+		{"emulate-screen", "Emulate screen", "Lay out like a real device from the built-in catalog, e.g. \"Pixel 4a\"", "Device settings"},
+		{"window-class", "Window class", "Show the window size classes, or land in a compact, medium or expanded width and height", "Device settings"},
+		{"preset", "Preset", "List, apply or save named sets of setting values", "Device settings"},
+		{"snapshot", "Snapshot", "Save every setting gadget can change to a file per device", "Device settings"},
+		{"restore", "Restore", "Put a device back the way its snapshot recorded it", "Device settings"},
+		{"history", "History", "List setting changes per device, undo, redo or revert to any of them", "Device settings"},
+		{"revert", "Revert", "Undo the setting changes a -no-trace session made", "Device settings"},
+		{"talkback", "TalkBack", "Turn the TalkBack screen reader on or off", "Accessibility"},
+		{"high-contrast-text", "High-contrast text", "Turn high-contrast text on or off", "Accessibility"},
+		{"color-inversion", "Color inversion", "Turn color inversion on or off", "Accessibility"},
+		{"color-correction", "Color correction", "View or change the color correction mode: deuteranomaly, protanomaly, tritanomaly, monochromacy or off", "Accessibility"},
+		{"remove-animations", "Remove animations", "Turn every animation scale off, or back to the default", "Accessibility"},
 		{"wifi", "WiFi", "Manage WiFi device connections", "WiFi"},
 		{"emulator", "Emulator", "Manage Android emulators", "Devices/emulators"},
 		{"refresh-devices", "Refresh devices", "Refresh the device list", "Devices/emulators"},
+		{"devices", "Devices", "List devices, or recover unauthorized and offline ones with --fix", "Devices/emulators"},
+		{"server", "ADB server", "Start, stop or restart the adb server and check its version", "Devices/emulators"},
 	}
 }
 
@@ -34,16 +54,47 @@ func GetTUICommands() []Command {
 	return []Command{
 		{"screenshot", "Screenshot", "Take a screenshot", "Media"},
 		{"screenshot-day-night", "Screenshot day-night", "Take screenshots in day and night mode", "Media"},
+		{"screenshot-pseudo-locales", "Screenshot pseudo-locales", "Take screenshots in the en-XA and ar-XB pseudo-locales", "Media"},
 		{"screen-record", "Screen record", "Record the screen", "Media"},
 		{"dpi", "DPI", "View or change device DPI", "Device settings"},
 		{"font-size", "Font size", "View or change device font size", "Device settings"},
 		{"screen-size", "Screen size", "View or change device screen size", "Device settings"},
+		{"animations", "Animations", "View, change or turn off the window, transition and animator scales", "Device settings"},
+		{"toggle-dark-mode", "Toggle dark mode", "Switch the device between dark and light mode", "Device settings"},
+		{"locale", "Locale", "View or change the system locale", "Device settings"},
+		{"pseudo-locale-accents", "Pseudo-locale accents", "Switch to en-XA, with accented and padded text", "Device settings"},
+		{"pseudo-locale-rtl", "Pseudo-locale RTL", "Switch to ar-XB, with text mirrored right to left", "Device settings"},
+		{"emulate-screen", "Emulate screen", "Set the screen size and DPI to lay out like a real device", "Device settings"},
+		{"window-class", "Window class", "Change the screen size and DPI to land in a compact, medium or expanded width and height", "Device settings"},
+		{"apply-preset", "Apply preset", "Pick a named set of setting values and apply it in one step", "Device settings"},
+		{"save-preset", "Save preset", "Save the device's current settings as a named preset", "Device settings"},
+		{"snapshot-settings", "Snapshot settings", "Save DPI, font size, screen size, animations, dark mode, accessibility, debug overlays and locale to a file", "Device settings"},
+		{"restore-settings", "Restore settings", "Put the device back the way its snapshot recorded it", "Device settings"},
+		{"revert-changes", "Revert changes", "Undo the setting changes made since gadget started with -no-trace", "Device settings"},
+		{"overlay-layout-bounds", "Layout bounds", "Toggle the outlines of view bounds, margins and padding", "Debug overlays"},
+		{"overlay-overdraw", "GPU overdraw", "Toggle the overdraw colors, from blue for drawn twice to red for four times or more", "Debug overlays"},
+		{"overlay-gpu-profile", "GPU rendering bars", "Toggle the bars showing how long each frame took to render", "Debug overlays"},
+		{"overlay-taps", "Show taps", "Toggle a dot wherever the screen is touched", "Debug overlays"},
+		{"overlay-pointer-location", "Pointer location", "Toggle the touch coordinates and traces", "Debug overlays"},
+		{"overlays", "Debug overlays", "Choose every overlay to show at once", "Debug overlays"},
+		{"toggle-talkback", "Toggle TalkBack", "Turn the TalkBack screen reader on or off", "Accessibility"},
+		{"toggle-high-contrast-text", "Toggle high-contrast text", "Turn high-contrast text on or off", "Accessibility"},
+		{"toggle-color-inversion", "Toggle color inversion", "Turn color inversion on or off", "Accessibility"},
+		{"color-correction", "Color correction", "Simulate or correct deuteranomaly, protanomaly, tritanomaly or monochromacy", "Accessibility"},
+		{"toggle-remove-animations", "Toggle remove animations", "Turn every animation scale off, or back to the default", "Accessibility"},
+		{"reset-accessibility", "Reset accessibility", "Turn TalkBack, high-contrast text, color inversion and color correction off", "Accessibility"},
 		{"pair-wifi", "Pair WiFi device", "Pair with a new WiFi device", "WiFi"},
 		{"connect-wifi", "Connect WiFi device", "Connect to a WiFi device", "WiFi"},
 		{"disconnect-wifi", "Disconnect WiFi device", "Disconnect from a WiFi device", "WiFi"},
 		{"launch-emulator", "Launch emulator", "Start an Android emulator", "Devices/emulators"},
 		{"configure-emulator", "Configure emulator", "Edit emulator configuration", "Devices/emulators"},
 		{"refresh-devices", "Refresh devices", "Refresh the device list", "Devices/emulators"},
+		{"fix-devices", "Fix devices", "Reconnect offline devices and re-request authorization from unauthorized ones", "Devices/emulators"},
+		{"authorize-device", "Re-request authorization", "Show the USB debugging prompt on an unauthorized device again", "Devices/emulators"},
+		{"reconnect-offline", "Reconnect offline devices", "Reconnect devices the adb server lists as offline", "Devices/emulators"},
+		{"restart-adb-server", "Restart ADB server", "Stop and start the adb server (kill-server, start-server)", "Devices/emulators"},
+		{"adb-server-version", "ADB server version", "Check that the adb binary and server versions match", "Devices/emulators"},
+		{"regenerate-adb-keys", "Regenerate host adb keys", "Generate new adb keys on this computer, keeping the old ones, so every device asks for authorization again", "Devices/emulators"},
 	}
 }
 
@@ -58,7 +109,7 @@ func GetTUICommandCategories() []CommandCategory {
 	}
 
 	// Return categories in desired order
-	categoryOrder := []string{"Media", "Device settings", "WiFi", "Devices/emulators"}
+	categoryOrder := []string{"Media", "Device settings", "Accessibility", "Debug overlays", "WiFi", "Devices/emulators"}
 	var categories []CommandCategory
 
 	for _, categoryName := range categoryOrder {
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"gadget/internal/adb"
 	"gadget/internal/commands"
 	"gadget/internal/config"
@@ -16,8 +17,8 @@ import (
 )
 
 // loadDevices loads connected ADB devices asynchronously with extended info
-func loadDevices(cfg *config.Config) tea.Cmd {
-	return devices.LoadDevicesCmd(cfg)
+func loadDevices(cfg *config.Config, cache *adb.DeviceInfoCache) tea.Cmd {
+	return devices.LoadDevicesCmd(cfg, cache)
 }
 
 // loadAVDs loads available Android Virtual Devices asynchronously
@@ -30,28 +31,36 @@ func loadLaunchableAVDs(cfg *config.Config, connectedDevices []adb.Device) tea.C
 	return devices.LoadLaunchableAvdsCmd(cfg, connectedDevices)
 }
 
-func takeScreenshot(cfg *config.Config, device adb.Device) tea.Cmd {
-	return media.TakeScreenshotCmd(cfg, device)
+func takeScreenshot(ctx context.Context, cfg *config.Config, device adb.Device) tea.Cmd {
+	return media.TakeScreenshotCmd(ctx, cfg, device)
 }
 
-func takeDayNightScreenshots(cfg *config.Config, device adb.Device) tea.Cmd {
-	return media.TakeDayNightScreenshotsCmd(cfg, device)
+func takeDayNightScreenshots(ctx context.Context, cfg *config.Config, device adb.Device) tea.Cmd {
+	return media.TakeDayNightScreenshotsCmd(ctx, cfg, device)
 }
 
 func startRecording(cfg *config.Config, device adb.Device) tea.Cmd {
 	return media.StartScreenRecordCmd(cfg, device)
 }
 
-func stopAndSaveRecording(recording *commands.ScreenRecording) tea.Cmd {
-	return media.StopAndSaveRecordingCmd(recording)
+func stopAndSaveRecording(ctx context.Context, recording *commands.ScreenRecording) tea.Cmd {
+	return media.StopAndSaveRecordingCmd(ctx, recording)
 }
 
-func getCurrentSetting(cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
-	return settings.LoadSettingCmd(cfg, device, settingType)
+func getCurrentSetting(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+	return settings.LoadSettingCmd(ctx, cfg, device, settingType)
 }
 
-func changeSetting(cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
-	return settings.ChangeSettingCmd(cfg, device, settingType, value)
+func resetSetting(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+	return settings.ResetSettingCmd(ctx, cfg, device, settingType)
+}
+
+func stepSettingHistory(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, redo bool) tea.Cmd {
+	return settings.StepHistoryCmd(ctx, cfg, device, settingType, redo)
+}
+
+func changeSetting(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
+	return settings.ChangeSettingCmd(ctx, cfg, device, settingType, value)
 }
 
 // configureEmulatorCmd opens the AVD configuration file in editor using tea.ExecProcess
@@ -46,6 +46,9 @@ var (
 	InfoStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#8BE9FD"))
 
+	WarningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+
 	// Input styles
 	FocusedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#EE6FF8"))
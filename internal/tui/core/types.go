@@ -13,6 +13,7 @@ const (
 	ModeEmulatorSelect Mode = "emulator-select"
 	ModeCommand        Mode = "command"
 	ModeTextInput      Mode = "text-input"
+	ModePresetSelect   Mode = "preset-select"
 )
 
 // LogType represents the type of log message
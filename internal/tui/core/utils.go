@@ -1,6 +1,8 @@
 package core
 
 import (
+	"fmt"
+	"gadget/internal/adb"
 	"os"
 	"strings"
 )
@@ -18,3 +20,11 @@ func ShortenHomePath(path string) string {
 
 	return path
 }
+
+// ErrorText formats an error for the log, followed by a hint on how to fix known adb failures
+func ErrorText(err error) string {
+	if hint := adb.Hint(err); hint != "" {
+		return fmt.Sprintf("%v\nHint: %s", err, hint)
+	}
+	return err.Error()
+}
@@ -1,6 +1,7 @@
 package devices
 
 import (
+	"context"
 	"gadget/internal/adb"
 	"gadget/internal/config"
 	"gadget/internal/tui/messaging"
@@ -9,15 +10,19 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// loadDevicesTimeout bounds a full device refresh so a hung device can't freeze the list
+const loadDevicesTimeout = 30 * time.Second
+
 // LoadDevicesCmd returns a command to load connected devices with extended info
-func LoadDevicesCmd(cfg *config.Config) tea.Cmd {
+// Info that is still cached is reused instead of probing the device again
+func LoadDevicesCmd(cfg *config.Config, cache *adb.DeviceInfoCache) tea.Cmd {
 	return func() tea.Msg {
-		devices, err := adb.GetConnectedDevices(cfg.GetADBPath())
+		ctx, cancel := context.WithTimeout(context.Background(), loadDevicesTimeout)
+		defer cancel()
+
+		devices, err := adb.GetConnectedDevicesContext(ctx, cfg.GetADBPath())
 		if err == nil {
-			// Load extended info for each device
-			for i := range devices {
-				devices[i].LoadExtendedInfo(cfg.GetADBPath())
-			}
+			cache.LoadAll(ctx, cfg.GetADBPath(), devices, adb.DefaultLoadWorkers)
 		}
 		return messaging.DevicesLoadedMsg{
 			Devices: devices,
@@ -39,30 +44,46 @@ func LoadLaunchableAvdsCmd(cfg *config.Config, devices []adb.Device) tea.Cmd {
 // StartDeviceTrackingCmd starts monitoring device changes via adb track-devices
 func StartDeviceTrackingCmd(cfg *config.Config) tea.Cmd {
 	return func() tea.Msg {
-		eventChan, err := adb.StartDeviceTracking(cfg.GetADBPath())
-		if err != nil {
-			return messaging.DeviceRefreshMsg{Reason: "tracking-error"}
-		}
-
-		// Create a command that listens to device changes
-		return DeviceTrackingStartedMsg{EventChan: eventChan}
+		tracker := adb.StartDeviceTracking(cfg.GetADBPath())
+		return DeviceTrackingStartedMsg{Tracker: tracker}
 	}
 }
 
-// DeviceTrackingStartedMsg contains the device event channel
+// DeviceTrackingStartedMsg contains the running device tracker
 type DeviceTrackingStartedMsg struct {
-	EventChan <-chan adb.DeviceChangeEvent
+	Tracker *adb.DeviceTracker
 }
 
-// WaitForDeviceChangeCmd waits for the next device change event
-func WaitForDeviceChangeCmd(eventChan <-chan adb.DeviceChangeEvent) tea.Cmd {
-	return func() tea.Msg {
-		event := <-eventChan
-		_ = event // Use event for debugging if needed
+// DeviceChangedMsg is sent when the tracker reports a device change
+type DeviceChangedMsg struct {
+	Event adb.DeviceChangeEvent
+}
 
-		// Return after a brief delay to let device settle
-		time.Sleep(500 * time.Millisecond)
-		return messaging.DeviceRefreshMsg{Reason: "device-changed"}
+// TrackerHealthMsg is sent when the tracker connects to or loses the adb server
+type TrackerHealthMsg struct {
+	Health adb.TrackerHealth
+}
+
+// TrackerStoppedMsg is sent when the tracker's channels are closed
+type TrackerStoppedMsg struct{}
+
+// WaitForDeviceChangeCmd waits for the next device change or tracker health update
+func WaitForDeviceChangeCmd(tracker *adb.DeviceTracker) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case event, ok := <-tracker.Events():
+			if !ok {
+				return TrackerStoppedMsg{}
+			}
+			// Return after a brief delay to let device settle
+			time.Sleep(500 * time.Millisecond)
+			return DeviceChangedMsg{Event: event}
+		case health, ok := <-tracker.Health():
+			if !ok {
+				return TrackerStoppedMsg{}
+			}
+			return TrackerHealthMsg{Health: health}
+		}
 	}
 }
 
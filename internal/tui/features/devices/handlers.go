@@ -2,7 +2,9 @@ package devices
 
 import (
 	"fmt"
+	"gadget/internal/adb"
 	"gadget/internal/emulator"
+	"gadget/internal/tui/core"
 	"gadget/internal/tui/messaging"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,22 +15,63 @@ func (d *DevicesFeature) HandleDevicesLoaded(msg messaging.DevicesLoadedMsg) (te
 	d.SetDevices(msg.Devices)
 
 	if msg.Err != nil {
-		return nil, nil, "", msg.Err.Error()
+		return nil, nil, "", core.ErrorText(msg.Err)
 	}
 
 	if len(msg.Devices) == 0 {
-		return nil, nil, "", "no devices connected"
+		return nil, nil, "", core.ErrorText(adb.ErrNoDevices)
 	}
 
 	return nil, nil, "", ""
 }
 
+// HandleDeviceChanged handles a device change reported by the tracker
+func (d *DevicesFeature) HandleDeviceChanged(msg DeviceChangedMsg) (tea.Model, tea.Cmd, string, string) {
+	event := msg.Event
+	d.infoCache.HandleEvent(event)
+	if event.Type == adb.DeviceRemoved || event.Status != adb.StatusDevice {
+		// The shell of a device that went away is dead; a new one is opened on next use
+		adb.CloseShellSession(event.Serial)
+	}
+
+	var infoMsg string
+	switch event.Type {
+	case adb.DeviceAdded:
+		infoMsg = fmt.Sprintf("Device connected: %s (%s)", event.Serial, event.Status)
+	case adb.DeviceRemoved:
+		infoMsg = fmt.Sprintf("Device disconnected: %s", event.Serial)
+	case adb.DeviceStatusChanged:
+		infoMsg = fmt.Sprintf("Device %s: %s → %s", event.Serial, event.PreviousStatus, event.Status)
+	}
+	return nil, LoadDevicesCmd(d.config, d.infoCache), infoMsg, ""
+}
+
+// HandleTrackerHealth handles a device tracker health update
+func (d *DevicesFeature) HandleTrackerHealth(msg TrackerHealthMsg) (tea.Model, tea.Cmd, string, string) {
+	previous := d.trackerHealth
+	d.SetTrackerHealth(msg.Health)
+
+	if !msg.Health.Connected {
+		errorMsg := fmt.Sprintf("Device tracking lost, reconnecting in %s", msg.Health.RetryIn)
+		if msg.Health.Err != nil {
+			errorMsg = fmt.Sprintf("%s: %v", errorMsg, msg.Health.Err)
+		}
+		return nil, nil, "", errorMsg
+	}
+
+	if previous != nil && !previous.Connected {
+		// Devices may have come and gone while the server was unreachable
+		return nil, LoadDevicesCmd(d.config, d.infoCache), "Device tracking reconnected", ""
+	}
+	return nil, nil, "", ""
+}
+
 // HandleAvdsLoaded handles the loading of AVD list
 func (d *DevicesFeature) HandleAvdsLoaded(msg messaging.AvdsLoadedMsg) (tea.Model, tea.Cmd, string, string) {
 	d.SetAvds(msg.Avds)
 
 	if msg.Err != nil {
-		return nil, nil, "", msg.Err.Error()
+		return nil, nil, "", core.ErrorText(msg.Err)
 	}
 
 	return nil, nil, "", ""
@@ -48,5 +91,5 @@ func (d *DevicesFeature) LaunchSelectedEmulator() (tea.Model, tea.Cmd, string, s
 
 	successMsg := fmt.Sprintf("Launched emulator: %s (may take a moment to appear)", selectedAvd.Name)
 	// Return command to refresh device list after launching emulator and schedule delayed refresh
-	return nil, tea.Batch(LoadDevicesCmd(d.config), ScheduleEmulatorRefreshCmd()), successMsg, ""
+	return nil, tea.Batch(LoadDevicesCmd(d.config, d.infoCache), ScheduleEmulatorRefreshCmd()), successMsg, ""
 }
@@ -13,6 +13,11 @@ type DevicesFeature struct {
 	avds             []emulator.AVD
 	selectedDevice   int
 	selectedEmulator int
+	trackerHealth    *adb.TrackerHealth
+	infoCache        *adb.DeviceInfoCache
+	filtering        bool
+	deviceFilter     string
+	markedDevices    map[string]bool // Serials picked for a multi-device run
 }
 
 // NewDevicesFeature creates a new devices feature instance
@@ -21,9 +26,16 @@ func NewDevicesFeature(cfg *config.Config) *DevicesFeature {
 		config:           cfg,
 		selectedDevice:   0,
 		selectedEmulator: 0,
+		infoCache:        adb.NewDeviceInfoCache(cfg.DeviceInfoTTL),
+		markedDevices:    make(map[string]bool),
 	}
 }
 
+// GetInfoCache returns the cache of extended device info shared across refreshes
+func (d *DevicesFeature) GetInfoCache() *adb.DeviceInfoCache {
+	return d.infoCache
+}
+
 // GetDevices returns the current device list
 func (d *DevicesFeature) GetDevices() []adb.Device {
 	return d.devices
@@ -44,9 +56,9 @@ func (d *DevicesFeature) GetSelectedEmulator() int {
 	return d.selectedEmulator
 }
 
-// SetSelectedDevice sets the selected device index
+// SetSelectedDevice sets the selected device index within the filtered device list
 func (d *DevicesFeature) SetSelectedDevice(index int) {
-	if index >= 0 && index < len(d.devices) {
+	if index >= 0 && index < len(d.GetFilteredDevices()) {
 		d.selectedDevice = index
 	}
 }
@@ -58,14 +70,56 @@ func (d *DevicesFeature) SetSelectedEmulator(index int) {
 	}
 }
 
-// GetSelectedDeviceInstance returns the currently selected device instance
+// GetSelectedDeviceInstance returns the currently selected device instance from the filtered device list
 func (d *DevicesFeature) GetSelectedDeviceInstance() *adb.Device {
-	if d.selectedDevice < len(d.devices) {
-		return &d.devices[d.selectedDevice]
+	devices := d.GetFilteredDevices()
+	if d.selectedDevice < len(devices) {
+		return &devices[d.selectedDevice]
 	}
 	return nil
 }
 
+// GetFilteredDevices returns the devices matching the current device filter
+func (d *DevicesFeature) GetFilteredDevices() []adb.Device {
+	return adb.MatchDevices(d.devices, d.deviceFilter)
+}
+
+// IsFiltering returns whether the device filter is being typed
+func (d *DevicesFeature) IsFiltering() bool {
+	return d.filtering
+}
+
+// GetDeviceFilter returns the device selector typed so far
+func (d *DevicesFeature) GetDeviceFilter() string {
+	return d.deviceFilter
+}
+
+// StartFiltering enters device filter mode
+func (d *DevicesFeature) StartFiltering() {
+	d.filtering = true
+}
+
+// AppendToFilter adds typed characters to the device filter
+func (d *DevicesFeature) AppendToFilter(text string) {
+	d.deviceFilter += text
+	d.selectedDevice = 0
+}
+
+// DeleteFromFilter removes the last character of the device filter
+func (d *DevicesFeature) DeleteFromFilter() {
+	if len(d.deviceFilter) > 0 {
+		d.deviceFilter = d.deviceFilter[:len(d.deviceFilter)-1]
+		d.selectedDevice = 0
+	}
+}
+
+// ClearFilter leaves device filter mode and shows every device again
+func (d *DevicesFeature) ClearFilter() {
+	d.filtering = false
+	d.deviceFilter = ""
+	d.selectedDevice = 0
+}
+
 // GetSelectedEmulatorInstance returns the currently selected emulator instance
 func (d *DevicesFeature) GetSelectedEmulatorInstance() *emulator.AVD {
 	if d.selectedEmulator < len(d.avds) {
@@ -74,11 +128,51 @@ func (d *DevicesFeature) GetSelectedEmulatorInstance() *emulator.AVD {
 	return nil
 }
 
+// ToggleMarked adds the device under the cursor to the multi-device selection, or removes it
+func (d *DevicesFeature) ToggleMarked() {
+	if device := d.GetSelectedDeviceInstance(); device != nil {
+		d.markedDevices[device.Serial] = !d.markedDevices[device.Serial]
+	}
+}
+
+// ToggleAllMarked marks every visible device, or unmarks them if they all are marked already
+func (d *DevicesFeature) ToggleAllMarked() {
+	devices := d.GetFilteredDevices()
+	allMarked := true
+	for _, device := range devices {
+		allMarked = allMarked && d.markedDevices[device.Serial]
+	}
+	for _, device := range devices {
+		d.markedDevices[device.Serial] = !allMarked
+	}
+}
+
+// IsMarked returns whether a device is part of the multi-device selection
+func (d *DevicesFeature) IsMarked(serial string) bool {
+	return d.markedDevices[serial]
+}
+
+// GetMarkedDevices returns the marked devices that are still connected, in list order
+func (d *DevicesFeature) GetMarkedDevices() []adb.Device {
+	var marked []adb.Device
+	for _, device := range d.devices {
+		if d.markedDevices[device.Serial] {
+			marked = append(marked, device)
+		}
+	}
+	return marked
+}
+
+// ClearMarked empties the multi-device selection
+func (d *DevicesFeature) ClearMarked() {
+	clear(d.markedDevices)
+}
+
 // SetDevices updates the device list
 func (d *DevicesFeature) SetDevices(devices []adb.Device) {
 	d.devices = devices
 	// Reset selection if out of bounds
-	if d.selectedDevice >= len(devices) {
+	if d.selectedDevice >= len(d.GetFilteredDevices()) {
 		d.selectedDevice = 0
 	}
 }
@@ -91,3 +185,13 @@ func (d *DevicesFeature) SetAvds(avds []emulator.AVD) {
 		d.selectedEmulator = 0
 	}
 }
+
+// GetTrackerHealth returns the last reported device tracker health, nil before the first report
+func (d *DevicesFeature) GetTrackerHealth() *adb.TrackerHealth {
+	return d.trackerHealth
+}
+
+// SetTrackerHealth stores the latest device tracker health
+func (d *DevicesFeature) SetTrackerHealth(health adb.TrackerHealth) {
+	d.trackerHealth = &health
+}
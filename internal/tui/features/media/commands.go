@@ -1,6 +1,7 @@
 package media
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/commands"
@@ -15,13 +16,13 @@ import (
 )
 
 // takeScreenshotRaw takes a screenshot using raw ADB commands without logging
-func takeScreenshotRaw(adbPath, serial, remotePath, localPath string) error {
-	err := adb.ExecuteCommand(adbPath, serial, "shell", "screencap", remotePath)
+func takeScreenshotRaw(ctx context.Context, adbPath, serial, remotePath, localPath string) error {
+	err := adb.ExecuteCommandContext(ctx, adbPath, serial, "shell", "screencap", remotePath)
 	if err != nil {
 		return fmt.Errorf("failed to take screenshot: %w", err)
 	}
 
-	err = adb.ExecuteCommand(adbPath, serial, "pull", remotePath, localPath)
+	err = adb.ExecuteCommandContext(ctx, adbPath, serial, "pull", remotePath, localPath)
 	if err != nil {
 		return fmt.Errorf("failed to pull screenshot: %w", err)
 	}
@@ -37,15 +38,15 @@ type StreamingDayNightScreenshot struct {
 }
 
 // TakeScreenshotCmd returns a command to take a single screenshot
-func TakeScreenshotCmd(cfg *config.Config, device adb.Device) tea.Cmd {
+func TakeScreenshotCmd(ctx context.Context, cfg *config.Config, device adb.Device) tea.Cmd {
 	return StreamCommand(func() error {
-		return commands.TakeScreenshot(cfg, device)
+		return commands.TakeScreenshot(ctx, cfg, device)
 	})
 }
 
 // TakeDayNightScreenshotsCmd returns a command to take day-night screenshots
-func TakeDayNightScreenshotsCmd(cfg *config.Config, device adb.Device) tea.Cmd {
-	return executeScreenshotOperation(cfg, device, ScreenshotDayNight)
+func TakeDayNightScreenshotsCmd(ctx context.Context, cfg *config.Config, device adb.Device) tea.Cmd {
+	return executeScreenshotOperation(ctx, cfg, device, ScreenshotDayNight)
 }
 
 // StartScreenRecordCmd returns a command to start screen recording
@@ -54,16 +55,16 @@ func StartScreenRecordCmd(cfg *config.Config, device adb.Device) tea.Cmd {
 }
 
 // StopAndSaveRecordingCmd returns a command to stop and save screen recording
-func StopAndSaveRecordingCmd(recording *commands.ScreenRecording) tea.Cmd {
+func StopAndSaveRecordingCmd(ctx context.Context, recording *commands.ScreenRecording) tea.Cmd {
 	return func() tea.Msg {
 		capturedOutput, err := capture.CaptureCommand(func() error {
-			return recording.StopAndSave()
+			return recording.StopAndSave(ctx)
 		})
 
 		if err != nil {
 			return messaging.ScreenRecordDoneMsg{
 				Success:        false,
-				Message:        err.Error(),
+				Message:        core.ErrorText(err),
 				CapturedOutput: capturedOutput,
 			}
 		}
@@ -76,7 +77,7 @@ func StopAndSaveRecordingCmd(recording *commands.ScreenRecording) tea.Cmd {
 }
 
 // executeScreenshotOperation executes a screenshot operation asynchronously with common handling
-func executeScreenshotOperation(cfg *config.Config, device adb.Device, operation ScreenshotOperation) tea.Cmd {
+func executeScreenshotOperation(ctx context.Context, cfg *config.Config, device adb.Device, operation ScreenshotOperation) tea.Cmd {
 	return func() tea.Msg {
 		timestamp := time.Now().Format("2006-01-02_15-04-05")
 
@@ -84,12 +85,12 @@ func executeScreenshotOperation(cfg *config.Config, device adb.Device, operation
 		case ScreenshotSingle:
 			// Use generic streaming for single screenshots
 			return StreamCommand(func() error {
-				return commands.TakeScreenshot(cfg, device)
+				return commands.TakeScreenshot(ctx, cfg, device)
 			})()
 
 		case ScreenshotDayNight:
 			// Use live streaming for day-night (needs progress updates)
-			return createStreamingDayNightCommand(cfg, device, timestamp)
+			return createStreamingDayNightCommand(ctx, cfg, device, timestamp)
 		}
 
 		return nil // Should never reach here
@@ -99,14 +100,16 @@ func executeScreenshotOperation(cfg *config.Config, device adb.Device, operation
 // StreamingCommandStart signals the start of a streaming command
 type StreamingCommandStart struct {
 	OutputChan <-chan string
+	ErrChan    <-chan error // Receives the command's error, if any, before OutputChan is closed
 	Config     *config.Config
 	Device     adb.Device
 	Timestamp  string
 }
 
 // createStreamingDayNightCommand creates a command that shows progress as it happens
-func createStreamingDayNightCommand(cfg *config.Config, device adb.Device, timestamp string) tea.Msg {
+func createStreamingDayNightCommand(ctx context.Context, cfg *config.Config, device adb.Device, timestamp string) tea.Msg {
 	outputChan := make(chan string, 100)
+	errChan := make(chan error, 1)
 
 	go func() {
 		defer close(outputChan)
@@ -119,14 +122,16 @@ func createStreamingDayNightCommand(cfg *config.Config, device adb.Device, times
 			}
 		}
 
-		err := executeDayNightWithProgress(cfg, device, timestamp, sendProgress)
+		err := executeDayNightWithProgress(ctx, cfg, device, timestamp, sendProgress)
 		if err != nil {
 			sendProgress(fmt.Sprintf("Command failed: %v", err))
+			errChan <- err
 		}
 	}()
 
 	return StreamingCommandStart{
 		OutputChan: outputChan,
+		ErrChan:    errChan,
 		Config:     cfg,
 		Device:     device,
 		Timestamp:  timestamp,
@@ -156,7 +161,7 @@ func StreamCommand(commandFunc func() error) tea.Cmd {
 			// Send error if command failed
 			if err != nil {
 				select {
-				case outputChan <- fmt.Sprintf("Command failed: %v", err):
+				case outputChan <- "Command failed: " + core.ErrorText(err):
 				default:
 				}
 			}
@@ -174,7 +179,7 @@ type GenericStreamingStart struct {
 }
 
 // executeDayNightWithProgress executes day-night screenshots with progress callbacks
-func executeDayNightWithProgress(cfg *config.Config, device adb.Device, timestamp string, progress func(string)) error {
+func executeDayNightWithProgress(ctx context.Context, cfg *config.Config, device adb.Device, timestamp string, progress func(string)) error {
 	filenameDay := fmt.Sprintf("android-img-%s-day.png", timestamp)
 	filenameNight := fmt.Sprintf("android-img-%s-night.png", timestamp)
 	localPathDay := filepath.Join(cfg.MediaPath, filenameDay)
@@ -184,43 +189,62 @@ func executeDayNightWithProgress(cfg *config.Config, device adb.Device, timestam
 
 	progress(fmt.Sprintf("Taking day and night screenshots of %s", device.Serial))
 
+	// The device is put back in whatever mode it was in, not left in light mode
+	originalMode, err := commands.GetNightMode(ctx, cfg, device)
+	if err != nil {
+		progress(fmt.Sprintf("Error reading night mode: %v", err))
+		return err
+	}
+
 	progress("Setting light mode...")
-	err := commands.SetDarkMode(cfg, device, false)
+	err = commands.SetDarkMode(ctx, cfg, device, false)
 	if err != nil {
 		progress(fmt.Sprintf("Error setting light mode: %v", err))
 		return err
 	}
-	time.Sleep(1 * time.Second)
+	if err := commands.Wait(ctx, 1*time.Second); err != nil {
+		commands.RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
 
 	progress("Taking day screenshot...")
-	err = takeScreenshotRaw(adbPath, device.Serial, remotePath, localPathDay)
+	err = takeScreenshotRaw(ctx, adbPath, device.Serial, remotePath, localPathDay)
 	if err != nil {
 		progress(fmt.Sprintf("Error taking day screenshot: %v", err))
+		commands.RestoreNightMode(cfg, device, originalMode)
 		return err
 	}
 	progress(fmt.Sprintf("Day screenshot saved to: %s", localPathDay))
 
 	progress("Setting dark mode...")
-	err = commands.SetDarkMode(cfg, device, true)
+	err = commands.SetDarkMode(ctx, cfg, device, true)
 	if err != nil {
 		progress(fmt.Sprintf("Error setting dark mode: %v", err))
+		commands.RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
+	if err := commands.Wait(ctx, 1*time.Second); err != nil {
+		commands.RestoreNightMode(cfg, device, originalMode)
 		return err
 	}
-	time.Sleep(1 * time.Second)
 
 	progress("Taking night screenshot...")
-	err = takeScreenshotRaw(adbPath, device.Serial, remotePath, localPathNight)
+	err = takeScreenshotRaw(ctx, adbPath, device.Serial, remotePath, localPathNight)
 	if err != nil {
 		progress(fmt.Sprintf("Error taking night screenshot: %v", err))
+		commands.RestoreNightMode(cfg, device, originalMode)
 		return err
 	}
 	progress(fmt.Sprintf("Night screenshot saved to: %s", localPathNight))
 
-	progress("Restoring light mode...")
-	time.Sleep(1 * time.Second)
-	err = commands.SetDarkMode(cfg, device, false)
+	progress(fmt.Sprintf("Restoring night mode %s...", originalMode))
+	if err := commands.Wait(ctx, 1*time.Second); err != nil {
+		commands.RestoreNightMode(cfg, device, originalMode)
+		return err
+	}
+	err = commands.SetNightMode(ctx, cfg, device, originalMode)
 	if err != nil {
-		progress(fmt.Sprintf("Warning: failed to restore light mode: %v", err))
+		progress(fmt.Sprintf("Warning: failed to restore night mode %s: %v", originalMode, err))
 	}
 
 	commands.CleanupRemoteFile(adbPath, device.Serial, remotePath)
@@ -2,6 +2,7 @@ package media
 
 import (
 	"fmt"
+	"gadget/internal/tui/core"
 	"gadget/internal/tui/messaging"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -31,7 +32,7 @@ func (m *MediaFeature) HandleDayNightScreenshotDone(msg messaging.DayNightScreen
 func (m *MediaFeature) HandleRecordingStarted(msg messaging.RecordingStartedMsg) (tea.Model, tea.Cmd, string, string) {
 	if msg.Err != nil {
 		m.recordingScreen = false
-		return nil, nil, "", fmt.Sprintf("Failed to start recording: %s", msg.Err.Error())
+		return nil, nil, "", "Failed to start recording: " + core.ErrorText(msg.Err)
 	}
 
 	m.activeRecording = msg.Recording
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"context"
 	"gadget/internal/adb"
 	"gadget/internal/commands"
 	"gadget/internal/config"
@@ -10,11 +11,21 @@ import (
 )
 
 // LoadSettingCmd returns a command to load current setting value
-func LoadSettingCmd(cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
-	return messaging.LoadSettingCmd(cfg, device, settingType)
+func LoadSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+	return messaging.LoadSettingCmd(ctx, cfg, device, settingType)
+}
+
+// ResetSettingCmd returns a command to reset a device setting to its default
+func ResetSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+	return messaging.ResetSettingCmd(ctx, cfg, device, settingType)
+}
+
+// StepHistoryCmd returns a command to undo or redo the last setting change on a device
+func StepHistoryCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, redo bool) tea.Cmd {
+	return messaging.StepHistoryCmd(ctx, cfg, device, settingType, redo)
 }
 
 // ChangeSettingCmd returns a command to change a device setting
-func ChangeSettingCmd(cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
-	return messaging.ChangeSettingCmd(cfg, device, settingType, value)
+func ChangeSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
+	return messaging.ChangeSettingCmd(ctx, cfg, device, settingType, value)
 }
@@ -1,8 +1,10 @@
 package settings
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
+	"gadget/internal/tui/core"
 	"gadget/internal/tui/messaging"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -11,18 +13,19 @@ import (
 // HandleSettingLoaded handles the loading of setting information
 func (s *SettingsFeature) HandleSettingLoaded(msg messaging.SettingLoadedMsg) (tea.Model, tea.Cmd, string, string) {
 	if msg.Err != nil {
-		return nil, nil, "", fmt.Sprintf("Failed to load setting info: %s", msg.Err.Error())
+		return nil, nil, "", "Failed to load setting info: " + core.ErrorText(msg.Err)
 	}
 
 	s.SetCurrentSettingInfo(msg.SettingInfo)
+	s.SetCurrentHistory(msg.History)
 	return nil, nil, "", ""
 }
 
-// HandleSettingChanged handles the completion of a setting change operation
-func (s *SettingsFeature) HandleSettingChanged(msg messaging.SettingChangedMsg, device adb.Device) (tea.Model, tea.Cmd, string, string) {
+// HandleSettingChanged handles the completion of a setting change operation, reloading the setting under ctx
+func (s *SettingsFeature) HandleSettingChanged(ctx context.Context, msg messaging.SettingChangedMsg, device adb.Device) (tea.Model, tea.Cmd, string, string) {
 	if msg.Success {
 		successMsg := fmt.Sprintf("Setting changed successfully: %s", msg.Message)
-		return nil, LoadSettingCmd(s.config, device, msg.SettingType), successMsg, ""
+		return nil, LoadSettingCmd(ctx, s.config, device, msg.SettingType), successMsg, ""
 	}
 	return nil, nil, "", fmt.Sprintf("Setting change failed: %s", msg.Message)
 }
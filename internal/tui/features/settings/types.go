@@ -10,6 +10,9 @@ type SettingsFeature struct {
 	config             *config.Config
 	currentSettingInfo *commands.SettingInfo
 	currentSettingType commands.SettingType
+	currentHistory     *commands.History
+	presets            []commands.NamedPreset
+	selectedPreset     int
 }
 
 // NewSettingsFeature creates a new settings feature instance
@@ -37,8 +40,50 @@ func (s *SettingsFeature) SetCurrentSettingInfo(info *commands.SettingInfo) {
 	}
 }
 
+// GetCurrentHistory returns the setting history of the device being changed
+func (s *SettingsFeature) GetCurrentHistory() *commands.History {
+	return s.currentHistory
+}
+
+// SetCurrentHistory sets the setting history of the device being changed
+func (s *SettingsFeature) SetCurrentHistory(history *commands.History) {
+	s.currentHistory = history
+}
+
+// GetPresets returns the presets offered by the preset picker
+func (s *SettingsFeature) GetPresets() []commands.NamedPreset {
+	return s.presets
+}
+
+// SetPresets sets the presets offered by the preset picker, selecting the first
+func (s *SettingsFeature) SetPresets(presets []commands.NamedPreset) {
+	s.presets = presets
+	s.selectedPreset = 0
+}
+
+// GetSelectedPreset returns the selected preset index
+func (s *SettingsFeature) GetSelectedPreset() int {
+	return s.selectedPreset
+}
+
+// SetSelectedPreset sets the selected preset index
+func (s *SettingsFeature) SetSelectedPreset(index int) {
+	if index >= 0 && index < len(s.presets) {
+		s.selectedPreset = index
+	}
+}
+
+// GetSelectedPresetInstance returns the selected preset, or nil if there are none
+func (s *SettingsFeature) GetSelectedPresetInstance() *commands.NamedPreset {
+	if s.selectedPreset < len(s.presets) {
+		return &s.presets[s.selectedPreset]
+	}
+	return nil
+}
+
 // ClearCurrentSetting clears the current setting info
 func (s *SettingsFeature) ClearCurrentSetting() {
 	s.currentSettingInfo = nil
 	s.currentSettingType = ""
+	s.currentHistory = nil
 }
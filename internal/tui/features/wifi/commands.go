@@ -1,6 +1,7 @@
 package wifi
 
 import (
+	"context"
 	"gadget/internal/commands"
 	"gadget/internal/config"
 	"gadget/internal/tui/features/media"
@@ -9,22 +10,22 @@ import (
 )
 
 // ConnectWiFiCmd returns a command to connect to a WiFi device
-func ConnectWiFiCmd(cfg *config.Config, ipAndPort string) tea.Cmd {
+func ConnectWiFiCmd(ctx context.Context, cfg *config.Config, ipAndPort string) tea.Cmd {
 	return media.StreamCommand(func() error {
-		return commands.ConnectWiFi(cfg, ipAndPort)
+		return commands.ConnectWiFi(ctx, cfg, ipAndPort)
 	})
 }
 
 // DisconnectWiFiCmd returns a command to disconnect from a WiFi device
-func DisconnectWiFiCmd(cfg *config.Config, ipAndPort string) tea.Cmd {
+func DisconnectWiFiCmd(ctx context.Context, cfg *config.Config, ipAndPort string) tea.Cmd {
 	return media.StreamCommand(func() error {
-		return commands.DisconnectWiFi(cfg, ipAndPort)
+		return commands.DisconnectWiFi(ctx, cfg, ipAndPort)
 	})
 }
 
 // PairWiFiCmd returns a command to pair with a WiFi device
-func PairWiFiCmd(cfg *config.Config, ipAndPort, pairingCode string) tea.Cmd {
+func PairWiFiCmd(ctx context.Context, cfg *config.Config, ipAndPort, pairingCode string) tea.Cmd {
 	return media.StreamCommand(func() error {
-		return commands.PairWiFiDevice(cfg, ipAndPort, pairingCode)
+		return commands.PairWiFiDevice(ctx, cfg, ipAndPort, pairingCode)
 	})
 }
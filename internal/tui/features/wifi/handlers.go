@@ -1,6 +1,7 @@
 package wifi
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/tui/messaging"
 
@@ -8,21 +9,21 @@ import (
 )
 
 // StartWiFiConnect starts a WiFi connection operation
-func (w *WiFiFeature) StartWiFiConnect(input string) tea.Cmd {
+func (w *WiFiFeature) StartWiFiConnect(ctx context.Context, input string) tea.Cmd {
 	w.SetConnecting(true)
-	return ConnectWiFiCmd(w.config, input)
+	return ConnectWiFiCmd(ctx, w.config, input)
 }
 
 // StartWiFiDisconnect starts a WiFi disconnection operation
-func (w *WiFiFeature) StartWiFiDisconnect(input string) tea.Cmd {
+func (w *WiFiFeature) StartWiFiDisconnect(ctx context.Context, input string) tea.Cmd {
 	w.SetDisconnecting(true)
-	return DisconnectWiFiCmd(w.config, input)
+	return DisconnectWiFiCmd(ctx, w.config, input)
 }
 
 // StartWiFiPair starts a WiFi pairing operation
-func (w *WiFiFeature) StartWiFiPair(address, code string) tea.Cmd {
+func (w *WiFiFeature) StartWiFiPair(ctx context.Context, address, code string) tea.Cmd {
 	w.SetPairing(true)
-	return PairWiFiCmd(w.config, address, code)
+	return PairWiFiCmd(ctx, w.config, address, code)
 }
 
 // HandleWiFiConnectDone handles the completion of a WiFi connect operation
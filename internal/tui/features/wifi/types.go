@@ -55,6 +55,18 @@ func (w *WiFiFeature) SetPairing(pairing bool) {
 	w.pairingWiFi = pairing
 }
 
+// IsActive returns true if any WiFi operation is in progress
+func (w *WiFiFeature) IsActive() bool {
+	return w.connectingWiFi || w.disconnectingWiFi || w.pairingWiFi
+}
+
+// FinishOperations clears all WiFi progress states
+func (w *WiFiFeature) FinishOperations() {
+	w.connectingWiFi = false
+	w.disconnectingWiFi = false
+	w.pairingWiFi = false
+}
+
 // SetPairingAddress sets the pairing address
 func (w *WiFiFeature) SetPairingAddress(address string) {
 	w.pairingAddress = address
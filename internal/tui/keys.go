@@ -23,10 +23,20 @@ type KeyMap struct {
 	VimLeft  key.Binding
 	VimRight key.Binding
 
+	// Multi-device selection keys
+	ToggleMark key.Binding
+	MarkAll    key.Binding
+
 	// Text input keys (handled by bubbles TextInput component later)
 	Submit key.Binding
 	Cancel key.Binding
 
+	// Setting input keys
+	Undo     key.Binding
+	Redo     key.Binding
+	Reset    key.Binding
+	Complete key.Binding // Accepts a suggested value, handled by the TextInput component
+
 	// Recording keys
 	StopRecording key.Binding
 
@@ -91,6 +101,16 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("l", "right"),
 		),
 
+		// Multi-device selection
+		ToggleMark: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "mark"),
+		),
+		MarkAll: key.NewBinding(
+			key.WithKeys("ctrl+a"),
+			key.WithHelp("ctrl+a", "mark all"),
+		),
+
 		// Text input
 		Submit: key.NewBinding(
 			key.WithKeys("enter"),
@@ -101,6 +121,24 @@ func DefaultKeyMap() KeyMap {
 			key.WithHelp("esc", "cancel"),
 		),
 
+		// Setting history
+		Undo: key.NewBinding(
+			key.WithKeys("ctrl+z"),
+			key.WithHelp("ctrl+z", "undo"),
+		),
+		Redo: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "redo"),
+		),
+		Reset: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "reset to default"),
+		),
+		Complete: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "complete"),
+		),
+
 		// Recording
 		StopRecording: key.NewBinding(
 			key.WithKeys("esc"),
@@ -123,8 +161,14 @@ func (k KeyMap) SelectionKeys() []key.Binding {
 }
 
 // DeviceSelectKeys returns keys available in device selection mode
-func (k KeyMap) DeviceSelectKeys() []key.Binding {
-	return k.SelectionKeys()
+func (k KeyMap) DeviceSelectKeys(filtering bool) []key.Binding {
+	if filtering {
+		// Letters are typed into the filter, so only the arrows navigate
+		up := key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "up"))
+		down := key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "down"))
+		return []key.Binding{up, down, k.ToggleMark, k.MarkAll, k.Enter, k.Escape, k.Backspace, k.Quit}
+	}
+	return []key.Binding{k.Search, k.VimUp, k.VimDown, k.ToggleMark, k.MarkAll, k.Enter, k.EscapeBack, k.Quit}
 }
 
 // EmulatorSelectKeys returns keys available in emulator selection mode
@@ -132,11 +176,21 @@ func (k KeyMap) EmulatorSelectKeys() []key.Binding {
 	return k.SelectionKeys()
 }
 
+// PresetSelectKeys returns keys available in preset selection mode
+func (k KeyMap) PresetSelectKeys() []key.Binding {
+	return k.SelectionKeys()
+}
+
 // TextInputKeys returns keys available in text input mode
 func (k KeyMap) TextInputKeys() []key.Binding {
 	return []key.Binding{k.Submit, k.Cancel, k.Quit}
 }
 
+// SettingInputKeys returns keys available while entering a setting value
+func (k KeyMap) SettingInputKeys() []key.Binding {
+	return []key.Binding{k.Submit, k.Complete, k.Reset, k.Undo, k.Redo, k.Cancel, k.Quit}
+}
+
 // RecordingKeys returns keys available during recording
 func (k KeyMap) RecordingKeys() []key.Binding {
 	return []key.Binding{k.StopRecording, k.Quit}
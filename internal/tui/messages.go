@@ -18,3 +18,5 @@ type wifiDisconnectDoneMsg = messaging.WiFiDisconnectDoneMsg
 type wifiPairDoneMsg = messaging.WiFiPairDoneMsg
 type emulatorConfigureDoneMsg = messaging.EmulatorConfigureDoneMsg
 type liveOutputMsg = messaging.LiveOutputMsg
+type multiDeviceDoneMsg = messaging.MultiDeviceDoneMsg
+type actionDoneMsg = messaging.ActionDoneMsg
@@ -1,12 +1,14 @@
 package messaging
 
 import (
+	"context"
 	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/commands"
 	"gadget/internal/config"
 	"gadget/internal/emulator"
 	"gadget/internal/tui/capture"
+	"gadget/internal/tui/core"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -29,22 +31,23 @@ func LoadLaunchableAvdsCmd(cfg *config.Config, devices []adb.Device) tea.Cmd {
 }
 
 // LoadSettingCmd returns a command that loads current setting value
-func LoadSettingCmd(cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+func LoadSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
 	return func() tea.Msg {
 		handler := commands.GetSettingHandler(settingType)
-		settingInfo, err := handler.GetInfo(cfg, device)
-		return SettingLoadedMsg{SettingInfo: settingInfo, Err: err}
+		settingInfo, err := handler.GetInfo(ctx, cfg, device)
+		history, _ := commands.LoadHistory(cfg, device.Serial)
+		return SettingLoadedMsg{SettingInfo: settingInfo, History: history, Err: err}
 	}
 }
 
 // ChangeSettingCmd returns a command that changes a device setting
-func ChangeSettingCmd(cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
+func ChangeSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, value string) tea.Cmd {
 	return func() tea.Msg {
 		handler := commands.GetSettingHandler(settingType)
 
 		// Changed: Capture command output (validation happens in SetValue)
 		capturedOutput, err := capture.CaptureCommand(func() error {
-			return handler.SetValue(cfg, device, value)
+			return handler.SetValue(ctx, cfg, device, value)
 		})
 
 		var message string
@@ -53,7 +56,7 @@ func ChangeSettingCmd(cfg *config.Config, device adb.Device, settingType command
 		if success {
 			message = fmt.Sprintf("%s changed to %s on %s", settingType, value, device.Serial)
 		} else {
-			message = fmt.Sprintf("Failed to change %s: %s", settingType, err.Error())
+			message = fmt.Sprintf("Failed to change %s: %s", settingType, core.ErrorText(err))
 		}
 
 		return SettingChangedMsg{
@@ -65,6 +68,56 @@ func ChangeSettingCmd(cfg *config.Config, device adb.Device, settingType command
 	}
 }
 
+// ResetSettingCmd returns a command that resets a device setting to its default
+func ResetSettingCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType) tea.Cmd {
+	return func() tea.Msg {
+		handler := commands.GetSettingHandler(settingType)
+		capturedOutput, err := capture.CaptureCommand(func() error {
+			return handler.Reset(ctx, cfg, device)
+		})
+
+		message := fmt.Sprintf("%s reset to the default on %s", settingType, device.Serial)
+		if err != nil {
+			message = fmt.Sprintf("Failed to reset %s: %s", settingType, core.ErrorText(err))
+		}
+		return SettingChangedMsg{
+			SettingType:    settingType,
+			Success:        err == nil,
+			Message:        message,
+			CapturedOutput: capturedOutput,
+		}
+	}
+}
+
+// StepHistoryCmd returns a command that undoes, or with redo set redoes, the last setting change on a device
+// The result is reported like a setting change of settingType, the setting being shown
+func StepHistoryCmd(ctx context.Context, cfg *config.Config, device adb.Device, settingType commands.SettingType, redo bool) tea.Cmd {
+	return func() tea.Msg {
+		step, verb := commands.Undo, "Undid"
+		if redo {
+			step, verb = commands.Redo, "Redid"
+		}
+
+		var change commands.HistoryChange
+		capturedOutput, err := capture.CaptureCommand(func() error {
+			var err error
+			change, err = step(ctx, cfg, device)
+			return err
+		})
+
+		message := fmt.Sprintf("%s %s on %s", verb, change, device.Serial)
+		if err != nil {
+			message = core.ErrorText(err)
+		}
+		return SettingChangedMsg{
+			SettingType:    settingType,
+			Success:        err == nil,
+			Message:        message,
+			CapturedOutput: capturedOutput,
+		}
+	}
+}
+
 // StartScreenRecordCmd returns a command that starts screen recording
 func StartScreenRecordCmd(cfg *config.Config, device adb.Device) tea.Cmd {
 	return func() tea.Msg {
@@ -72,3 +125,20 @@ func StartScreenRecordCmd(cfg *config.Config, device adb.Device) tea.Cmd {
 		return RecordingStartedMsg{Recording: recording, Err: err}
 	}
 }
+
+// RunOnDevicesCmd returns a command that runs fn on several devices in parallel
+// Per-device results are logged as they are summarized
+func RunOnDevicesCmd(ctx context.Context, action string, devices []adb.Device, fn func(ctx context.Context, device adb.Device) error) tea.Cmd {
+	return func() tea.Msg {
+		results := commands.RunOnDevices(ctx, devices, commands.DefaultDeviceWorkers, fn)
+		err := commands.SummarizeResults(action, results)
+		return MultiDeviceDoneMsg{Action: action, Results: results, Err: err}
+	}
+}
+
+// RunActionCmd returns a command that runs a background action such as restarting the adb server
+func RunActionCmd(ctx context.Context, action string, fn func(ctx context.Context) error) tea.Cmd {
+	return func() tea.Msg {
+		return ActionDoneMsg{Action: action, Err: fn(ctx)}
+	}
+}
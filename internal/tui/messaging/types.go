@@ -23,6 +23,7 @@ type AvdsLoadedMsg struct {
 // SettingLoadedMsg is sent when current setting is retrieved
 type SettingLoadedMsg struct {
 	SettingInfo *commands.SettingInfo
+	History     *commands.History // Nil if the device's history couldn't be read
 	Err         error
 }
 
@@ -56,6 +57,19 @@ type WiFiDisconnectDoneMsg OperationResult
 type WiFiPairDoneMsg OperationResult
 type EmulatorConfigureDoneMsg OperationResult
 
+// MultiDeviceDoneMsg is sent when a command finished on every selected device
+type MultiDeviceDoneMsg struct {
+	Action  string
+	Results []commands.DeviceResult
+	Err     error // Set if any device failed
+}
+
+// ActionDoneMsg is sent when a background action that isn't tied to a device finished
+type ActionDoneMsg struct {
+	Action string
+	Err    error
+}
+
 // LiveOutputMsg is sent when command output is captured in real-time
 type LiveOutputMsg struct {
 	Message string
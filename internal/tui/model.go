@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"gadget/internal/adb"
@@ -41,6 +42,7 @@ const (
 	ModeEmulatorSelect = core.ModeEmulatorSelect
 	ModeCommand        = core.ModeCommand
 	ModeTextInput      = core.ModeTextInput
+	ModePresetSelect   = core.ModePresetSelect
 )
 
 const (
@@ -83,9 +85,13 @@ type Model struct {
 	wifiFeature             *wifi.WiFiFeature
 	settingsFeature         *settings.SettingsFeature
 	selectedDeviceForAction adb.Device
+	// Every target of a setting change started on several devices; empty for single-device changes
+	selectedDevicesForAction []adb.Device
 
 	textInputPrompt string
 	textInputAction string
+	// A setting value rejected as out of range; submitting it again applies it anyway
+	forcedSettingValue string
 
 	searchFilter         string
 	filteredCommands     []Command
@@ -94,9 +100,17 @@ type Model struct {
 
 	operationStartTime time.Time
 
+	// Cancels the in-flight screenshot, recording save or WiFi operation; nil when idle
+	cancelOperation context.CancelFunc
+
+	// Describes the running background action, e.g. a multi-device run; empty when none is running
+	runningAction string
+
+	// Latest transfer progress of the running operation, shown under its indicator
+	transferProgress string
+
 	// Device tracking
-	deviceTrackingActive bool
-	deviceEventChan      <-chan adb.DeviceChangeEvent
+	deviceTracker *adb.DeviceTracker
 
 	keys      KeyMap
 	help      help.Model
@@ -133,6 +147,11 @@ func NewModel(cfg *config.Config) Model {
 	// Set up TUI renderer for unified logging
 	logger.SetRenderer(logger.NewTUIRenderer(logChannel))
 
+	// Setting changes are journaled so they can be reverted when the TUI exits
+	if cfg.NoTrace {
+		commands.EnableJournal(cfg)
+	}
+
 	m.keys = DefaultKeyMap()
 	m.help = help.New()
 	m.textInput = newTextInput()
@@ -162,21 +181,27 @@ func newSpinner() spinner.Model {
 
 // addSuccess adds a success log entry using unified logger
 func (m *Model) addSuccess(message string) {
-	logger.Success(message)
+	logger.Success("%s", message)
 }
 
 // addError adds an error log entry using unified logger
 func (m *Model) addError(message string) {
-	logger.Error(message)
+	logger.Error("%s", message)
 }
 
 // addInfo adds an info log entry using unified logger
 func (m *Model) addInfo(message string) {
-	logger.Info(message)
+	logger.Info("%s", message)
 }
 
 // addLogEntryDirect converts a logger entry to TUI log entry and adds it to history
 func (m *Model) addLogEntryDirect(entry logger.TUILogEntry) {
+	// Progress updates replace each other instead of filling the log
+	if entry.Level == logger.LogLevelProgress {
+		m.transferProgress = entry.Message
+		return
+	}
+
 	// Convert logger levels to TUI log types
 	var logType LogType
 	switch entry.Level {
@@ -345,7 +370,7 @@ func (m Model) fuzzyMatchStringScore(str, filter string) int {
 // Init initializes the model (required by Bubble Tea)
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
-		loadDevices(m.config),
+		loadDevices(m.config, m.devicesFeature.GetInfoCache()),
 		m.spinner.Tick,
 		m.listenForLogs(),
 		devices.StartDeviceTrackingCmd(m.config),
@@ -405,9 +430,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
+		m.finishOperation()
 		_, _, successMsg, errorMsg := m.mediaFeature.HandleScreenshotDone(msg)
 		if successMsg != "" {
 			m.addSuccess(successMsg)
@@ -420,9 +446,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
+		m.finishOperation()
 		_, _, successMsg, errorMsg := m.mediaFeature.HandleDayNightScreenshotDone(msg)
 		if successMsg != "" {
 			m.addSuccess(successMsg)
@@ -441,9 +468,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
+		m.finishOperation()
 		_, _, successMsg, errorMsg := m.mediaFeature.HandleScreenRecordDone(msg)
 		if successMsg != "" {
 			m.addSuccess(successMsg)
@@ -453,6 +481,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case settingLoadedMsg:
+		m.finishOperation()
+		m.runningAction = ""
 		_, _, _, errorMsg := m.settingsFeature.HandleSettingLoaded(msg)
 		if errorMsg != "" {
 			m.err = fmt.Errorf("failed to get current setting: %s", errorMsg)
@@ -476,36 +506,82 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				placeholder = settingInfo.Current
 			case commands.SettingTypeScreenSize:
 				placeholder = settingInfo.Current
+			case commands.SettingTypeAnimations:
+				placeholder = settingInfo.Current
+			case commands.SettingTypeLocale:
+				placeholder = settingInfo.Current
+			case commands.SettingTypeOverlays:
+				placeholder = settingInfo.Current
+			case commands.SettingTypeColorCorrection:
+				placeholder = settingInfo.Current
 			default:
 				placeholder = "Enter new value..."
 			}
 			m.textInput.Placeholder = placeholder
 
-			m.textInputPrompt = fmt.Sprintf("Device: %s\n%s\n\n%s:",
-				m.selectedDeviceForAction.Serial, displayInfo, settingInfo.DisplayName)
+			deviceLine := fmt.Sprintf("Device: %s", m.selectedDeviceForAction.Serial)
+			if len(m.selectedDevicesForAction) > 1 {
+				var serials []string
+				for _, device := range m.selectedDevicesForAction {
+					serials = append(serials, device.Serial)
+				}
+				deviceLine = fmt.Sprintf("Devices: %s (values of %s)", strings.Join(serials, ", "), m.selectedDeviceForAction.Serial)
+			}
+			metadata := commands.GetSettingHandler(settingInfo.Type).Metadata()
+			m.textInput.ShowSuggestions = true
+			m.textInput.SetSuggestions(metadata.Suggestions)
+			allowedLine := ""
+			if allowed := metadata.Range(); allowed != "" {
+				allowedLine = fmt.Sprintf("Allowed: %s\n", allowed)
+			}
+			m.textInputPrompt = fmt.Sprintf("%s\n%s\n%sSuggested: %s\n\n%s:",
+				deviceLine, displayInfo, allowedLine, strings.Join(metadata.Suggestions, ", "), settingInfo.DisplayName)
 		}
 		return m, nil
 	case settingChangedMsg:
+		m.runningAction = ""
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
-		_, cmd, successMsg, errorMsg := m.settingsFeature.HandleSettingChanged(msg, m.selectedDeviceForAction)
+		_, cmd, successMsg, errorMsg := m.settingsFeature.HandleSettingChanged(m.startOperation(), msg, m.selectedDeviceForAction)
 		if successMsg != "" {
 			m.addSuccess(successMsg)
-			// Refresh setting info to show updated values
-			return m, cmd
-		} else if errorMsg != "" {
+			// Refresh setting info to show updated values, cancellable like the change itself
+			m.runningAction = fmt.Sprintf("Loading %s", msg.SettingType)
+			m.operationStartTime = time.Now()
+			return m, tea.Batch(cmd, m.spinner.Tick)
+		}
+		m.finishOperation()
+		if errorMsg != "" {
 			m.addError(errorMsg)
 		}
 		return m, nil
+	case multiDeviceDoneMsg:
+		// Per-device results were already logged by the summary
+		m.finishOperation()
+		m.runningAction = ""
+		if msg.Err != nil {
+			m.addError(core.ErrorText(msg.Err))
+		}
+		return m, nil
+	case actionDoneMsg:
+		m.finishOperation()
+		m.runningAction = ""
+		if msg.Err != nil {
+			m.addError(core.ErrorText(msg.Err))
+		} else {
+			m.addSuccess(msg.Action + " done")
+		}
+		// Recovery actions change device states; show the new ones
+		return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
 	case wifiConnectDoneMsg:
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
 		_, _, successMsg, errorMsg := m.wifiFeature.HandleWiFiConnectDone(msg)
@@ -513,7 +589,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addSuccess(successMsg)
 			m.mode = ModeMenu
 			// Refresh device list after successful WiFi connection
-			return m, loadDevices(m.config)
+			return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
 		} else if errorMsg != "" {
 			m.addError(errorMsg)
 		}
@@ -522,7 +598,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
 		_, _, successMsg, errorMsg := m.wifiFeature.HandleWiFiDisconnectDone(msg)
@@ -530,7 +606,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addSuccess(successMsg)
 			m.mode = ModeMenu
 			// Refresh device list after successful WiFi disconnection
-			return m, loadDevices(m.config)
+			return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
 		} else if errorMsg != "" {
 			m.addError(errorMsg)
 		}
@@ -539,7 +615,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Log captured output
 		for _, line := range msg.CapturedOutput {
 			if strings.TrimSpace(line) != "" {
-				logger.Info(line)
+				logger.Info("%s", line)
 			}
 		}
 		_, _, successMsg, errorMsg := m.wifiFeature.HandleWiFiPairDone(msg)
@@ -547,7 +623,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addSuccess(successMsg)
 			m.mode = ModeMenu
 			// Refresh device list after successful WiFi pairing
-			return m, loadDevices(m.config)
+			return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
 		} else if errorMsg != "" {
 			m.addError(errorMsg)
 		}
@@ -569,7 +645,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case media.StreamingCommandStart:
 		// Handle day-night streaming command
-		return m, PollChannels(msg.OutputChan, nil, msg.Config, msg.Device, msg.Timestamp)
+		return m, PollChannels(msg.OutputChan, msg.ErrChan, msg.Config, msg.Device, msg.Timestamp)
 	case media.GenericStreamingStart:
 		// Handle any generic streaming command
 		return m, PollGenericChannels(msg.OutputChan)
@@ -586,26 +662,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case GenericCommandComplete:
 		// Handle completion of generic streaming commands
 		// Check what operation was active and finish it appropriately
+		m.finishOperation()
 		if m.mediaFeature.IsTakingScreenshot() {
 			m.mediaFeature.FinishScreenshot()
 		}
-		// WiFi and other operations don't have progress indicators that need clearing
+		if m.wifiFeature.IsActive() {
+			m.wifiFeature.FinishOperations()
+		}
 		return m, nil
 	case messaging.DeviceRefreshMsg:
 		// Handle device refresh requests from various sources
-		return m, devices.LoadDevicesCmd(m.config)
+		return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
 	case devices.DeviceTrackingStartedMsg:
-		// Device tracking started successfully, store channel and start listening
-		m.deviceTrackingActive = true
-		m.deviceEventChan = msg.EventChan
-		return m, devices.WaitForDeviceChangeCmd(m.deviceEventChan)
+		// Device tracking started, store tracker and start listening
+		m.deviceTracker = msg.Tracker
+		return m, devices.WaitForDeviceChangeCmd(m.deviceTracker)
+	case devices.DeviceChangedMsg:
+		_, cmd, infoMsg, _ := m.devicesFeature.HandleDeviceChanged(msg)
+		if infoMsg != "" {
+			m.addInfo(infoMsg)
+		}
+		return m, tea.Batch(cmd, devices.WaitForDeviceChangeCmd(m.deviceTracker))
+	case devices.TrackerHealthMsg:
+		_, cmd, successMsg, errorMsg := m.devicesFeature.HandleTrackerHealth(msg)
+		if successMsg != "" {
+			m.addSuccess(successMsg)
+		}
+		if errorMsg != "" {
+			m.addError(errorMsg)
+		}
+		return m, tea.Batch(cmd, devices.WaitForDeviceChangeCmd(m.deviceTracker))
+	case devices.TrackerStoppedMsg:
+		m.deviceTracker = nil
+		return m, nil
 	case ChannelPollResult:
 		// Handle live output from day-night streaming
 		if msg.LiveOutput != "" {
 			m.addInfo(msg.LiveOutput)
 		}
 		if msg.ShouldPoll {
-			return m, PollChannels(msg.OutputChan, nil, msg.Config, msg.Device, msg.Timestamp)
+			return m, PollChannels(msg.OutputChan, msg.ErrChan, msg.Config, msg.Device, msg.Timestamp)
 		}
 		return m, nil
 	case tea.WindowSizeMsg:
@@ -624,6 +720,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Global key handling for quit
 	if key.Matches(msg, m.keys.Quit) {
+		m.finishOperation()
+		if m.deviceTracker != nil {
+			m.deviceTracker.Stop()
+		}
+		adb.CloseShellSessions()
 		return m, tea.Quit
 	}
 
@@ -641,6 +742,8 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.searchFilter = ""
 				m.filteredCommands = m.filterCommands()
 				m.selectedCommandIndex = 0
+			} else if m.cancelOperation != nil {
+				m.cancelActiveOperation()
 			}
 			return m, nil
 		} else if key.Matches(msg, m.keys.Up) {
@@ -693,22 +796,53 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 	case ModeDeviceSelect:
+		// While filtering, letters go to the filter so only the arrow keys navigate
+		filtering := m.devicesFeature.IsFiltering()
+		moveUp := msg.Type == tea.KeyUp || !filtering && key.Matches(msg, m.keys.VimUp)
+		moveDown := msg.Type == tea.KeyDown || !filtering && key.Matches(msg, m.keys.VimDown)
 		if key.Matches(msg, m.keys.Escape) {
-			m.mode = ModeMenu
+			// Clear the device filter first, go back on the next Esc
+			if filtering {
+				m.devicesFeature.ClearFilter()
+			} else {
+				m.mode = ModeMenu
+			}
 			return m, nil
-		} else if key.Matches(msg, m.keys.VimUp) {
+		} else if moveUp {
 			selectedDevice := m.devicesFeature.GetSelectedDevice()
 			if selectedDevice > 0 {
 				m.devicesFeature.SetSelectedDevice(selectedDevice - 1)
 			}
 			return m, nil
-		} else if key.Matches(msg, m.keys.VimDown) {
+		} else if moveDown {
 			selectedDevice := m.devicesFeature.GetSelectedDevice()
-			if selectedDevice < len(m.devicesFeature.GetDevices())-1 {
+			if selectedDevice < len(m.devicesFeature.GetFilteredDevices())-1 {
 				m.devicesFeature.SetSelectedDevice(selectedDevice + 1)
 			}
 			return m, nil
+		} else if key.Matches(msg, m.keys.Backspace) {
+			m.devicesFeature.DeleteFromFilter()
+			return m, nil
+		} else if key.Matches(msg, m.keys.ToggleMark) {
+			m.devicesFeature.ToggleMarked()
+			return m, nil
+		} else if key.Matches(msg, m.keys.MarkAll) {
+			m.devicesFeature.ToggleAllMarked()
+			return m, nil
+		} else if key.Matches(msg, m.keys.Search) && !filtering {
+			m.devicesFeature.StartFiltering()
+			return m, nil
+		} else if filtering && msg.Type == tea.KeyRunes {
+			// Typed text narrows the list with the same selectors -device accepts
+			m.devicesFeature.AppendToFilter(string(msg.Runes))
+			return m, nil
 		} else if key.Matches(msg, m.keys.Enter) {
+			// Marked devices take precedence over the one under the cursor
+			if marked := m.devicesFeature.GetMarkedDevices(); len(marked) > 1 {
+				return m.executeCommandForDevices(marked)
+			} else if len(marked) == 1 {
+				return m.executeCommandForDevice(marked[0])
+			}
 			selectedDevice := m.devicesFeature.GetSelectedDeviceInstance()
 			if selectedDevice != nil {
 				return m.executeCommandForDevice(*selectedDevice)
@@ -733,12 +867,37 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		} else if key.Matches(msg, m.keys.Enter) {
 			return m.executeEmulatorCommand()
 		}
+	case ModePresetSelect:
+		if key.Matches(msg, m.keys.Escape) {
+			m.mode = ModeMenu
+			return m, nil
+		} else if key.Matches(msg, m.keys.VimUp) {
+			m.settingsFeature.SetSelectedPreset(m.settingsFeature.GetSelectedPreset() - 1)
+			return m, nil
+		} else if key.Matches(msg, m.keys.VimDown) {
+			m.settingsFeature.SetSelectedPreset(m.settingsFeature.GetSelectedPreset() + 1)
+			return m, nil
+		} else if key.Matches(msg, m.keys.Enter) {
+			return m.executePresetApply()
+		}
 	case ModeTextInput:
+		if m.isSettingInput() && (key.Matches(msg, m.keys.Undo) || key.Matches(msg, m.keys.Redo)) {
+			return m.stepSettingHistory(key.Matches(msg, m.keys.Redo))
+		}
+		if m.isSettingInput() && key.Matches(msg, m.keys.Reset) {
+			return m.executeSettingReset(commands.SettingType(m.textInputAction))
+		}
 		if key.Matches(msg, m.keys.Submit) {
 			return m.handleTextInputSubmit()
 		} else if key.Matches(msg, m.keys.Cancel) {
+			// The first Esc cancels a setting change still running, the next leaves the input
+			if m.cancelOperation != nil {
+				m.cancelActiveOperation()
+				return m, nil
+			}
 			m.mode = ModeMenu
 			m.textInput.SetValue("")
+			m.clearSettingSuggestions()
 			m.textInputPrompt = ""
 			m.textInputAction = ""
 			return m, nil
@@ -758,8 +917,9 @@ func (m Model) executeScreenshot(device adb.Device) (tea.Model, tea.Cmd) {
 	m.mode = ModeMenu
 	m.mediaFeature.StartScreenshot()
 	m.operationStartTime = time.Now()
+	ctx := m.startOperation()
 
-	return m, tea.Batch(takeScreenshot(m.config, device), m.spinner.Tick)
+	return m, tea.Batch(takeScreenshot(ctx, m.config, device), m.spinner.Tick)
 }
 
 // executeDayNightScreenshots runs the day-night screenshot command
@@ -767,8 +927,9 @@ func (m Model) executeDayNightScreenshots(device adb.Device) (tea.Model, tea.Cmd
 	m.mode = ModeMenu
 	m.mediaFeature.StartDayNightScreenshot()
 	m.operationStartTime = time.Now()
+	ctx := m.startOperation()
 
-	return m, tea.Batch(takeDayNightScreenshots(m.config, device), m.spinner.Tick)
+	return m, tea.Batch(takeDayNightScreenshots(ctx, m.config, device), m.spinner.Tick)
 }
 
 // executeSelectedCommand executes the currently selected command from the filtered list
@@ -816,13 +977,39 @@ func (m Model) executeSelectedCommand() (tea.Model, tea.Cmd) {
 		return m, nil
 	case "refresh-devices":
 		m.clearLogs()
-		return m, loadDevices(m.config)
+		return m, loadDevices(m.config, m.devicesFeature.GetInfoCache())
+	case "fix-devices":
+		return m.runAction("Fix devices", func(ctx context.Context) error {
+			return commands.FixDevices(ctx, m.config, commands.DefaultFixOptions)
+		})
+	case "reconnect-offline":
+		return m.runAction("Reconnect offline devices", func(ctx context.Context) error {
+			return commands.ReconnectOffline(ctx, m.config)
+		})
+	case "restart-adb-server":
+		return m.runAction("Restart ADB server", func(ctx context.Context) error {
+			return commands.RestartServer(ctx, m.config)
+		})
+	case "adb-server-version":
+		return m.runAction("ADB server version check", func(ctx context.Context) error {
+			return commands.ShowServerVersion(ctx, m.config)
+		})
+	case "revert-changes":
+		return m.runAction("Revert changes", func(ctx context.Context) error {
+			return commands.RevertJournals(ctx, m.config)
+		})
+	case "regenerate-adb-keys":
+		return m.runAction("Regenerate host adb keys", func(ctx context.Context) error {
+			return commands.RegenerateADBKeys(ctx, m.config)
+		})
 	default:
 		// Commands that require device selection
 		devices := m.devicesFeature.GetDevices()
 		if len(devices) == 1 {
 			return m.executeCommandForDevice(devices[0])
 		}
+		m.devicesFeature.ClearFilter()
+		m.devicesFeature.ClearMarked()
 		m.mode = ModeDeviceSelect
 		return m, nil
 	}
@@ -836,11 +1023,30 @@ func (m Model) executeCommandForDevice(device adb.Device) (tea.Model, tea.Cmd) {
 
 	selectedCmd := m.filteredCommands[m.selectedCommandIndex]
 
+	if selectedCmd.Command == "authorize-device" {
+		return m.runAction("Re-request authorization", func(ctx context.Context) error {
+			return commands.RequestAuthorization(ctx, m.config, device)
+		})
+	}
+
+	// Unauthorized and offline devices reject every command; point at the fix instead
+	if err := adb.StatusError(device); err != nil {
+		m.mode = ModeMenu
+		m.addError(core.ErrorText(err))
+		m.addInfo("Run \"Fix devices\" to recover it")
+		return m, nil
+	}
+
 	switch selectedCmd.Command {
 	case "screenshot":
 		return m.executeScreenshot(device)
 	case "screenshot-day-night":
 		return m.executeDayNightScreenshots(device)
+	case "screenshot-pseudo-locales":
+		return m.runAction("Pseudo-locale screenshots", func(ctx context.Context) error {
+			locales, _ := commands.ParseLocales("")
+			return commands.TakeLocaleScreenshots(ctx, m.config, device, locales)
+		})
 	case "screen-record":
 		return m.executeScreenRecord(device)
 	case "dpi":
@@ -849,12 +1055,188 @@ func (m Model) executeCommandForDevice(device adb.Device) (tea.Model, tea.Cmd) {
 		return m.startSettingChange(device, commands.SettingTypeFontSize)
 	case "screen-size":
 		return m.startSettingChange(device, commands.SettingTypeScreenSize)
+	case "animations":
+		return m.startSettingChange(device, commands.SettingTypeAnimations)
+	case "toggle-dark-mode":
+		return m.runAction("Toggle dark mode", func(ctx context.Context) error {
+			return commands.ToggleDarkMode(ctx, m.config, device)
+		})
+	case "locale":
+		return m.startSettingChange(device, commands.SettingTypeLocale)
+	case "pseudo-locale-accents":
+		return m.runAction("Pseudo-locale accents", func(ctx context.Context) error {
+			return commands.SetPseudoLocale(ctx, m.config, device, "accents")
+		})
+	case "pseudo-locale-rtl":
+		return m.runAction("Pseudo-locale RTL", func(ctx context.Context) error {
+			return commands.SetPseudoLocale(ctx, m.config, device, "rtl")
+		})
+	case "overlay-layout-bounds", "overlay-overdraw", "overlay-gpu-profile", "overlay-taps", "overlay-pointer-location":
+		name := strings.TrimPrefix(selectedCmd.Command, "overlay-")
+		return m.runAction("Toggle "+selectedCmd.Name, func(ctx context.Context) error {
+			return commands.ToggleDebugOverlay(ctx, m.config, device, name)
+		})
+	case "overlays":
+		return m.startSettingChange(device, commands.SettingTypeOverlays)
+	case "toggle-talkback", "toggle-high-contrast-text", "toggle-color-inversion":
+		settingType := accessibilityToggles[selectedCmd.Command]
+		return m.runAction(selectedCmd.Name, func(ctx context.Context) error {
+			return commands.ToggleSetting(ctx, m.config, device, settingType)
+		})
+	case "color-correction":
+		return m.startSettingChange(device, commands.SettingTypeColorCorrection)
+	case "toggle-remove-animations":
+		return m.runAction("Toggle remove animations", func(ctx context.Context) error {
+			return commands.ToggleRemoveAnimations(ctx, m.config, device)
+		})
+	case "reset-accessibility":
+		return m.runAction("Reset accessibility", func(ctx context.Context) error {
+			return commands.ResetSettings(ctx, m.config, device, commands.AccessibilitySettingTypes)
+		})
+	case "apply-preset":
+		return m.startPresetSelect([]adb.Device{device})
+	case "emulate-screen":
+		return m.startEmulateScreen([]adb.Device{device})
+	case "window-class":
+		return m.startWindowClass([]adb.Device{device})
+	case "save-preset":
+		m.selectedDeviceForAction = device
+		m.mode = ModeTextInput
+		m.textInput.Focus()
+		m.textInput.Placeholder = "e.g. my-tablet"
+		m.textInputPrompt = fmt.Sprintf("Save the settings of %s as preset", device.Serial)
+		m.textInputAction = "preset_save"
+		m.textInput.SetValue("")
+		return m, nil
+	case "snapshot-settings":
+		return m.runAction("Snapshot settings", func(ctx context.Context) error {
+			return commands.TakeSnapshot(ctx, m.config, device)
+		})
+	case "restore-settings":
+		return m.runAction("Restore settings", func(ctx context.Context) error {
+			return commands.RestoreSnapshot(ctx, m.config, device)
+		})
 	default:
 		// Fallback to screenshot
 		return m.executeScreenshot(device)
 	}
 }
 
+// executeCommandForDevices executes the selected command on several devices in parallel
+func (m Model) executeCommandForDevices(devices []adb.Device) (tea.Model, tea.Cmd) {
+	if len(m.filteredCommands) == 0 || m.selectedCommandIndex >= len(m.filteredCommands) {
+		return m, nil
+	}
+
+	selectedCmd := m.filteredCommands[m.selectedCommandIndex]
+	cfg := m.config
+
+	switch selectedCmd.Command {
+	case "screenshot":
+		return m.runOnDevices("Screenshot", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.TakeScreenshot(ctx, cfg, device)
+		})
+	case "screenshot-day-night":
+		return m.runOnDevices("Day-night screenshots", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.TakeDayNightScreenshots(ctx, cfg, device)
+		})
+	case "screenshot-pseudo-locales":
+		locales, _ := commands.ParseLocales("")
+		return m.runOnDevices("Pseudo-locale screenshots", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.TakeLocaleScreenshots(ctx, cfg, device, locales)
+		})
+	case "dpi":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeDPI)
+	case "font-size":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeFontSize)
+	case "screen-size":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeScreenSize)
+	case "animations":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeAnimations)
+	case "toggle-dark-mode":
+		return m.runOnDevices("Toggle dark mode", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ToggleDarkMode(ctx, cfg, device)
+		})
+	case "locale":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeLocale)
+	case "pseudo-locale-accents":
+		return m.runOnDevices("Pseudo-locale accents", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.SetPseudoLocale(ctx, cfg, device, "accents")
+		})
+	case "pseudo-locale-rtl":
+		return m.runOnDevices("Pseudo-locale RTL", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.SetPseudoLocale(ctx, cfg, device, "rtl")
+		})
+	case "overlay-layout-bounds", "overlay-overdraw", "overlay-gpu-profile", "overlay-taps", "overlay-pointer-location":
+		name := strings.TrimPrefix(selectedCmd.Command, "overlay-")
+		return m.runOnDevices("Toggle "+selectedCmd.Name, devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ToggleDebugOverlay(ctx, cfg, device, name)
+		})
+	case "overlays":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeOverlays)
+	case "toggle-talkback", "toggle-high-contrast-text", "toggle-color-inversion":
+		settingType := accessibilityToggles[selectedCmd.Command]
+		return m.runOnDevices(selectedCmd.Name, devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ToggleSetting(ctx, cfg, device, settingType)
+		})
+	case "color-correction":
+		return m.startMultiDeviceSettingChange(devices, commands.SettingTypeColorCorrection)
+	case "toggle-remove-animations":
+		return m.runOnDevices("Toggle remove animations", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ToggleRemoveAnimations(ctx, cfg, device)
+		})
+	case "reset-accessibility":
+		return m.runOnDevices("Reset accessibility", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ResetSettings(ctx, cfg, device, commands.AccessibilitySettingTypes)
+		})
+	case "apply-preset":
+		return m.startPresetSelect(devices)
+	case "emulate-screen":
+		return m.startEmulateScreen(devices)
+	case "window-class":
+		return m.startWindowClass(devices)
+	case "snapshot-settings":
+		return m.runOnDevices("Snapshot settings", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.TakeSnapshot(ctx, cfg, device)
+		})
+	case "restore-settings":
+		return m.runOnDevices("Restore settings", devices, func(ctx context.Context, device adb.Device) error {
+			return commands.RestoreSnapshot(ctx, cfg, device)
+		})
+	default:
+		m.mode = ModeMenu
+		m.err = fmt.Errorf("%s runs on one device at a time", selectedCmd.Name)
+		return m, nil
+	}
+}
+
+// runOnDevices starts a cancellable operation that runs fn on every device in parallel
+func (m Model) runOnDevices(action string, devices []adb.Device, fn func(ctx context.Context, device adb.Device) error) (tea.Model, tea.Cmd) {
+	m.mode = ModeMenu
+	m.runningAction = fmt.Sprintf("%s on %d devices", action, len(devices))
+	m.operationStartTime = time.Now()
+	ctx := m.startOperation()
+
+	return m, tea.Batch(messaging.RunOnDevicesCmd(ctx, action, devices, fn), m.spinner.Tick)
+}
+
+// accessibilityToggles maps the TUI's accessibility toggles to the settings they switch
+var accessibilityToggles = map[string]commands.SettingType{
+	"toggle-talkback":           commands.SettingTypeTalkBack,
+	"toggle-high-contrast-text": commands.SettingTypeHighContrastText,
+	"toggle-color-inversion":    commands.SettingTypeColorInversion,
+}
+
+// runAction starts a cancellable background action that isn't tied to a selected device
+func (m Model) runAction(action string, fn func(ctx context.Context) error) (tea.Model, tea.Cmd) {
+	m.mode = ModeMenu
+	m.runningAction = action
+	m.operationStartTime = time.Now()
+	ctx := m.startOperation()
+
+	return m, tea.Batch(messaging.RunActionCmd(ctx, action, fn), m.spinner.Tick)
+}
+
 // executeScreenRecord runs the screen recording command
 func (m Model) executeScreenRecord(device adb.Device) (tea.Model, tea.Cmd) {
 	m.mode = ModeMenu
@@ -865,10 +1247,17 @@ func (m Model) executeScreenRecord(device adb.Device) (tea.Model, tea.Cmd) {
 }
 
 // stopRecording stops the active recording and saves it
+// Pressing Esc again while the recording is being saved cancels the transfer
 func (m Model) stopRecording() (tea.Model, tea.Cmd) {
+	if m.cancelOperation != nil {
+		m.cancelActiveOperation()
+		return m, nil
+	}
+
 	activeRecording := m.mediaFeature.GetActiveRecording()
 	if activeRecording != nil {
-		return m, stopAndSaveRecording(activeRecording)
+		m.addInfo("Saving recording • Press Esc again to cancel")
+		return m, stopAndSaveRecording(m.startOperation(), activeRecording)
 	}
 	m.mediaFeature.FinishRecording()
 	return m, nil
@@ -877,17 +1266,41 @@ func (m Model) stopRecording() (tea.Model, tea.Cmd) {
 // startSettingChange initiates setting change for the selected device
 func (m Model) startSettingChange(device adb.Device, settingType commands.SettingType) (tea.Model, tea.Cmd) {
 	m.selectedDeviceForAction = device
+	m.selectedDevicesForAction = nil
+	m.textInputAction = string(settingType)
+	m.mode = ModeMenu
+
+	return m.runSettingCmd(fmt.Sprintf("Loading %s", settingType), func(ctx context.Context) tea.Cmd {
+		return getCurrentSetting(ctx, m.config, device, settingType)
+	})
+}
+
+// startMultiDeviceSettingChange initiates a setting change for several devices,
+// showing the current values of the first one
+func (m Model) startMultiDeviceSettingChange(devices []adb.Device, settingType commands.SettingType) (tea.Model, tea.Cmd) {
+	m.selectedDeviceForAction = devices[0]
+	m.selectedDevicesForAction = devices
 	m.textInputAction = string(settingType)
+	m.mode = ModeMenu
 
-	return m, getCurrentSetting(m.config, device, settingType)
+	return m.runSettingCmd(fmt.Sprintf("Loading %s", settingType), func(ctx context.Context) tea.Cmd {
+		return getCurrentSetting(ctx, m.config, devices[0], settingType)
+	})
+}
+
+// runSettingCmd starts a cancellable setting operation on the selected device, staying in the current mode
+func (m Model) runSettingCmd(action string, run func(ctx context.Context) tea.Cmd) (tea.Model, tea.Cmd) {
+	m.runningAction = action
+	m.operationStartTime = time.Now()
+	ctx := m.startOperation()
+
+	return m, tea.Batch(run(ctx), m.spinner.Tick)
 }
 
 // handleTextInputSubmit handles submission of text input
 func (m Model) handleTextInputSubmit() (tea.Model, tea.Cmd) {
-	settingType := commands.SettingType(m.textInputAction)
-	switch settingType {
-	case commands.SettingTypeDPI, commands.SettingTypeFontSize, commands.SettingTypeScreenSize:
-		return m.executeSettingChange(settingType)
+	if m.isSettingInput() {
+		return m.executeSettingChange(commands.SettingType(m.textInputAction))
 	}
 
 	// Handle WiFi actions
@@ -900,6 +1313,12 @@ func (m Model) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 		return m.handlePairingAddressInput()
 	case "wifi_pair_code":
 		return m.executeWiFiPair()
+	case "preset_save":
+		return m.executePresetSave()
+	case "emulate_screen":
+		return m.executeEmulateScreen()
+	case "window_class":
+		return m.executeWindowClass()
 	}
 
 	// Reset to menu if unknown action
@@ -910,15 +1329,98 @@ func (m Model) handleTextInputSubmit() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// isSettingInput reports whether the text input is asking for a setting value
+func (m Model) isSettingInput() bool {
+	return commands.GetSettingHandler(commands.SettingType(m.textInputAction)) != nil
+}
+
+// stepSettingHistory undoes or redoes the last setting change on the device being changed
+func (m Model) stepSettingHistory(redo bool) (tea.Model, tea.Cmd) {
+	if len(m.selectedDevicesForAction) > 1 {
+		m.addError("Undo and redo work on one device at a time")
+		return m, nil
+	}
+	verb := "Undoing"
+	if redo {
+		verb = "Redoing"
+	}
+	device, settingType := m.selectedDeviceForAction, commands.SettingType(m.textInputAction)
+	return m.runSettingCmd(verb+" the last change", func(ctx context.Context) tea.Cmd {
+		return stepSettingHistory(ctx, m.config, device, settingType, redo)
+	})
+}
+
 // executeSettingChange processes the setting change
 func (m Model) executeSettingChange(settingType commands.SettingType) (tea.Model, tea.Cmd) {
 	// Stay in text input mode, just clear the input and send the command
 
-	// Save input and clear it
 	input := m.textInput.Value()
+	handler := commands.GetSettingHandler(settingType)
+
+	// Rejected values stay in the input; one outside the allowed range is applied if submitted again
+	if err := handler.Validate(input); err != nil {
+		outOfRange := errors.Is(err, commands.ErrValueOutOfRange)
+		if !outOfRange || (input != m.forcedSettingValue && !m.config.Force) {
+			if outOfRange {
+				m.forcedSettingValue = input
+				m.addError(fmt.Sprintf("%v, press enter again to apply it anyway", err))
+			} else {
+				m.addError(err.Error())
+			}
+			return m, nil
+		}
+	}
+	m.forcedSettingValue = ""
+	m.textInput.SetValue("")
+
+	// Several devices are changed in one go and reported together, back in the menu
+	if len(m.selectedDevicesForAction) > 1 {
+		devices := m.selectedDevicesForAction
+		m.selectedDevicesForAction = nil
+		m.clearSettingSuggestions()
+		m.textInputPrompt = ""
+		m.textInputAction = ""
+		cfg := m.config
+		return m.runOnDevices(fmt.Sprintf("Setting %s to %s", settingType, input), devices, func(ctx context.Context, device adb.Device) error {
+			return handler.SetValue(ctx, cfg, device, input)
+		})
+	}
+
+	device := m.selectedDeviceForAction
+	return m.runSettingCmd(fmt.Sprintf("Setting %s to %s", settingType, input), func(ctx context.Context) tea.Cmd {
+		return changeSetting(ctx, m.config, device, settingType, input)
+	})
+}
+
+// clearSettingSuggestions stops offering setting values, before the text input is used for something else
+func (m *Model) clearSettingSuggestions() {
+	m.textInput.SetSuggestions(nil)
+	m.textInput.ShowSuggestions = false
+	m.forcedSettingValue = ""
+}
+
+// executeSettingReset resets the setting being changed to the device default
+func (m Model) executeSettingReset(settingType commands.SettingType) (tea.Model, tea.Cmd) {
 	m.textInput.SetValue("")
 
-	return m, changeSetting(m.config, m.selectedDeviceForAction, settingType, input)
+	// Several devices are reset in one go and reported together, back in the menu
+	if len(m.selectedDevicesForAction) > 1 {
+		devices := m.selectedDevicesForAction
+		m.selectedDevicesForAction = nil
+		m.clearSettingSuggestions()
+		m.textInputPrompt = ""
+		m.textInputAction = ""
+		handler := commands.GetSettingHandler(settingType)
+		cfg := m.config
+		return m.runOnDevices(fmt.Sprintf("Resetting %s", settingType), devices, func(ctx context.Context, device adb.Device) error {
+			return handler.Reset(ctx, cfg, device)
+		})
+	}
+
+	device := m.selectedDeviceForAction
+	return m.runSettingCmd(fmt.Sprintf("Resetting %s", settingType), func(ctx context.Context) tea.Cmd {
+		return resetSetting(ctx, m.config, device, settingType)
+	})
 }
 
 // executeWiFiConnect processes WiFi connection
@@ -932,10 +1434,183 @@ func (m Model) executeWiFiConnect() (tea.Model, tea.Cmd) {
 	m.textInputPrompt = ""
 	m.textInputAction = ""
 
-	cmd := m.wifiFeature.StartWiFiConnect(input)
+	cmd := m.wifiFeature.StartWiFiConnect(m.startOperation(), input)
 	return m, tea.Batch(cmd, m.spinner.Tick)
 }
 
+// startPresetSelect shows the preset picker for the devices a preset will be applied to
+func (m Model) startPresetSelect(devices []adb.Device) (tea.Model, tea.Cmd) {
+	presets, err := commands.LoadPresets(m.config)
+	if err != nil {
+		m.mode = ModeMenu
+		m.addError(core.ErrorText(err))
+		return m, nil
+	}
+
+	m.selectedDeviceForAction = devices[0]
+	m.selectedDevicesForAction = devices
+	m.settingsFeature.SetPresets(presets)
+	m.mode = ModePresetSelect
+	return m, nil
+}
+
+// executePresetApply applies the selected preset to the devices it was picked for
+func (m Model) executePresetApply() (tea.Model, tea.Cmd) {
+	preset := m.settingsFeature.GetSelectedPresetInstance()
+	if preset == nil {
+		return m, nil
+	}
+
+	name := preset.Name
+	cfg := m.config
+	devices := m.selectedDevicesForAction
+	m.selectedDevicesForAction = nil
+	action := fmt.Sprintf("Apply preset %s", name)
+	if len(devices) > 1 {
+		return m.runOnDevices(action, devices, func(ctx context.Context, device adb.Device) error {
+			return commands.ApplyPreset(ctx, cfg, device, name)
+		})
+	}
+	device := devices[0]
+	return m.runAction(action, func(ctx context.Context) error {
+		return commands.ApplyPreset(ctx, cfg, device, name)
+	})
+}
+
+// executePresetSave saves the settings of the selected device as a preset named by the input
+func (m Model) executePresetSave() (tea.Model, tea.Cmd) {
+	name := strings.TrimSpace(m.textInput.Value())
+	if name == "" {
+		m.addError("Enter a name for the preset")
+		return m, nil
+	}
+	m.textInput.SetValue("")
+	m.textInputPrompt = ""
+	m.textInputAction = ""
+
+	cfg := m.config
+	device := m.selectedDeviceForAction
+	return m.runAction(fmt.Sprintf("Save preset %s", name), func(ctx context.Context) error {
+		return commands.SavePreset(ctx, cfg, device, name)
+	})
+}
+
+// startEmulateScreen asks which catalog device the devices should lay out like
+func (m Model) startEmulateScreen(devices []adb.Device) (tea.Model, tea.Cmd) {
+	m.selectedDeviceForAction = devices[0]
+	m.selectedDevicesForAction = devices
+	m.mode = ModeTextInput
+	m.textInput.Focus()
+	m.textInput.Placeholder = "e.g. Pixel 4a"
+	m.textInput.ShowSuggestions = true
+	m.textInput.SetSuggestions(append(commands.DeviceSpecNames(), "reset"))
+	m.textInputPrompt = fmt.Sprintf("Devices: %s\n\nEmulate the screen of (or reset)", strings.Join(commands.DeviceSpecNames(), ", "))
+	m.textInputAction = "emulate_screen"
+	m.textInput.SetValue("")
+	return m, nil
+}
+
+// executeEmulateScreen sets the selected devices to lay out like the catalog device in the input
+func (m Model) executeEmulateScreen() (tea.Model, tea.Cmd) {
+	name := strings.TrimSpace(m.textInput.Value())
+	reset := name == "reset"
+	if _, err := commands.FindDeviceSpec(name); err != nil && !reset {
+		m.addError(core.ErrorText(err))
+		return m, nil
+	}
+	m.textInput.SetValue("")
+	m.clearSettingSuggestions()
+	m.textInputPrompt = ""
+	m.textInputAction = ""
+
+	cfg := m.config
+	devices := m.selectedDevicesForAction
+	m.selectedDevicesForAction = nil
+	apply := func(ctx context.Context, device adb.Device) error {
+		if reset {
+			return commands.ResetEmulatedScreen(ctx, cfg, device)
+		}
+		return commands.EmulateScreen(ctx, cfg, device, name)
+	}
+	action := fmt.Sprintf("Emulate %s", name)
+	if reset {
+		action = "Reset emulated screen"
+	}
+	if len(devices) > 1 {
+		return m.runOnDevices(action, devices, apply)
+	}
+	device := devices[0]
+	return m.runAction(action, func(ctx context.Context) error {
+		return apply(ctx, device)
+	})
+}
+
+// startWindowClass asks which width and height class the devices' window should land in
+func (m Model) startWindowClass(devices []adb.Device) (tea.Model, tea.Cmd) {
+	var suggestions []string
+	for _, width := range commands.WidthClasses {
+		suggestions = append(suggestions, width.Name)
+		for _, height := range commands.HeightClasses {
+			suggestions = append(suggestions, width.Name+" "+height.Name)
+		}
+	}
+	suggestions = append(suggestions, "reset")
+
+	m.selectedDeviceForAction = devices[0]
+	m.selectedDevicesForAction = devices
+	m.mode = ModeTextInput
+	m.textInput.Focus()
+	m.textInput.Placeholder = "e.g. medium compact"
+	m.textInput.ShowSuggestions = true
+	m.textInput.SetSuggestions(suggestions)
+	m.textInputPrompt = "Classes: compact, medium, expanded (width under 600dp, under 840dp, from 840dp; height under 480dp, under 900dp, from 900dp)\n\nWidth class, then an optional height class, or reset"
+	m.textInputAction = "window_class"
+	m.textInput.SetValue("")
+	return m, nil
+}
+
+// executeWindowClass lands the selected devices' window in the classes in the input
+func (m Model) executeWindowClass() (tea.Model, tea.Cmd) {
+	fields := strings.Fields(m.textInput.Value())
+	if len(fields) == 0 || len(fields) > 2 {
+		m.addError("Enter a width class and an optional height class, e.g. medium compact")
+		return m, nil
+	}
+	reset := len(fields) == 1 && fields[0] == "reset"
+	for _, class := range fields {
+		if !reset && !commands.IsWindowSizeClass(class) {
+			m.addError(fmt.Sprintf("Invalid window class: %s (expected compact, medium or expanded)", class))
+			return m, nil
+		}
+	}
+	widthClass, heightClass := fields[0], ""
+	if len(fields) > 1 {
+		heightClass = fields[1]
+	}
+	m.textInput.SetValue("")
+	m.clearSettingSuggestions()
+	m.textInputPrompt = ""
+	m.textInputAction = ""
+
+	cfg := m.config
+	devices := m.selectedDevicesForAction
+	m.selectedDevicesForAction = nil
+	apply := func(ctx context.Context, device adb.Device) error {
+		if reset {
+			return commands.ResetSettings(ctx, cfg, device, []commands.SettingType{commands.SettingTypeScreenSize, commands.SettingTypeDPI})
+		}
+		return commands.SetWindowClass(ctx, cfg, device, widthClass, heightClass)
+	}
+	action := fmt.Sprintf("Window class %s", strings.Join(fields, " "))
+	if len(devices) > 1 {
+		return m.runOnDevices(action, devices, apply)
+	}
+	device := devices[0]
+	return m.runAction(action, func(ctx context.Context) error {
+		return apply(ctx, device)
+	})
+}
+
 // executeWiFiDisconnect processes WiFi disconnection
 func (m Model) executeWiFiDisconnect() (tea.Model, tea.Cmd) {
 	m.mode = ModeMenu
@@ -947,7 +1622,7 @@ func (m Model) executeWiFiDisconnect() (tea.Model, tea.Cmd) {
 	m.textInputPrompt = ""
 	m.textInputAction = ""
 
-	cmd := m.wifiFeature.StartWiFiDisconnect(input)
+	cmd := m.wifiFeature.StartWiFiDisconnect(m.startOperation(), input)
 	return m, tea.Batch(cmd, m.spinner.Tick)
 }
 
@@ -977,10 +1652,33 @@ func (m Model) executeWiFiPair() (tea.Model, tea.Cmd) {
 	m.textInputAction = ""
 	m.wifiFeature.ClearPairingAddress()
 
-	cmd := m.wifiFeature.StartWiFiPair(pairingAddress, pairingCode)
+	cmd := m.wifiFeature.StartWiFiPair(m.startOperation(), pairingAddress, pairingCode)
 	return m, tea.Batch(cmd, m.spinner.Tick)
 }
 
+// startOperation returns a context for a new cancellable operation, cancelling any previous one
+func (m *Model) startOperation() context.Context {
+	m.finishOperation()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelOperation = cancel
+	return ctx
+}
+
+// finishOperation releases the context of the current operation
+func (m *Model) finishOperation() {
+	m.transferProgress = ""
+	if m.cancelOperation != nil {
+		m.cancelOperation()
+		m.cancelOperation = nil
+	}
+}
+
+// cancelActiveOperation interrupts the in-flight operation; its done message reports the outcome
+func (m *Model) cancelActiveOperation() {
+	m.finishOperation()
+	m.addInfo("Cancelling operation...")
+}
+
 // launchEmulator starts the selected emulator
 func (m Model) launchEmulator() (tea.Model, tea.Cmd) {
 	m.mode = ModeMenu
@@ -1069,6 +1767,8 @@ func (m Model) View() string {
 		s.WriteString(m.renderDeviceSelection())
 	case ModeEmulatorSelect:
 		s.WriteString(m.renderEmulatorSelection())
+	case ModePresetSelect:
+		s.WriteString(m.renderPresetSelection())
 	case ModeTextInput:
 		s.WriteString(m.renderTextInput())
 	}
@@ -1086,7 +1786,10 @@ func (m Model) View() string {
 		helpKeys = m.keys.MenuKeys(m.searchMode)
 	case ModeTextInput:
 		helpKeys = m.keys.TextInputKeys()
-	case ModeDeviceSelect, ModeEmulatorSelect:
+		if m.isSettingInput() {
+			helpKeys = m.keys.SettingInputKeys()
+		}
+	case ModeDeviceSelect, ModeEmulatorSelect, ModePresetSelect:
 		// These modes handle their own help display, skip global footer
 		// But still show persistent log box below everything
 		s.WriteString("\n" + m.renderLogBox())
@@ -1176,7 +1879,9 @@ func (m Model) renderMainMenu() string {
 
 	for _, device := range devices {
 		s.WriteString(fmt.Sprintf("  %s %s", device.GetStatusIndicator(), device.String()))
-		if extendedInfo := device.GetExtendedInfo(); extendedInfo != "" {
+		if hint := device.GetStatusHint(); hint != "" {
+			s.WriteString(fmt.Sprintf("\n    %s", core.WarningStyle.Render(hint)))
+		} else if extendedInfo := device.GetExtendedInfo(); extendedInfo != "" {
 			s.WriteString(fmt.Sprintf("\n    %s", extendedInfo))
 		}
 		s.WriteString("\n")
@@ -1189,24 +1894,51 @@ func (m Model) renderMainMenu() string {
 func (m Model) renderDeviceSelection() string {
 	s := []string{"Select a device:", ""}
 
-	devices := m.devicesFeature.GetDevices()
+	if m.devicesFeature.IsFiltering() {
+		filter := m.devicesFeature.GetDeviceFilter()
+		if filter == "" {
+			s[0] = "Select a device (filter: type a model, AVD name, serial, glob, usb, wifi or emulators):"
+		} else {
+			s[0] = fmt.Sprintf("Select a device (filter: %s):", filter)
+		}
+	}
+
+	devices := m.devicesFeature.GetFilteredDevices()
 	selectedDevice := m.devicesFeature.GetSelectedDevice()
 
+	if len(devices) == 0 {
+		s = append(s, "  No matching devices")
+	}
+
+	// Checkboxes only appear once the user starts marking devices
+	marked := len(m.devicesFeature.GetMarkedDevices())
+	if marked > 0 {
+		s[0] = strings.TrimSuffix(s[0], ":") + fmt.Sprintf(" (%d marked, enter runs on all of them):", marked)
+	}
+
 	for i, device := range devices {
 		cursor := "  "
 		if i == selectedDevice {
 			cursor = "> "
 		}
+		if marked > 0 {
+			if m.devicesFeature.IsMarked(device.Serial) {
+				cursor += "[x] "
+			} else {
+				cursor += "[ ] "
+			}
+		}
 		deviceInfo := fmt.Sprintf("%s %s", device.GetStatusIndicator(), device.String())
-		extendedInfo := device.GetExtendedInfo()
-		if extendedInfo != "" {
+		if hint := device.GetStatusHint(); hint != "" {
+			deviceInfo += fmt.Sprintf("\n    %s", core.WarningStyle.Render(hint))
+		} else if extendedInfo := device.GetExtendedInfo(); extendedInfo != "" {
 			deviceInfo += fmt.Sprintf("\n    %s", extendedInfo)
 		}
 		s = append(s, fmt.Sprintf("%s%s", cursor, deviceInfo))
 	}
 
 	// Add help using bubbles help component
-	s = append(s, "", "", m.renderHelp(m.keys.DeviceSelectKeys()))
+	s = append(s, "", "", m.renderHelp(m.keys.DeviceSelectKeys(m.devicesFeature.IsFiltering())))
 	return strings.Join(s, "\n")
 }
 
@@ -1229,6 +1961,12 @@ func (m Model) renderTextInput() string {
 	s = append(s, "")
 	s = append(s, m.textInput.View())
 
+	if m.isSettingInput() {
+		if history := m.renderSettingHistory(); history != "" {
+			s = append(s, "", history)
+		}
+	}
+
 	// Help is handled by global footer, don't duplicate it here
 	// Log history is now handled globally at the bottom
 
@@ -1266,6 +2004,63 @@ func (m Model) renderEmulatorSelection() string {
 	return strings.Join(s, "\n")
 }
 
+// renderPresetSelection renders the preset picker
+func (m Model) renderPresetSelection() string {
+	target := m.selectedDeviceForAction.Serial
+	if len(m.selectedDevicesForAction) > 1 {
+		target = fmt.Sprintf("%d devices", len(m.selectedDevicesForAction))
+	}
+	s := []string{fmt.Sprintf("Select a preset to apply to %s:", target), ""}
+
+	presets := m.settingsFeature.GetPresets()
+	if len(presets) == 0 {
+		s = append(s, "No presets found. Save one with \"Save preset\" or define them in "+commands.PresetsPath(m.config)+".")
+		s = append(s, "", "", m.renderHelp([]key.Binding{m.keys.EscapeBack}))
+		return strings.Join(s, "\n")
+	}
+
+	detailStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	for i, preset := range presets {
+		cursor := "  "
+		if i == m.settingsFeature.GetSelectedPreset() {
+			cursor = "> "
+		}
+		line := cursor + preset.Name
+		if preset.BuiltIn {
+			line += " (built-in)"
+		}
+		if preset.Description != "" {
+			line += " - " + preset.Description
+		}
+		s = append(s, line, detailStyle.Render("    "+commands.FormatPresetSettings(preset.Preset)))
+	}
+
+	s = append(s, "", detailStyle.Render("Changes such as +20% are from the device default, not the current value"))
+	s = append(s, "", m.renderHelp(m.keys.PresetSelectKeys()))
+	return strings.Join(s, "\n")
+}
+
+// renderSettingHistory renders the latest setting changes of the device being changed
+func (m Model) renderSettingHistory() string {
+	history := m.settingsFeature.GetCurrentHistory()
+	if history == nil || len(history.Changes) == 0 {
+		return ""
+	}
+
+	const shown = 5
+	undoneStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	lines := []string{"History:"}
+	for i := max(0, len(history.Changes)-shown); i < len(history.Changes); i++ {
+		change := history.Changes[i]
+		line := fmt.Sprintf("  %d. %s  %s", i+1, change.ChangedAt.Format(time.TimeOnly), change)
+		if i >= history.Position {
+			line = undoneStyle.Render(line + "  (undone)")
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // renderStatusBar renders the status bar showing filter, device count, and active operations
 func (m Model) renderStatusBar() string {
 	var statusItems []string
@@ -1304,6 +2099,11 @@ func (m Model) renderStatusBar() string {
 		statusItems = append(statusItems, "No devices connected")
 	}
 
+	// Device tracker health
+	if health := m.devicesFeature.GetTrackerHealth(); health != nil && !health.Connected {
+		statusItems = append(statusItems, "⚠️ Tracking offline")
+	}
+
 	// Active filter
 	if m.searchMode {
 		if m.searchFilter == "/" {
@@ -1344,6 +2144,11 @@ func (m Model) renderStatusBar() string {
 		statusItems = append(statusItems, fmt.Sprintf("Active: %s", strings.Join(activeOps, ", ")))
 	}
 
+	// Changes are reverted on exit
+	if m.config.NoTrace {
+		statusItems = append(statusItems, "👣 No trace")
+	}
+
 	if len(statusItems) == 0 {
 		return ""
 	}
@@ -1566,12 +2371,12 @@ func (m Model) renderProgressIndicators() string {
 		Bold(true)
 
 	if m.mediaFeature.IsTakingScreenshot() {
-		progressText := m.getProgressText("Taking screenshot")
+		progressText := m.getProgressText("Taking screenshot • Press Esc to cancel")
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
 	if m.mediaFeature.IsTakingDayNight() {
-		progressText := m.getProgressText("Taking day-night screenshots")
+		progressText := m.getProgressText("Taking day-night screenshots • Press Esc to cancel")
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
@@ -1580,21 +2385,31 @@ func (m Model) renderProgressIndicators() string {
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
+	if m.runningAction != "" {
+		progressText := m.getProgressText(m.runningAction + " • Press Esc to cancel")
+		indicators = append(indicators, loadingStyle.Render(progressText))
+	}
+
 	if m.wifiFeature.IsConnecting() {
-		progressText := m.getProgressText("Connecting to WiFi device")
+		progressText := m.getProgressText("Connecting to WiFi device • Press Esc to cancel")
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
 	if m.wifiFeature.IsDisconnecting() {
-		progressText := m.getProgressText("Disconnecting from WiFi device")
+		progressText := m.getProgressText("Disconnecting from WiFi device • Press Esc to cancel")
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
 	if m.wifiFeature.IsPairing() {
-		progressText := m.getProgressText("Pairing with WiFi device")
+		progressText := m.getProgressText("Pairing with WiFi device • Press Esc to cancel")
 		indicators = append(indicators, loadingStyle.Render(progressText))
 	}
 
+	if m.transferProgress != "" {
+		transferStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+		indicators = append(indicators, transferStyle.Render("  "+m.transferProgress))
+	}
+
 	if len(indicators) == 0 {
 		return ""
 	}
@@ -1603,7 +2418,7 @@ func (m Model) renderProgressIndicators() string {
 }
 
 // PollChannels creates a command that polls output channel for streaming updates
-func PollChannels(outputChan <-chan string, doneChan <-chan bool, cfg *config.Config, device adb.Device, timestamp string) tea.Cmd {
+func PollChannels(outputChan <-chan string, errChan <-chan error, cfg *config.Config, device adb.Device, timestamp string) tea.Cmd {
 	return func() tea.Msg {
 		select {
 		case line, ok := <-outputChan:
@@ -1613,12 +2428,23 @@ func PollChannels(outputChan <-chan string, doneChan <-chan bool, cfg *config.Co
 					LiveOutput: line,
 					ShouldPoll: true,
 					OutputChan: outputChan,
+					ErrChan:    errChan,
 					Config:     cfg,
 					Device:     device,
 					Timestamp:  timestamp,
 				}
 			} else if !ok {
-				// Channel closed - command is done
+				// Channel closed - command is done; the error, if any, was sent before closing
+				select {
+				case err := <-errChan:
+					return dayNightScreenshotDoneMsg{
+						Success:        false,
+						Message:        core.ErrorText(err),
+						CapturedOutput: []string{},
+					}
+				default:
+				}
+
 				filenameDay := fmt.Sprintf("android-img-%s-day.png", timestamp)
 				filenameNight := fmt.Sprintf("android-img-%s-night.png", timestamp)
 				localPathDay := filepath.Join(cfg.MediaPath, filenameDay)
@@ -1636,11 +2462,11 @@ func PollChannels(outputChan <-chan string, doneChan <-chan bool, cfg *config.Co
 		default:
 			// No new data, continue polling with a small delay
 			time.Sleep(50 * time.Millisecond) // Reduced for more responsive logs
-			return PollChannels(outputChan, nil, cfg, device, timestamp)()
+			return PollChannels(outputChan, errChan, cfg, device, timestamp)()
 		}
 
 		// Continue polling if we get here (empty line case)
-		return PollChannels(outputChan, nil, cfg, device, timestamp)()
+		return PollChannels(outputChan, errChan, cfg, device, timestamp)()
 	}
 }
 
@@ -1689,6 +2515,7 @@ type ChannelPollResult struct {
 	LiveOutput string
 	ShouldPoll bool
 	OutputChan <-chan string
+	ErrChan    <-chan error
 	Config     *config.Config
 	Device     adb.Device
 	Timestamp  string
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"gadget/internal/adb"
 	"gadget/internal/cli"
+	"gadget/internal/commands"
 	"gadget/internal/config"
 	"gadget/internal/logger"
 	"gadget/internal/registry"
@@ -21,13 +25,23 @@ func main() {
 	commandHelp := fmt.Sprintf("Command to execute directly (%s)", strings.Join(availableCommands, ", "))
 
 	command := flag.String("command", "", commandHelp)
-	deviceSerial := flag.String("device", "", "Device serial for device-specific commands")
+	deviceSerial := flag.String("device", "", "Device selector for device-specific commands (serial, transport ID, model, AVD name, glob, usb, wifi, emulators)")
 	ip := flag.String("ip", "", "IP address for WiFi commands")
 	code := flag.String("code", "", "Pairing code for WiFi pairing")
-	value := flag.String("value", "", "Value for setting commands (DPI, font size, screen size)")
+	value := flag.String("value", "", "Value for setting commands (DPI, font size, screen size, animation scale, dark mode, locale, accessibility)")
+	serverHost := flag.String("H", cfg.ADBServerHost, "Host of the adb server (default localhost, or ADB_SERVER_SOCKET)")
+	noTrace := flag.Bool("no-trace", cfg.NoTrace, "Revert every setting change when gadget exits (or GADGET_NO_TRACE=1)")
+	serverPort := flag.Int("P", cfg.ADBServerPort, "Port of the adb server (default 5037, or ANDROID_ADB_SERVER_PORT)")
+	force := flag.Bool("force", false, "Apply setting values outside their allowed range")
 	flag.Parse()
 
-	args := flag.Args()
+	cfg.ADBServerHost = *serverHost
+	cfg.ADBServerPort = *serverPort
+	cfg.NoTrace = *noTrace
+	adb.UseServer(cfg.ADBServerHost, cfg.ADBServerPort)
+
+	args, forced := extractForceFlag(flag.Args())
+	cfg.Force = *force || forced
 
 	// Determine command from either flag or first positional argument
 	var cmdToExecute string
@@ -49,7 +63,20 @@ func main() {
 	if _, err := os.Stat(cfg.GetADBPath()); os.IsNotExist(err) {
 		logger.Error("ADB not found at %s", cfg.GetADBPath())
 		logger.Error("Please check your ANDROID_HOME environment variable: %s", cfg.AndroidHome)
-		os.Exit(1)
+		os.Exit(cli.ExitAdbNotFound)
+	}
+
+	// A shared server on another machine must not be restarted by a mismatched local adb binary
+	if adb.IsRemoteServer() {
+		if _, _, err := adb.CheckServerVersion(context.Background(), cfg.GetADBPath()); errors.Is(err, adb.ErrVersionMismatch) {
+			logger.SetRenderer(logger.NewCLIRenderer())
+			exitWithError(err)
+		}
+	}
+
+	// Changes an earlier "leave no trace" session couldn't revert, e.g. because it crashed, are undone first
+	if cmdToExecute != "revert" {
+		revertLeftoverChanges(cfg)
 	}
 
 	// If no command specified, start TUI
@@ -57,9 +84,15 @@ func main() {
 		model := tui.NewModel(cfg)
 		program := tea.NewProgram(model, tea.WithAltScreen())
 
-		if _, err := program.Run(); err != nil {
-			// Set up CLI renderer for TUI error messages
-			logger.SetRenderer(logger.NewCLIRenderer())
+		_, err := program.Run()
+		// Set up CLI renderer for messages after the TUI is gone
+		logger.SetRenderer(logger.NewCLIRenderer())
+		if cfg.NoTrace {
+			if err := cli.RevertSessionChanges(cfg); err != nil {
+				logger.Error("Failed to revert changes: %v", err)
+			}
+		}
+		if err != nil {
 			logger.Error("Error running program: %v", err)
 			os.Exit(1)
 		}
@@ -69,18 +102,56 @@ func main() {
 	// Check if this is a nested command
 	if isNestedCommand(cmdToExecute) {
 		if err := cli.ExecuteNestedCommand(cfg, cmdToExecute, args); err != nil {
-			logger.Error("Error: %v", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	} else {
 		parsedArgs := parsePositionalArgs(cmdToExecute, args, *deviceSerial, *ip, *code, *value)
 		if err := executeDirectCommand(cfg, cmdToExecute, parsedArgs.device, parsedArgs.ip, parsedArgs.code, parsedArgs.value); err != nil {
-			logger.Error("Error: %v", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	}
 }
 
+// extractForceFlag removes --force from the arguments; the flag package stops parsing at the command,
+// so "gadget dpi 100 --force" leaves it among them
+func extractForceFlag(args []string) ([]string, bool) {
+	remaining := make([]string, 0, len(args))
+	forced := false
+	for _, arg := range args {
+		if arg == "--force" || arg == "-force" {
+			forced = true
+			continue
+		}
+		remaining = append(remaining, arg)
+	}
+	return remaining, forced
+}
+
+// exitWithError reports a failed command, with a hint when the failure is a known one,
+// and exits with the code for its kind
+func exitWithError(err error) {
+	logger.Error("Error: %v", err)
+	if hint := adb.Hint(err); hint != "" {
+		logger.Info("Hint: %s", hint)
+	}
+	os.Exit(cli.ExitCode(err))
+}
+
+// revertLeftoverChanges undoes the changes of "leave no trace" sessions that ended without reverting them
+// It reports on the terminal even in TUI mode, before the TUI takes over the screen
+func revertLeftoverChanges(cfg *config.Config) {
+	pending, err := commands.PendingJournals(cfg)
+	if err != nil || len(pending) == 0 {
+		return
+	}
+
+	logger.SetRenderer(logger.NewCLIRenderer())
+	logger.Info("Reverting changes left by an earlier session...")
+	if err := cli.RevertSessionChanges(cfg); err != nil {
+		logger.Error("Warning: failed to revert changes: %v", err)
+	}
+}
+
 // ParsedArgs holds the parsed command arguments
 type ParsedArgs struct {
 	device string
@@ -99,11 +170,24 @@ var argumentParsers = map[string]ArgumentParser{
 	"dpi":                  parseSettingArgs,
 	"font-size":            parseSettingArgs,
 	"screen-size":          parseSettingArgs,
+	"animations":           parseSettingArgs,
+	"dark-mode":            parseSettingArgs,
+	"locale":               parseSettingArgs,
+	"screenshot-locales":   parseSettingArgs,
+	"talkback":             parseSettingArgs,
+	"high-contrast-text":   parseSettingArgs,
+	"color-inversion":      parseSettingArgs,
+	"color-correction":     parseSettingArgs,
+	"remove-animations":    parseSettingArgs,
 	"launch-emulator":      parseValueArgs,
 	"configure-emulator":   parseValueArgs,
 	"screenshot":           parseDeviceArgs,
 	"screenshot-day-night": parseDeviceArgs,
 	"screen-record":        parseDeviceArgs,
+	"revert":               parseDeviceArgs,
+	"reset":                parseResetArgs,
+	"snapshot":             parseDeviceArgs,
+	"restore":              parseDeviceArgs,
 }
 
 // parsePositionalArgs parses positional arguments based on command type
@@ -163,6 +247,19 @@ func parseSettingArgs(args []string, flags ParsedArgs) ParsedArgs {
 	return result
 }
 
+// parseResetArgs takes the setting to reset, then an optional device
+func parseResetArgs(args []string, flags ParsedArgs) ParsedArgs {
+	result := flags
+	if len(args) >= 1 && result.value == "" {
+		result.value = args[0]
+		args = args[1:]
+	}
+	if len(args) >= 1 && result.device == "" {
+		result.device = args[0]
+	}
+	return result
+}
+
 func parseValueArgs(args []string, flags ParsedArgs) ParsedArgs {
 	result := flags
 	if len(args) >= 1 && result.value == "" {
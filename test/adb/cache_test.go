@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/test/adb/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubDeviceInfo stubs the extended-info probes of emulator-5554 on the fake server
+func stubDeviceInfo(server *util.FakeADBServer, battery string) {
+	server.SetDevices(twoDevices)
+	server.StubShell("emulator-5554", "dumpsys battery", util.ShellResponse{Stdout: "  level: " + battery + "\n"})
+	server.StubShell("emulator-5554", "getprop ro.build.version.release", util.ShellResponse{Stdout: "14\n"})
+	server.StubShell("emulator-5554", "getprop ro.build.version.sdk", util.ShellResponse{Stdout: "34\n"})
+	server.StubShell("emulator-5554", "getprop ro.boot.qemu.avd_name", util.ShellResponse{Stdout: "Pixel_8_API_34\n"})
+}
+
+func TestDeviceInfoCache(t *testing.T) {
+	tests := []struct {
+		name           string
+		ttl            time.Duration
+		between        func(cache *adb.DeviceInfoCache, device *adb.Device)
+		expectedShells int
+		expectStatic   bool // Whether the second load probes static properties again
+		expectBattery  int
+	}{
+		{
+			name:           "fresh entry is reused",
+			ttl:            time.Minute,
+			between:        func(*adb.DeviceInfoCache, *adb.Device) {},
+			expectedShells: 1,
+			expectBattery:  80,
+		},
+		{
+			name: "expired volatile info is re-probed alone",
+			ttl:  20 * time.Millisecond,
+			between: func(*adb.DeviceInfoCache, *adb.Device) {
+				time.Sleep(40 * time.Millisecond)
+			},
+			expectedShells: 2,
+			expectStatic:   false,
+			expectBattery:  55,
+		},
+		{
+			name: "disconnect drops static info",
+			ttl:  time.Minute,
+			between: func(cache *adb.DeviceInfoCache, _ *adb.Device) {
+				cache.HandleEvent(adb.DeviceChangeEvent{Type: adb.DeviceRemoved, Serial: "emulator-5554", Status: adb.StatusDisconnected})
+			},
+			expectedShells: 2,
+			expectStatic:   true,
+			expectBattery:  55,
+		},
+		{
+			name: "reconnect with new transport ID probes again",
+			ttl:  time.Minute,
+			between: func(_ *adb.DeviceInfoCache, device *adb.Device) {
+				device.TransportID = "7"
+			},
+			expectedShells: 2,
+			expectStatic:   true,
+			expectBattery:  55,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeServer(t, func(server *util.FakeADBServer) {
+				stubDeviceInfo(server, "80")
+				cache := adb.NewDeviceInfoCache(tt.ttl)
+
+				device := adb.Device{Serial: "emulator-5554", Status: "device", TransportID: "1"}
+				cache.Load(context.Background(), "/nonexistent/adb", &device)
+
+				stubDeviceInfo(server, "55")
+				tt.between(cache, &device)
+
+				reloaded := adb.Device{Serial: device.Serial, Status: device.Status, TransportID: device.TransportID}
+				cache.Load(context.Background(), "/nonexistent/adb", &reloaded)
+
+				requests := server.GetShellCommands()
+				assert.Len(t, requests, tt.expectedShells)
+				if len(requests) == 2 {
+					assert.Contains(t, requests[1], "dumpsys battery")
+					assert.Equal(t, tt.expectStatic, strings.Contains(requests[1], "ro.build.version.sdk"))
+				}
+
+				assert.Equal(t, tt.expectBattery, reloaded.BatteryLevel)
+				assert.Equal(t, "14", reloaded.AndroidVersion)
+				assert.Equal(t, 34, reloaded.APILevel)
+				assert.Equal(t, "Pixel_8_API_34", reloaded.AVDName)
+			})
+		})
+	}
+}
+
+func TestDeviceInfoCacheSkipsFailedProbes(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		stubDeviceInfo(server, "80")
+		cache := adb.NewDeviceInfoCache(time.Minute)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		device := adb.Device{Serial: "emulator-5554", Status: "device", TransportID: "1"}
+		cache.Load(ctx, "/nonexistent/adb", &device)
+		assert.Equal(t, -1, device.APILevel)
+
+		cache.Load(context.Background(), "/nonexistent/adb", &device)
+		assert.Equal(t, 34, device.APILevel, "a timed-out probe must not be cached")
+	})
+}
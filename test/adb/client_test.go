@@ -0,0 +1,229 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/test/adb/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const twoDevices = "emulator-5554\tdevice product:sdk_gphone64_x86_64 model:sdk_gphone64_x86_64 device:generic_x86_64 transport_id:1\n" +
+	"R58M123ABC\tdevice usb:1-1 product:a52qnsxx model:SM_A525F device:a52q transport_id:2\n"
+
+// withFakeServer points the adb package at a fake server for the duration of fn
+func withFakeServer(t *testing.T, fn func(server *util.FakeADBServer)) {
+	server, err := util.NewFakeADBServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	adb.SetCommandExecutor(adb.NewServerCommandExecutor(server.Address()))
+	defer adb.ResetCommandExecutor()
+
+	fn(server)
+}
+
+func TestServerClientDevices(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+
+		devices, err := adb.GetConnectedDevices("/nonexistent/adb")
+		require.NoError(t, err)
+		require.Len(t, devices, 2)
+
+		assert.Equal(t, "emulator-5554", devices[0].Serial)
+		assert.Equal(t, "device", devices[0].Status)
+		assert.Equal(t, "1", devices[0].TransportID)
+		assert.Equal(t, "R58M123ABC", devices[1].Serial)
+		assert.Equal(t, "SM_A525F", devices[1].Model)
+		assert.Equal(t, []string{"host:devices-l"}, server.GetRequests())
+	})
+}
+
+func TestServerClientShell(t *testing.T) {
+	tests := []struct {
+		name          string
+		legacyOnly    bool
+		response      util.ShellResponse
+		expectedOut   string
+		expectedError string
+	}{
+		{
+			name:        "shell v2 success",
+			response:    util.ShellResponse{Stdout: "Physical density: 420\n"},
+			expectedOut: "Physical density: 420\n",
+		},
+		{
+			name:          "shell v2 non-zero exit",
+			response:      util.ShellResponse{Stderr: "wm: not found", ExitCode: 127},
+			expectedError: "exit status 127",
+		},
+		{
+			name:        "legacy shell fallback",
+			legacyOnly:  true,
+			response:    util.ShellResponse{Stdout: "Physical density: 420\n"},
+			expectedOut: "Physical density: 420\n",
+		},
+		{
+			name:          "legacy shell non-zero exit",
+			legacyOnly:    true,
+			response:      util.ShellResponse{ExitCode: 1},
+			expectedError: "exit status 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeServer(t, func(server *util.FakeADBServer) {
+				server.SetDevices(twoDevices)
+				server.SetLegacyShellOnly(tt.legacyOnly)
+				server.StubShell("emulator-5554", "wm density", tt.response)
+
+				output, err := adb.ExecuteCommandWithOutput("/nonexistent/adb", "emulator-5554", "shell", "wm", "density")
+				if tt.expectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.expectedError)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedOut, output)
+				assert.Contains(t, server.GetRequests(), "host:transport:emulator-5554")
+			})
+		})
+	}
+}
+
+func TestServerClientUnknownDevice(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+
+		err := adb.ExecuteCommand("/nonexistent/adb", "missing-serial", "shell", "wm", "density")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "device 'missing-serial' not found")
+		assert.ErrorIs(t, err, adb.ErrDeviceNotFound)
+	})
+}
+
+func TestServerClientVersion(t *testing.T) {
+	server, err := util.NewFakeADBServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	version, err := adb.NewClient(server.Address()).Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 41, version)
+}
+
+func TestServerShellCancellation(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancel      func(context.Context) (context.Context, context.CancelFunc)
+		expectedErr error
+	}{
+		{
+			name: "timeout",
+			cancel: func(ctx context.Context) (context.Context, context.CancelFunc) {
+				return context.WithTimeout(ctx, 100*time.Millisecond)
+			},
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancel",
+			cancel: func(ctx context.Context) (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(ctx)
+				time.AfterFunc(100*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			expectedErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeServer(t, func(server *util.FakeADBServer) {
+				server.SetDevices(twoDevices)
+				server.StubShell("emulator-5554", "dumpsys battery", util.ShellResponse{Stdout: "level: 80\n", Delay: 5 * time.Second})
+
+				ctx, cancel := tt.cancel(context.Background())
+				defer cancel()
+
+				start := time.Now()
+				_, err := adb.ExecuteCommandWithOutputContext(ctx, "/nonexistent/adb", "emulator-5554", "shell", "dumpsys", "battery")
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Less(t, time.Since(start), 2*time.Second, "cancellation should interrupt the pending shell command")
+			})
+		})
+	}
+}
+
+func TestLoadExtendedInfoSkipsCancelledProbes(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.StubShell("emulator-5554", "getprop ro.build.version.release", util.ShellResponse{Stdout: "14\n"})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		device := adb.Device{Serial: "emulator-5554", Status: "device"}
+		device.LoadExtendedInfo(ctx, "/nonexistent/adb")
+
+		assert.Empty(t, device.AndroidVersion)
+		assert.Equal(t, -1, device.BatteryLevel)
+		assert.Equal(t, -1, device.APILevel)
+	})
+}
+
+func TestLoadExtendedInfoBatchesProbes(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.StubShell("emulator-5554", "dumpsys battery", util.ShellResponse{Stdout: "Current Battery Service state:\n  AC powered: true\n  level: 85\n"})
+		server.StubShell("emulator-5554", "getprop ro.build.version.release", util.ShellResponse{Stdout: "14\n"})
+		server.StubShell("emulator-5554", "wm size", util.ShellResponse{Stdout: "Physical size: 1080x2400\n"})
+		server.StubShell("emulator-5554", "getprop ro.product.cpu.abi", util.ShellResponse{Stdout: "x86_64\n"})
+		server.StubShell("emulator-5554", "getprop ro.build.version.sdk", util.ShellResponse{Stdout: "34\n"})
+		server.StubShell("emulator-5554", "ip addr show wlan0", util.ShellResponse{Stdout: "    inet 10.0.2.16/24 brd 10.0.2.255 scope global wlan0\n"})
+		server.StubShell("emulator-5554", "ifconfig wlan0", util.ShellResponse{ExitCode: 127})
+		server.StubShell("emulator-5554", "getprop ro.boot.qemu.avd_name", util.ShellResponse{Stdout: "Pixel_8_API_34\n"})
+
+		device := adb.Device{Serial: "emulator-5554", Status: "device"}
+		device.LoadExtendedInfo(context.Background(), "/nonexistent/adb")
+
+		assert.Equal(t, 85, device.BatteryLevel)
+		assert.Equal(t, "14", device.AndroidVersion)
+		assert.Equal(t, "1080x2400", device.ScreenRes)
+		assert.Equal(t, "x86_64", device.CPUArchitecture)
+		assert.Equal(t, 34, device.APILevel)
+		assert.Equal(t, "10.0.2.16", device.IPAddress)
+		assert.Equal(t, "Pixel_8_API_34", device.AVDName)
+
+		assert.Len(t, server.GetShellCommands(), 1, "all probes should share one shell command")
+	})
+}
+
+func TestLoadExtendedInfoForDevicesRunsConcurrently(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		for _, serial := range []string{"emulator-5554", "R58M123ABC"} {
+			server.StubShell(serial, "getprop ro.build.version.sdk", util.ShellResponse{Stdout: "34\n", Delay: 300 * time.Millisecond})
+		}
+
+		devices := []adb.Device{
+			{Serial: "emulator-5554", Status: "device"},
+			{Serial: "R58M123ABC", Status: "device"},
+			{Serial: "offline-device", Status: "offline"},
+		}
+
+		start := time.Now()
+		adb.LoadExtendedInfoForDevices(context.Background(), "/nonexistent/adb", devices, adb.DefaultLoadWorkers)
+
+		assert.Less(t, time.Since(start), 550*time.Millisecond, "devices should load in parallel")
+		assert.Equal(t, 34, devices[0].APILevel)
+		assert.Equal(t, 34, devices[1].APILevel)
+		assert.Equal(t, 0, devices[2].APILevel, "offline devices are skipped")
+	})
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"gadget/internal/adb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var selectorDevices = []adb.Device{
+	{Serial: "emulator-5554", Status: "device", Model: "sdk_gphone64_arm64", TransportID: "1", AVDName: "Pixel_6_API_34"},
+	{Serial: "emulator-5556", Status: "device", Model: "sdk_gphone64_arm64", TransportID: "2", AVDName: "Pixel_Tablet_API_35"},
+	{Serial: "1A2B3C4D5E6F", Status: "device", Model: "Pixel_7", TransportID: "3"},
+	{Serial: "192.168.1.100:5555", Status: "device", Model: "SM_S911B", TransportID: "4"},
+}
+
+// serials returns the serials of devices, for compact assertions
+func serials(devices []adb.Device) []string {
+	var result []string
+	for _, device := range devices {
+		result = append(result, device.Serial)
+	}
+	return result
+}
+
+func TestMatchDevices(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		expected []string
+	}{
+		{"empty selector matches all", "", []string{"emulator-5554", "emulator-5556", "1A2B3C4D5E6F", "192.168.1.100:5555"}},
+		{"all keyword", "all", []string{"emulator-5554", "emulator-5556", "1A2B3C4D5E6F", "192.168.1.100:5555"}},
+		{"usb keyword", "usb", []string{"1A2B3C4D5E6F"}},
+		{"wifi keyword", "WiFi", []string{"192.168.1.100:5555"}},
+		{"emulators keyword", "emulators", []string{"emulator-5554", "emulator-5556"}},
+		{"exact serial", "emulator-5556", []string{"emulator-5556"}},
+		{"transport ID", "3", []string{"1A2B3C4D5E6F"}},
+		{"exact AVD name", "Pixel_6_API_34", []string{"emulator-5554"}},
+		{"AVD name with spaces", "pixel 6 api 34", []string{"emulator-5554"}},
+		{"model substring", "pixel", []string{"emulator-5554", "emulator-5556", "1A2B3C4D5E6F"}},
+		{"model substring narrowed", "pixel 7", []string{"1A2B3C4D5E6F"}},
+		{"serial glob", "emulator-*", []string{"emulator-5554", "emulator-5556"}},
+		{"AVD glob", "*tablet*", []string{"emulator-5556"}},
+		{"no match", "nexus", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, serials(adb.MatchDevices(selectorDevices, tt.selector)))
+		})
+	}
+}
+
+func TestSelectDevice(t *testing.T) {
+	device, err := adb.SelectDevice(selectorDevices, "tablet")
+	require.NoError(t, err)
+	assert.Equal(t, "emulator-5556", device.Serial)
+
+	_, err = adb.SelectDevice(selectorDevices, "pixel")
+	var ambiguous *adb.AmbiguousSelectorError
+	require.ErrorAs(t, err, &ambiguous)
+	assert.Len(t, ambiguous.Candidates, 3)
+	assert.Contains(t, err.Error(), "\"pixel\" matches 3 devices")
+	assert.Contains(t, err.Error(), "1A2B3C4D5E6F")
+
+	_, err = adb.SelectDevice(selectorDevices, "nexus")
+	var noMatch *adb.NoMatchingDeviceError
+	assert.ErrorAs(t, err, &noMatch)
+
+	_, err = adb.SelectDevice(nil, "")
+	assert.EqualError(t, err, "no devices connected")
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/test/adb/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShellSession(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.StubShell("emulator-5554", "wm density", util.ShellResponse{Stdout: "Physical density: 420\n"})
+		server.StubShell("emulator-5554", "printf 420", util.ShellResponse{Stdout: "420"})
+		server.StubShell("emulator-5554", "wm size 1x1", util.ShellResponse{Stderr: "Error: bad size\n", ExitCode: 255})
+
+		session := adb.NewShellSession(adb.NewClient(server.Address()), "emulator-5554")
+		defer session.Close()
+
+		result, err := session.Run(context.Background(), "wm density")
+		require.NoError(t, err)
+		assert.Equal(t, "Physical density: 420\n", result.Stdout)
+
+		result, err = session.Run(context.Background(), "printf 420")
+		require.NoError(t, err)
+		assert.Equal(t, "420", result.Stdout, "output without a trailing newline is kept intact")
+
+		result, err = session.Run(context.Background(), "wm size 1x1")
+		var exitErr *adb.ShellExitError
+		require.ErrorAs(t, err, &exitErr)
+		assert.Equal(t, 255, exitErr.Code)
+		assert.Equal(t, "Error: bad size\n", result.Stderr)
+
+		assert.Equal(t, 1, server.GetSessionCount(), "commands should share one shell")
+		assert.Equal(t, []string{"wm density", "printf 420", "wm size 1x1"}, server.GetShellCommands())
+	})
+}
+
+func TestShellSessionIsolatesCommands(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.SetRealShell(true)
+
+		session := adb.NewShellSession(adb.NewClient(server.Address()), "emulator-5554")
+		defer session.Close()
+
+		start, err := session.Run(context.Background(), "pwd")
+		require.NoError(t, err)
+
+		tests := []struct {
+			name         string
+			command      string
+			expectedCode int
+		}{
+			{name: "change directory", command: "cd /"},
+			{name: "export a variable", command: "export GADGET_LEAK=1"},
+			{name: "exit the shell", command: "exit 3", expectedCode: 3},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := session.Run(context.Background(), tt.command)
+				if tt.expectedCode != 0 {
+					var exitErr *adb.ShellExitError
+					require.ErrorAs(t, err, &exitErr)
+					assert.Equal(t, tt.expectedCode, exitErr.Code)
+				} else {
+					require.NoError(t, err)
+				}
+
+				result, err := session.Run(context.Background(), "pwd; echo \"${GADGET_LEAK:-unset}\"")
+				require.NoError(t, err)
+				assert.Equal(t, start.Stdout+"unset\n", result.Stdout, "later commands should see the session's original state")
+			})
+		}
+
+		assert.Equal(t, 1, server.GetSessionCount(), "no command should end the shell")
+	})
+}
+
+func TestShellSessionReconnects(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.StubShell("emulator-5554", "wm density", util.ShellResponse{Stdout: "Physical density: 420\n"})
+
+		session := adb.NewShellSession(adb.NewClient(server.Address()), "emulator-5554")
+		defer session.Close()
+
+		_, err := session.Run(context.Background(), "wm density")
+		require.NoError(t, err)
+
+		server.DropSessions()
+		time.Sleep(20 * time.Millisecond) // Let the session notice the shell is gone
+
+		result, err := session.Run(context.Background(), "wm density")
+		require.NoError(t, err)
+		assert.Equal(t, "Physical density: 420\n", result.Stdout)
+		assert.Equal(t, 2, server.GetSessionCount())
+	})
+}
+
+func TestShellSessionCancellation(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.StubShell("emulator-5554", "dumpsys battery", util.ShellResponse{Stdout: "level: 80\n", Delay: 5 * time.Second})
+		server.StubShell("emulator-5554", "wm density", util.ShellResponse{Stdout: "Physical density: 420\n"})
+
+		session := adb.NewShellSession(adb.NewClient(server.Address()), "emulator-5554")
+		defer session.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := session.Run(ctx, "dumpsys battery")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 2*time.Second)
+
+		result, err := session.Run(context.Background(), "wm density")
+		require.NoError(t, err, "a cancelled command should leave a usable session behind")
+		assert.Equal(t, "Physical density: 420\n", result.Stdout)
+	})
+}
+
+func TestExecuteShellUsesSession(t *testing.T) {
+	tests := []struct {
+		name             string
+		legacyOnly       bool
+		expectedSessions int
+	}{
+		{name: "shell v2 device keeps one session", expectedSessions: 1},
+		{name: "legacy device falls back to one-shot commands", legacyOnly: true, expectedSessions: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeServer(t, func(server *util.FakeADBServer) {
+				server.SetDevices(twoDevices)
+				server.SetLegacyShellOnly(tt.legacyOnly)
+				server.StubShell("emulator-5554", "wm density", util.ShellResponse{Stdout: "Physical density: 420\n"})
+
+				for range 3 {
+					output, err := adb.ExecuteShell("/nonexistent/adb", "emulator-5554", "wm", "density")
+					require.NoError(t, err)
+					assert.Equal(t, "Physical density: 420\n", output)
+				}
+
+				assert.Equal(t, tt.expectedSessions, server.GetSessionCount())
+				assert.Len(t, server.GetShellCommands(), 3)
+			})
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"gadget/internal/adb"
+	"gadget/test/adb/util"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPullFile(t *testing.T) {
+	// Larger than one sync chunk so the transfer spans several DATA packets
+	screenshot := bytes.Repeat([]byte("png"), 50000)
+
+	tests := []struct {
+		name       string
+		file       *util.FakeFile
+		wantErr    bool
+		wantErrAs  interface{}
+		wantResult []byte
+	}{
+		{
+			name:       "pulls whole file",
+			file:       &util.FakeFile{Data: screenshot},
+			wantResult: screenshot,
+		},
+		{
+			name:      "missing remote file",
+			wantErr:   true,
+			wantErrAs: new(*adb.RemoteFileNotFoundError),
+		},
+		{
+			name:      "truncated transfer",
+			file:      &util.FakeFile{Data: screenshot[:1000], ReportedSize: int64(len(screenshot))},
+			wantErr:   true,
+			wantErrAs: new(*adb.SizeMismatchError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakeServer(t, func(server *util.FakeADBServer) {
+				server.SetDevices(twoDevices)
+				if tt.file != nil {
+					server.SetFile("emulator-5554", "/sdcard/screenshot.png", *tt.file)
+				}
+
+				localPath := filepath.Join(t.TempDir(), "screenshot.png")
+				var lastTransferred, lastTotal int64
+				err := adb.PullFile(context.Background(), "/nonexistent/adb", "emulator-5554", "/sdcard/screenshot.png", localPath,
+					func(transferred, total int64) {
+						lastTransferred, lastTotal = transferred, total
+					})
+
+				if tt.wantErr {
+					require.Error(t, err)
+					assert.ErrorAs(t, err, tt.wantErrAs)
+					assert.NoFileExists(t, localPath)
+					assert.NoFileExists(t, localPath+".part", "partial downloads should be removed")
+					return
+				}
+
+				require.NoError(t, err)
+				data, err := os.ReadFile(localPath)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantResult, data)
+				assert.Equal(t, int64(len(tt.wantResult)), lastTransferred)
+				assert.Equal(t, int64(len(tt.wantResult)), lastTotal)
+			})
+		})
+	}
+}
+
+func TestPushFile(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+
+		content := bytes.Repeat([]byte("config"), 20000)
+		localPath := filepath.Join(t.TempDir(), "config.json")
+		require.NoError(t, os.WriteFile(localPath, content, 0644))
+
+		err := adb.PushFile(context.Background(), "/nonexistent/adb", "emulator-5554", localPath, "/sdcard/config.json", nil)
+		require.NoError(t, err)
+
+		file, ok := server.GetFile("emulator-5554", "/sdcard/config.json")
+		require.True(t, ok)
+		assert.Equal(t, content, file.Data)
+	})
+}
+
+func TestSyncList(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices(twoDevices)
+		server.SetFile("emulator-5554", "/sdcard/a.png", util.FakeFile{Data: []byte("aaa")})
+		server.SetFile("emulator-5554", "/sdcard/Movies", util.FakeFile{Mode: 040755})
+
+		sync, err := adb.NewClient(server.Address()).OpenSync(context.Background(), "emulator-5554")
+		require.NoError(t, err)
+		defer sync.Close()
+
+		entries, err := sync.List("/sdcard")
+		require.NoError(t, err)
+
+		byName := make(map[string]adb.FileInfo)
+		for _, entry := range entries {
+			byName[entry.Name] = entry
+		}
+		require.Len(t, byName, 2)
+		assert.Equal(t, int64(3), byName["a.png"].Size)
+		assert.False(t, byName["a.png"].IsDir())
+		assert.True(t, byName["Movies"].IsDir())
+
+		_, err = sync.Stat("/sdcard/missing.png")
+		assert.True(t, adb.IsRemoteFileNotFound(err))
+	})
+}
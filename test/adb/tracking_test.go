@@ -0,0 +1,111 @@
+package test
+
+import (
+	"gadget/internal/adb"
+	"gadget/test/adb/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, tracker *adb.DeviceTracker) adb.DeviceChangeEvent {
+	t.Helper()
+	select {
+	case event := <-tracker.Events():
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for device event")
+		return adb.DeviceChangeEvent{}
+	}
+}
+
+func nextHealth(t *testing.T, tracker *adb.DeviceTracker) adb.TrackerHealth {
+	t.Helper()
+	select {
+	case health := <-tracker.Health():
+		return health
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for tracker health")
+		return adb.TrackerHealth{}
+	}
+}
+
+func TestDeviceTrackerEvents(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices("emulator-5554\tdevice\n")
+
+		tracker := adb.NewDeviceTracker("/nonexistent/adb")
+		tracker.Start()
+		defer tracker.Stop()
+
+		assert.True(t, nextHealth(t, tracker).Connected)
+		assert.Equal(t, adb.DeviceChangeEvent{Type: adb.DeviceAdded, Serial: "emulator-5554", Status: adb.StatusDevice}, nextEvent(t, tracker))
+
+		server.PushDevices("emulator-5554\tdevice\nR58M123ABC\tunauthorized\n")
+		assert.Equal(t, adb.DeviceChangeEvent{Type: adb.DeviceAdded, Serial: "R58M123ABC", Status: adb.StatusUnauthorized}, nextEvent(t, tracker))
+
+		server.PushDevices("emulator-5554\tdevice\nR58M123ABC\tdevice\n")
+		assert.Equal(t, adb.DeviceChangeEvent{
+			Type:           adb.DeviceStatusChanged,
+			Serial:         "R58M123ABC",
+			Status:         adb.StatusDevice,
+			PreviousStatus: adb.StatusUnauthorized,
+		}, nextEvent(t, tracker))
+
+		server.PushDevices("R58M123ABC\tdevice\n")
+		assert.Equal(t, adb.DeviceChangeEvent{
+			Type:           adb.DeviceRemoved,
+			Serial:         "emulator-5554",
+			Status:         adb.StatusDisconnected,
+			PreviousStatus: adb.StatusDevice,
+		}, nextEvent(t, tracker))
+	})
+}
+
+func TestDeviceTrackerReconnects(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices("emulator-5554\tdevice\n")
+
+		tracker := adb.NewDeviceTracker("/nonexistent/adb")
+		tracker.InitialBackoff = 10 * time.Millisecond
+		tracker.Start()
+		defer tracker.Stop()
+
+		require.True(t, nextHealth(t, tracker).Connected)
+		nextEvent(t, tracker)
+
+		// Simulate an adb server restart during which the emulator went away
+		server.SetDevices("")
+		server.DropTrackers()
+
+		health := nextHealth(t, tracker)
+		assert.False(t, health.Connected)
+		assert.Equal(t, 10*time.Millisecond, health.RetryIn)
+
+		health = nextHealth(t, tracker)
+		assert.True(t, health.Connected)
+		assert.Equal(t, 1, health.Reconnects)
+
+		// The first list after reconnecting is diffed against the state before the drop
+		assert.Equal(t, adb.DeviceRemoved, nextEvent(t, tracker).Type)
+	})
+}
+
+func TestDeviceTrackerStatuses(t *testing.T) {
+	withFakeServer(t, func(server *util.FakeADBServer) {
+		server.SetDevices("")
+
+		tracker := adb.NewDeviceTracker("/nonexistent/adb")
+		tracker.Start()
+		defer tracker.Stop()
+		nextHealth(t, tracker)
+
+		for _, status := range []string{adb.StatusRecovery, adb.StatusSideload, adb.StatusAuthorizing, adb.StatusOffline} {
+			server.PushDevices("0123456789ABCDEF\t" + status + "\n")
+			event := nextEvent(t, tracker)
+			assert.Equal(t, status, event.Status)
+		}
+	})
+}
@@ -0,0 +1,568 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShellResponse is the canned result of a shell command on the fake server
+type ShellResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Delay    time.Duration // How long the command "runs" before answering
+}
+
+// FakeADBServer speaks the adb host protocol on a loopback port
+type FakeADBServer struct {
+	listener net.Listener
+
+	mu             sync.Mutex
+	devices        string
+	shellResponses map[string]ShellResponse // keyed by "serial command"
+	legacyOnly     bool
+	realShell      bool
+	requests       []string
+	shellCommands  []string
+	trackers       []net.Conn
+	sessions       []net.Conn
+	sessionCount   int
+	files          map[string]FakeFile // keyed by "serial path"
+}
+
+// FakeFile is a file on a fake device, served over the sync protocol
+type FakeFile struct {
+	Data         []byte
+	Mode         uint32 // Unix mode bits; a regular 0644 file when zero
+	ReportedSize int64  // Size STAT reports instead of len(Data) when non-zero, to fake a truncated transfer
+}
+
+// NewFakeADBServer starts a fake adb server on a random loopback port
+func NewFakeADBServer() (*FakeADBServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	server := &FakeADBServer{
+		listener:       listener,
+		shellResponses: make(map[string]ShellResponse),
+		files:          make(map[string]FakeFile),
+	}
+	go server.serve()
+	return server, nil
+}
+
+// Address returns the host:port the server listens on
+func (s *FakeADBServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server
+func (s *FakeADBServer) Close() {
+	s.listener.Close()
+}
+
+// SetDevices sets the host:devices-l payload
+func (s *FakeADBServer) SetDevices(devices string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices = devices
+}
+
+// StubShell sets the response for a shell command on a device
+func (s *FakeADBServer) StubShell(serial, command string, response ShellResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shellResponses[serial+" "+command] = response
+}
+
+// SetFile puts a file on a device for sync STAT, LIST and RECV
+func (s *FakeADBServer) SetFile(serial, path string, file FakeFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[serial+" "+path] = file
+}
+
+// GetFile returns a file on a device, including files received over sync SEND
+func (s *FakeADBServer) GetFile(serial, path string) (FakeFile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[serial+" "+path]
+	return file, ok
+}
+
+// SetLegacyShellOnly makes the server reject shell,v2 like pre-Nougat devices do
+func (s *FakeADBServer) SetLegacyShellOnly(legacyOnly bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.legacyOnly = legacyOnly
+}
+
+// SetRealShell makes interactive sessions run their input in a local sh instead of answering from stubs,
+// for checking what the session script does to shell state
+func (s *FakeADBServer) SetRealShell(realShell bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.realShell = realShell
+}
+
+// GetRequests returns every request the server received, in order
+func (s *FakeADBServer) GetRequests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// GetShellCommands returns every shell command run, one-shot or in a session, in order
+func (s *FakeADBServer) GetShellCommands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.shellCommands...)
+}
+
+// GetSessionCount returns how many interactive shell sessions were opened
+func (s *FakeADBServer) GetSessionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessionCount
+}
+
+// DropSessions closes every interactive shell, as a device reboot would
+func (s *FakeADBServer) DropSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.sessions {
+		conn.Close()
+	}
+	s.sessions = nil
+}
+
+// PushDevices replaces the device list and sends it to every track-devices client
+func (s *FakeADBServer) PushDevices(devices string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices = devices
+	for _, conn := range s.trackers {
+		writeFrame(conn, trackingPayload(devices))
+	}
+}
+
+// DropTrackers closes every track-devices connection, as an adb server restart would
+func (s *FakeADBServer) DropTrackers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.trackers {
+		conn.Close()
+	}
+	s.trackers = nil
+}
+
+func (s *FakeADBServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *FakeADBServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var serial string
+	for {
+		request, err := s.readRequest(conn)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case request == "host:version":
+			writeOkayWithPayload(conn, fmt.Sprintf("%04x", 41))
+			return
+		case request == "host:devices-l":
+			s.mu.Lock()
+			devices := s.devices
+			s.mu.Unlock()
+			writeOkayWithPayload(conn, devices)
+			return
+		case request == "host:track-devices":
+			s.mu.Lock()
+			s.trackers = append(s.trackers, conn)
+			conn.Write([]byte("OKAY"))
+			writeFrame(conn, trackingPayload(s.devices))
+			s.mu.Unlock()
+			// Hold the connection open until the client or DropTrackers closes it
+			io.Copy(io.Discard, conn)
+			return
+		case strings.HasPrefix(request, "host:transport:"):
+			serial = strings.TrimPrefix(request, "host:transport:")
+			if !s.hasDevice(serial) {
+				writeFail(conn, fmt.Sprintf("device '%s' not found", serial))
+				return
+			}
+			conn.Write([]byte("OKAY"))
+		case request == "sync:":
+			conn.Write([]byte("OKAY"))
+			s.serveSync(conn, serial)
+			return
+		case strings.HasPrefix(request, "shell,v2,raw:"):
+			s.mu.Lock()
+			legacyOnly := s.legacyOnly
+			s.mu.Unlock()
+			if legacyOnly {
+				writeFail(conn, "closed")
+				return
+			}
+			command := strings.TrimPrefix(request, "shell,v2,raw:")
+			if command == "" {
+				s.serveSession(conn, serial)
+				return
+			}
+			response := s.shellResponse(serial, command)
+			conn.Write([]byte("OKAY"))
+			time.Sleep(response.Delay)
+			writeShellPacket(conn, 1, []byte(response.Stdout))
+			writeShellPacket(conn, 2, []byte(response.Stderr))
+			writeShellPacket(conn, 3, []byte{byte(response.ExitCode)})
+			return
+		case strings.HasPrefix(request, "shell:"):
+			command := strings.TrimPrefix(request, "shell:")
+			exitSuffix := ""
+			if i := strings.Index(command, "; echo \""); i != -1 {
+				marker := strings.TrimSuffix(command[i+len("; echo \""):], "$?\"")
+				command = command[:i]
+				exitSuffix = marker
+			}
+			response := s.shellResponse(serial, command)
+			conn.Write([]byte("OKAY"))
+			io.WriteString(conn, response.Stdout)
+			if exitSuffix != "" {
+				io.WriteString(conn, exitSuffix+strconv.Itoa(response.ExitCode)+"\n")
+			}
+			return
+		default:
+			writeFail(conn, "unknown host service")
+			return
+		}
+	}
+}
+
+// serveSession emulates an interactive shell fed over stdin packets
+// It understands the script ShellSession sends for every command:
+// "( <command>\n) </dev/null; echo \"<marker>$?\"; echo \"<marker>\" >&2\n"
+func (s *FakeADBServer) serveSession(conn net.Conn, serial string) {
+	s.mu.Lock()
+	s.sessions = append(s.sessions, conn)
+	s.sessionCount++
+	realShell := s.realShell
+	s.mu.Unlock()
+	conn.Write([]byte("OKAY"))
+
+	if realShell {
+		s.serveRealShell(conn)
+		return
+	}
+
+	var pending string
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		if header[0] != 0 {
+			continue
+		}
+		pending += string(payload)
+
+		for {
+			end := strings.Index(pending, "\n) </dev/null; echo \"")
+			if !strings.HasPrefix(pending, "( ") || end == -1 {
+				break
+			}
+			lineEnd := strings.Index(pending[end+1:], "\n")
+			if lineEnd == -1 {
+				break
+			}
+			command := pending[len("( "):end]
+			trailer := pending[end+len("\n) </dev/null; echo \""):]
+			marker := trailer[:strings.Index(trailer, "$?")]
+			pending = pending[end+1+lineEnd+1:]
+
+			response := s.shellResponse(serial, command)
+			time.Sleep(response.Delay)
+			writeShellPacket(conn, 1, []byte(response.Stdout+marker+strconv.Itoa(response.ExitCode)+"\n"))
+			writeShellPacket(conn, 2, []byte(response.Stderr+marker+"\n"))
+		}
+	}
+}
+
+// serveRealShell feeds stdin packets to a local sh and sends back its output as packets,
+// ending with an exit packet when the shell exits
+func (s *FakeADBServer) serveRealShell(conn net.Conn) {
+	shell := exec.Command("sh")
+	stdin, _ := shell.StdinPipe()
+	stdout, _ := shell.StdoutPipe()
+	stderr, _ := shell.StderrPipe()
+	if err := shell.Start(); err != nil {
+		conn.Close()
+		return
+	}
+	defer shell.Process.Kill()
+
+	var writeMu sync.Mutex
+	var copied sync.WaitGroup
+	copyOutput := func(id byte, r io.Reader) {
+		defer copied.Done()
+		buf := make([]byte, 4096)
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				writeMu.Lock()
+				writeShellPacket(conn, id, buf[:n])
+				writeMu.Unlock()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	copied.Add(2)
+	go copyOutput(1, stdout)
+	go copyOutput(2, stderr)
+	go func() {
+		copied.Wait()
+		shell.Wait()
+		writeMu.Lock()
+		writeShellPacket(conn, 3, []byte{0})
+		writeMu.Unlock()
+		conn.Close()
+	}()
+
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(conn, payload); err != nil {
+			return
+		}
+		if header[0] == 0 {
+			stdin.Write(payload)
+		}
+	}
+}
+
+// serveSync answers sync protocol requests from the device's files until QUIT
+func (s *FakeADBServer) serveSync(conn net.Conn, serial string) {
+	for {
+		id, path, err := readSyncRequest(conn)
+		if err != nil || id == "QUIT" {
+			return
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, "sync:"+id+" "+path)
+		s.mu.Unlock()
+
+		switch id {
+		case "STAT":
+			file, ok := s.GetFile(serial, path)
+			stat := make([]byte, 16)
+			copy(stat, "STAT")
+			if ok {
+				binary.LittleEndian.PutUint32(stat[4:], file.mode())
+				binary.LittleEndian.PutUint32(stat[8:], uint32(file.size()))
+				binary.LittleEndian.PutUint32(stat[12:], uint32(time.Now().Unix()))
+			}
+			conn.Write(stat)
+		case "LIST":
+			s.mu.Lock()
+			prefix := serial + " " + strings.TrimSuffix(path, "/") + "/"
+			for key, file := range s.files {
+				name, ok := strings.CutPrefix(key, prefix)
+				if !ok || strings.Contains(name, "/") {
+					continue
+				}
+				dent := make([]byte, 20)
+				copy(dent, "DENT")
+				binary.LittleEndian.PutUint32(dent[4:], file.mode())
+				binary.LittleEndian.PutUint32(dent[8:], uint32(file.size()))
+				binary.LittleEndian.PutUint32(dent[16:], uint32(len(name)))
+				conn.Write(append(dent, name...))
+			}
+			s.mu.Unlock()
+			conn.Write(append([]byte("DONE"), make([]byte, 16)...))
+		case "RECV":
+			file, ok := s.GetFile(serial, path)
+			if !ok {
+				writeSyncPacket(conn, "FAIL", []byte("No such file or directory"))
+				continue
+			}
+			for data := file.Data; len(data) > 0; {
+				chunk := data[:min(len(data), 64*1024)]
+				data = data[len(chunk):]
+				writeSyncPacket(conn, "DATA", chunk)
+			}
+			writeSyncPacket(conn, "DONE", nil)
+		case "SEND":
+			remotePath, _, _ := strings.Cut(path, ",")
+			var data []byte
+			for {
+				header := make([]byte, 8)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				if string(header[:4]) == "DONE" {
+					break
+				}
+				chunk := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+				if _, err := io.ReadFull(conn, chunk); err != nil {
+					return
+				}
+				data = append(data, chunk...)
+			}
+			s.SetFile(serial, remotePath, FakeFile{Data: data})
+			writeSyncPacket(conn, "OKAY", nil)
+		default:
+			writeSyncPacket(conn, "FAIL", []byte("unknown sync request"))
+			return
+		}
+	}
+}
+
+func (f FakeFile) mode() uint32 {
+	if f.Mode == 0 {
+		return 0100644
+	}
+	return f.Mode
+}
+
+func (f FakeFile) size() int64 {
+	if f.ReportedSize != 0 {
+		return f.ReportedSize
+	}
+	return int64(len(f.Data))
+}
+
+func readSyncRequest(conn net.Conn) (string, string, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", "", err
+	}
+	payload := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return "", "", err
+	}
+	return string(header[:4]), string(payload), nil
+}
+
+func writeSyncPacket(w io.Writer, id string, payload []byte) {
+	header := make([]byte, 8)
+	copy(header, id)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(payload)))
+	w.Write(append(header, payload...))
+}
+
+func (s *FakeADBServer) readRequest(conn net.Conn) (string, error) {
+	lengthHex := make([]byte, 4)
+	if _, err := io.ReadFull(conn, lengthHex); err != nil {
+		return "", err
+	}
+	length, err := strconv.ParseUint(string(lengthHex), 16, 16)
+	if err != nil {
+		return "", err
+	}
+	request := make([]byte, length)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, string(request))
+	s.mu.Unlock()
+	return string(request), nil
+}
+
+func (s *FakeADBServer) hasDevice(serial string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range strings.Split(s.devices, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == serial {
+			return true
+		}
+	}
+	return false
+}
+
+// shellResponse returns the stub for a command; unstubbed command lines joined with "; "
+// run like a tiny shell script: echo prints its argument, other commands use their stubs
+func (s *FakeADBServer) shellResponse(serial, command string) ShellResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shellCommands = append(s.shellCommands, command)
+
+	if response, ok := s.shellResponses[serial+" "+command]; ok || !strings.Contains(command, "; ") {
+		return response
+	}
+
+	var script ShellResponse
+	for _, part := range strings.Split(command, "; ") {
+		if text, ok := strings.CutPrefix(part, "echo "); ok {
+			script.Stdout += text + "\n"
+			continue
+		}
+		response := s.shellResponses[serial+" "+strings.TrimSuffix(part, " 2>/dev/null")]
+		script.Stdout += response.Stdout
+		script.ExitCode = response.ExitCode
+		script.Delay += response.Delay
+	}
+	return script
+}
+
+func writeOkayWithPayload(w io.Writer, payload string) {
+	fmt.Fprintf(w, "OKAY%04x%s", len(payload), payload)
+}
+
+func writeFrame(w io.Writer, payload string) {
+	fmt.Fprintf(w, "%04x%s", len(payload), payload)
+}
+
+// trackingPayload reduces a devices -l listing to the "SERIAL\tSTATUS" lines track-devices sends
+func trackingPayload(devices string) string {
+	var payload strings.Builder
+	for _, line := range strings.Split(devices, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		fmt.Fprintf(&payload, "%s\t%s\n", fields[0], fields[1])
+	}
+	return payload.String()
+}
+
+func writeFail(w io.Writer, message string) {
+	fmt.Fprintf(w, "FAIL%04x%s", len(message), message)
+}
+
+func writeShellPacket(w io.Writer, id byte, payload []byte) {
+	header := make([]byte, 5)
+	header[0] = id
+	binary.LittleEndian.PutUint32(header[1:], uint32(len(payload)))
+	w.Write(header)
+	w.Write(payload)
+}
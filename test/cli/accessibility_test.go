@@ -0,0 +1,243 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const talkBackService = "com.google.android.marvin.talkback/com.google.android.marvin.talkback.TalkBackService"
+
+func stubSecureSetting(f *util.GenericExecFaker, adbPath, key, value string) {
+	f.StubADBShellCommand(adbPath, "emulator-5554", []string{"settings", "get", "secure", key}, value, "", 0)
+}
+
+func TestAccessibilityCommands(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		command          string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "TalkBack is off",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "null")
+			},
+			command:        "talkback",
+			expectedOutput: []string{"Default TalkBack: off", "Current TalkBack: off", "Allowed: on, off"},
+			unexpected:     []string{"settings put"},
+		},
+		{
+			name: "TalkBack on keeps other services",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "com.example/.SwitchService")
+			},
+			command:        "talkback",
+			value:          "on",
+			expectedOutput: []string{"TalkBack turned on on device emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put secure enabled_accessibility_services com.example/.SwitchService:" + talkBackService,
+				"adb -s emulator-5554 shell settings put secure accessibility_enabled 1",
+			},
+		},
+		{
+			name: "TalkBack off as the only service",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", talkBackService)
+			},
+			command: "talkback",
+			value:   "off",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings delete secure enabled_accessibility_services",
+				"adb -s emulator-5554 shell settings put secure accessibility_enabled 0",
+			},
+		},
+		{
+			name: "TalkBack by its short component name",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "com.google.android.marvin.talkback/.TalkBackService")
+			},
+			command:        "talkback",
+			expectedOutput: []string{"Current TalkBack: on"},
+		},
+		{
+			name: "TalkBack on by its short name isn't added again",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "com.google.android.marvin.talkback/.TalkBackService:com.example/.SwitchService")
+			},
+			command:        "talkback",
+			value:          "on",
+			expectedOutput: []string{"TalkBack is already on on device emulator-5554"},
+			unexpected:     []string{"settings put", "settings delete"},
+		},
+		{
+			name: "TalkBack off by its short name keeps other services",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "com.google.android.marvin.talkback/.TalkBackService:com.example/.SwitchService")
+			},
+			command: "talkback",
+			value:   "off",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put secure enabled_accessibility_services com.example/.SwitchService",
+				"adb -s emulator-5554 shell settings put secure accessibility_enabled 1",
+			},
+		},
+		{
+			name: "TalkBack off when it is already off writes nothing",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "enabled_accessibility_services", "null")
+			},
+			command:        "talkback",
+			value:          "off",
+			expectedOutput: []string{"TalkBack is already off on device emulator-5554"},
+			unexpected:     []string{"settings put", "settings delete"},
+		},
+		{
+			name: "high-contrast text on",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "high_text_contrast_enabled", "1")
+			},
+			command:          "high-contrast-text",
+			value:            "on",
+			expectedOutput:   []string{"High-contrast text turned on on device emulator-5554", "Current high-contrast text: on"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put secure high_text_contrast_enabled 1"},
+		},
+		{
+			name: "color inversion needs on or off",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command:       "color-inversion",
+			value:         "maybe",
+			expectedError: "invalid value: maybe (expected on or off)",
+			unexpected:    []string{"settings put"},
+		},
+		{
+			name: "color correction mode",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubSecureSetting(f, cfg.GetADBPath(), "accessibility_display_daltonizer_enabled", "1")
+				stubSecureSetting(f, cfg.GetADBPath(), "accessibility_display_daltonizer", "13")
+			},
+			command:        "color-correction",
+			expectedOutput: []string{"Current color correction: tritanomaly", "Allowed: off, deuteranomaly, protanomaly, tritanomaly, monochromacy"},
+		},
+		{
+			name: "set color correction",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command: "color-correction",
+			value:   "protanomaly",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put secure accessibility_display_daltonizer 12",
+				"adb -s emulator-5554 shell settings put secure accessibility_display_daltonizer_enabled 1",
+			},
+		},
+		{
+			name: "remove animations",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubAnimationScales(f, cfg.GetADBPath(), "0.0", "0.0", "0.0")
+			},
+			command:        "remove-animations",
+			value:          "on",
+			expectedOutput: []string{"Animations removed: on"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put global window_animation_scale 0.0",
+				"adb -s emulator-5554 shell settings put global animator_duration_scale 0.0",
+			},
+		},
+		{
+			name: "bring animations back",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command:          "remove-animations",
+			value:            "off",
+			expectedOutput:   []string{"Animations removed: off"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings delete global transition_animation_scale"},
+		},
+		{
+			name: "reset accessibility",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command: "reset",
+			value:   "accessibility",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings delete secure high_text_contrast_enabled",
+				"adb -s emulator-5554 shell settings delete secure accessibility_display_inversion_enabled",
+				"adb -s emulator-5554 shell settings delete secure accessibility_display_daltonizer_enabled",
+			},
+			// TalkBack is already off, so the other services stay enabled
+			unexpected: []string{"settings delete secure enabled_accessibility_services", "accessibility_enabled", "wm density reset", "delete global window_animation_scale"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestSetColorCorrectionRejectsUnknownMode(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+
+	var err error
+	util.WithFakeExec(faker, func() {
+		err = commands.SetColorCorrection(context.Background(), cfg, adb.Device{Serial: "emulator-5554"}, "sepia")
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid color correction: sepia")
+	assert.False(t, executedCommand(faker, "settings put"), "Unexpected command executed: settings put")
+}
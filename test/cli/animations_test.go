@@ -0,0 +1,134 @@
+package test
+
+import (
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAnimationScales stubs what the device reports for the window, transition and animator scales
+func stubAnimationScales(f *util.GenericExecFaker, adbPath, window, transition, animator string) {
+	f.StubADBShellCommand(adbPath, "emulator-5554", []string{"settings", "get", "global", "window_animation_scale"}, window, "", 0)
+	f.StubADBShellCommand(adbPath, "emulator-5554", []string{"settings", "get", "global", "transition_animation_scale"}, transition, "", 0)
+	f.StubADBShellCommand(adbPath, "emulator-5554", []string{"settings", "get", "global", "animator_duration_scale"}, animator, "", 0)
+}
+
+func TestAnimationsCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		command          string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "get default scales",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubAnimationScales(f, cfg.GetADBPath(), "null", "null", "null")
+			},
+			command:        "animations",
+			expectedOutput: []string{"Default animation scale: 1.0", "Current animation scale: 1.0", "Allowed: 0–10 in steps of 0.5"},
+		},
+		{
+			name: "get different scales",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubAnimationScales(f, cfg.GetADBPath(), "0.5", "null", "0")
+			},
+			command:        "animations",
+			expectedOutput: []string{"Current animation scale: window=0.5,transition=1.0,animator=0.0"},
+		},
+		{
+			name: "turn animations off",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubAnimationScales(f, cfg.GetADBPath(), "0.0", "0.0", "0.0")
+			},
+			command:        "animations",
+			value:          "off",
+			expectedOutput: []string{"Animations changed to 0.0 on device emulator-5554", "Current animation scale: 0.0"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put global window_animation_scale 0.0",
+				"adb -s emulator-5554 shell settings put global transition_animation_scale 0.0",
+				"adb -s emulator-5554 shell settings put global animator_duration_scale 0.0",
+			},
+		},
+		{
+			name: "change one scale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				stubAnimationScales(f, cfg.GetADBPath(), "null", "null", "2.0")
+			},
+			command:          "animations",
+			value:            "animator=2",
+			expectedOutput:   []string{"Animations changed to animator=2.0 on device emulator-5554"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put global animator_duration_scale 2.0"},
+			unexpected:       []string{"put global window_animation_scale", "put global transition_animation_scale"},
+		},
+		{
+			name: "unknown scale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command:       "animations",
+			value:         "speed=2",
+			expectedError: "unknown animation scale: speed (expected window, transition or animator)",
+			unexpected:    []string{"settings put global"},
+		},
+		{
+			name: "reset animations",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command:        "reset",
+			value:          "animations",
+			expectedOutput: []string{"Animations reset to default on device emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings delete global window_animation_scale",
+				"adb -s emulator-5554 shell settings delete global transition_animation_scale",
+				"adb -s emulator-5554 shell settings delete global animator_duration_scale",
+			},
+			unexpected: []string{"wm density reset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
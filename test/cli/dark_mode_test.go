@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubNightMode(f *util.GenericExecFaker, adbPath, mode string) {
+	f.StubADBShellCommand(adbPath, "emulator-5554", []string{"cmd", "uimode", "night"}, "Night mode: "+mode, "", 0)
+}
+
+func TestDarkModeCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          string
+		nightMode        string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:           "get mode",
+			command:        "dark-mode",
+			nightMode:      "auto",
+			expectedOutput: []string{"Default dark mode: no", "Current dark mode: auto", "Allowed: yes, no, auto, custom"},
+			unexpected:     []string{"cmd uimode night yes", "cmd uimode night no"},
+		},
+		{
+			name:             "turn on",
+			command:          "dark-mode",
+			nightMode:        "yes",
+			value:            "on",
+			expectedOutput:   []string{"Dark mode changed to yes on device emulator-5554", "Current dark mode: yes"},
+			expectedCommands: []string{"adb -s emulator-5554 shell cmd uimode night yes"},
+		},
+		{
+			name:             "set custom",
+			command:          "dark-mode",
+			nightMode:        "custom",
+			value:            "custom",
+			expectedCommands: []string{"adb -s emulator-5554 shell cmd uimode night custom"},
+		},
+		{
+			name:          "unknown mode",
+			command:       "dark-mode",
+			nightMode:     "no",
+			value:         "dim",
+			expectedError: "invalid dark mode: dim (expected yes, no, auto, custom, on or off)",
+			unexpected:    []string{"cmd uimode night dim"},
+		},
+		{
+			name:             "reset",
+			command:          "reset",
+			nightMode:        "yes",
+			value:            "dark-mode",
+			expectedOutput:   []string{"Dark mode reset to no on device emulator-5554"},
+			expectedCommands: []string{"adb -s emulator-5554 shell cmd uimode night no"},
+			unexpected:       []string{"wm density reset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			faker.StubSingleDevice(cfg.GetADBPath())
+			stubNightMode(faker, cfg.GetADBPath(), tt.nightMode)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestToggleDarkMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		nightMode    string
+		expectedMode string
+	}{
+		{name: "dark to light", nightMode: "yes", expectedMode: "no"},
+		{name: "light to dark", nightMode: "no", expectedMode: "yes"},
+		{name: "auto to dark", nightMode: "auto", expectedMode: "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			stubNightMode(faker, cfg.GetADBPath(), tt.nightMode)
+
+			util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					require.NoError(t, commands.ToggleDarkMode(context.Background(), cfg, adb.Device{Serial: "emulator-5554"}))
+				})
+			})
+			assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell cmd uimode night "+tt.expectedMode))
+		})
+	}
+}
+
+func TestDayNightScreenshotsRestoreOriginalMode(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+	stubNightMode(faker, cfg.GetADBPath(), "auto")
+	faker.AddStub(cfg.GetADBPath(), []string{"-s", "emulator-5554", "shell", "screencap", "/sdcard/screenshot.png"}, "", "screencap failed", 1)
+
+	var err error
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "screenshot-day-night", "", "", "", "")
+		})
+	})
+	require.Error(t, err)
+
+	// The failed day screenshot leaves the device in auto, not in light mode
+	executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+	require.NotEmpty(t, executed)
+	assert.Equal(t, "/test/android/sdk/platform-tools/adb -s emulator-5554 shell cmd uimode night auto", executed[len(executed)-1])
+}
@@ -0,0 +1,206 @@
+package test
+
+import (
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmulateScreenCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		args             []string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:           "list the catalog",
+			setupStubs:     func(f *util.GenericExecFaker, cfg *config.Config) {},
+			args:           []string{"list"},
+			expectedOutput: []string{"Pixel 4a         1080x2340 px, 440 dpi, 392x850 dp, hole cutout", "Nexus 5          1080x1920 px, 480 dpi, 360x640 dp"},
+			unexpected:     []string{"devices"},
+		},
+		{
+			name: "same pixels as the physical screen",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			args:           []string{"pixel 4a"},
+			expectedOutput: []string{"emulator-5554 now lays out like a Pixel 4a (392x850 dp)"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size 1080x2340",
+				"adb -s emulator-5554 shell wm density 440",
+			},
+		},
+		{
+			name: "larger screen scaled down with its density",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400\nOverride size: 720x1280", "Physical density: 420")
+			},
+			args:           []string{"Pixel 7 Pro", "emulator-5554"},
+			expectedOutput: []string{"(411x891 dp)"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size 1080x2340",
+				"adb -s emulator-5554 shell wm density 420",
+			},
+		},
+		{
+			name: "tablet in its landscape natural orientation",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 2560x1600", "Physical density: 420")
+			},
+			args: []string{"Pixel Tablet"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size 2560x1600",
+				"adb -s emulator-5554 shell wm density 320",
+			},
+		},
+		{
+			name: "density below the limit",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 480x800", "Physical density: 420")
+			},
+			args:          []string{"Galaxy Tab A8"},
+			expectedError: "use --force to apply it anyway",
+			unexpected:    []string{"wm size 480x768", "wm density 96"},
+		},
+		{
+			name: "unknown device",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:          []string{"Pixel 99"},
+			expectedError: "unknown device: Pixel 99 (expected Nexus 5, Pixel 3a, Pixel 4a",
+			unexpected:    []string{"devices", "wm size"},
+		},
+		{
+			name: "reset without a saved screen",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:           []string{"reset"},
+			expectedOutput: []string{"No screen saved from before emulating on emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size reset",
+				"adb -s emulator-5554 shell wm density reset",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteNestedCommand(cfg, "emulate-screen", tt.args)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestEmulateScreenResetRestoresScreenBeforeEmulating(t *testing.T) {
+	cfg := util.TestConfig()
+	cfg.ConfigDir = t.TempDir()
+	device := adb.Device{Serial: "emulator-5554"}
+	emulate := func(args ...string) *util.GenericExecFaker {
+		// The device keeps its own overrides until emulate-screen changes them
+		faker := util.NewGenericExecFaker()
+		stubPhoneScreen(faker, cfg.GetADBPath(), "Physical size: 1080x2400\nOverride size: 900x2000", "Physical density: 420\nOverride density: 360")
+		util.CaptureLogOutput(func() {
+			util.WithFakeExec(faker, func() {
+				require.NoError(t, cli.ExecuteNestedCommand(cfg, "emulate-screen", args))
+			})
+		})
+		return faker
+	}
+
+	emulate("Pixel 4a")
+	assert.FileExists(t, commands.EmulationPath(cfg, device))
+	emulate("Nexus 5")
+
+	// Reset goes back to the overrides from before the first emulation, not to the physical screen
+	faker := emulate("reset")
+	assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell wm size 900x2000"))
+	assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell wm density 360"))
+	assert.False(t, executedCommand(faker, "wm size reset"))
+	assert.False(t, executedCommand(faker, "wm density reset"))
+	assert.NoFileExists(t, commands.EmulationPath(cfg, device))
+}
+
+func TestResetAfterEmulatingSavesScreenAgain(t *testing.T) {
+	tests := []struct {
+		name    string
+		setting string
+	}{
+		{name: "reset dpi", setting: "dpi"},
+		{name: "reset screen size", setting: "screen-size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			device := adb.Device{Serial: "emulator-5554"}
+			run := func(size, density string, command func() error) *util.GenericExecFaker {
+				faker := util.NewGenericExecFaker()
+				stubPhoneScreen(faker, cfg.GetADBPath(), size, density)
+				util.CaptureLogOutput(func() {
+					util.WithFakeExec(faker, func() {
+						require.NoError(t, command())
+					})
+				})
+				return faker
+			}
+			emulate := func(args ...string) func() error {
+				return func() error { return cli.ExecuteNestedCommand(cfg, "emulate-screen", args) }
+			}
+
+			run("Physical size: 1080x2400\nOverride size: 900x2000", "Physical density: 420\nOverride density: 360", emulate("Pixel 4a"))
+			require.FileExists(t, commands.EmulationPath(cfg, device))
+
+			// Resetting either emulated setting leaves the emulation, so nothing from before it is kept
+			run("Physical size: 1080x2400\nOverride size: 1080x2340", "Physical density: 420\nOverride density: 440", func() error {
+				return cli.ExecuteCommand(cfg, "reset", "", "", "", tt.setting)
+			})
+			assert.NoFileExists(t, commands.EmulationPath(cfg, device))
+
+			// The next emulation saves the screen the device has by then
+			run("Physical size: 1080x2400\nOverride size: 720x1600", "Physical density: 420\nOverride density: 320", emulate("Nexus 5"))
+			faker := run("Physical size: 1080x2400\nOverride size: 1080x1920", "Physical density: 420\nOverride density: 480", emulate("reset"))
+			assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell wm size 720x1600"))
+			assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell wm density 320"))
+			assert.NoFileExists(t, commands.EmulationPath(cfg, device))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandErrorKinds(t *testing.T) {
+	tests := []struct {
+		name         string
+		stderr       string
+		expectedKind error
+		expectedExit int
+	}{
+		{
+			name:         "unauthorized",
+			stderr:       "error: device unauthorized.\nThis adb server's $ADB_VENDOR_KEYS is not set",
+			expectedKind: adb.ErrUnauthorized,
+			expectedExit: cli.ExitUnauthorized,
+		},
+		{
+			name:         "offline",
+			stderr:       "error: device offline",
+			expectedKind: adb.ErrOffline,
+			expectedExit: cli.ExitOffline,
+		},
+		{
+			name:         "no devices",
+			stderr:       "error: no devices/emulators found",
+			expectedKind: adb.ErrNoDevices,
+			expectedExit: cli.ExitNoDevices,
+		},
+		{
+			name:         "more than one device",
+			stderr:       "error: more than one device/emulator",
+			expectedKind: adb.ErrMultipleDevices,
+			expectedExit: cli.ExitMultiple,
+		},
+		{
+			name:         "device not found",
+			stderr:       "error: device 'emulator-5554' not found",
+			expectedKind: adb.ErrDeviceNotFound,
+			expectedExit: cli.ExitDeviceNotFound,
+		},
+		{
+			name:         "unrecognised failure",
+			stderr:       "Error: bad density",
+			expectedExit: cli.ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			faker.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"wm", "density", "320"}, "", tt.stderr, 1)
+
+			var err error
+			util.WithFakeExec(faker, func() {
+				err = adb.ExecuteCommand(cfg.GetADBPath(), "emulator-5554", "shell", "wm", "density", "320")
+			})
+
+			require.Error(t, err)
+			if tt.expectedKind != nil {
+				assert.ErrorIs(t, err, tt.expectedKind)
+				assert.NotEmpty(t, adb.Hint(err))
+			}
+			assert.Equal(t, tt.expectedExit, cli.ExitCode(err))
+
+			var cmdErr *adb.CommandError
+			require.ErrorAs(t, err, &cmdErr)
+			assert.Contains(t, err.Error(), "exit status 1")
+		})
+	}
+}
+
+func TestCommandErrorExitCodes(t *testing.T) {
+	tests := []struct {
+		name         string
+		setupStubs   func(*util.GenericExecFaker, *config.Config)
+		command      string
+		ip           string
+		expectedErr  string
+		expectedExit int
+	}{
+		{
+			name: "no devices",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubEmptyDevices(cfg.GetADBPath())
+			},
+			command:      "dpi",
+			expectedErr:  "no devices connected",
+			expectedExit: cli.ExitNoDevices,
+		},
+		{
+			name: "several devices without a selector",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubMultipleDevices(cfg.GetADBPath())
+			},
+			command:      "dpi",
+			expectedErr:  "please specify -device",
+			expectedExit: cli.ExitMultiple,
+		},
+		{
+			name: "unauthorized device is rejected before running",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubADBDevicesCommand(cfg.GetADBPath(), "List of devices attached\nR58M123ABC\tunauthorized usb:1-1 transport_id:3\n")
+			},
+			command:      "screenshot",
+			expectedErr:  "R58M123ABC: device unauthorized",
+			expectedExit: cli.ExitUnauthorized,
+		},
+		{
+			name: "disconnecting a device that isn't connected",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.AddStub(cfg.GetADBPath(), []string{"disconnect", "192.168.1.5:4444"}, "", "error: no such device '192.168.1.5:4444'", 1)
+			},
+			command:      "disconnect-wifi",
+			ip:           "192.168.1.5",
+			expectedErr:  "device 192.168.1.5:4444 was not connected",
+			expectedExit: cli.ExitDeviceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", tt.ip, "", "")
+				})
+			})
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+			assert.Equal(t, tt.expectedExit, cli.ExitCode(err))
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeHistory saves a history of a DPI change followed by a font size change
+func writeHistory(t *testing.T, path string, position int) {
+	t.Helper()
+	changedAt := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	data, err := json.Marshal(commands.History{
+		Serial: snapshotSerial,
+		Changes: []commands.HistoryChange{
+			{
+				Setting:   commands.SettingTypeDPI,
+				Previous:  commands.SnapshotSetting{Value: "420"},
+				New:       commands.SnapshotSetting{Value: "480", Overridden: true},
+				ChangedAt: changedAt,
+			},
+			{
+				Setting:   commands.SettingTypeFontSize,
+				Previous:  commands.SnapshotSetting{Value: "1.0"},
+				New:       commands.SnapshotSetting{Value: "1.3", Overridden: true},
+				ChangedAt: changedAt.Add(time.Minute),
+			},
+		},
+		Position: position,
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+}
+
+func TestHistoryCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		position         int
+		expectedPosition int
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:             "list",
+			position:         1,
+			expectedPosition: 1,
+			expectedOutput: []string{
+				"1  2026-10-01 09:30:00  dpi: 420 (default) → 480",
+				"2  2026-10-01 09:31:00  fontsize: 1.0 (default) → 1.3  (undone)",
+			},
+		},
+		{
+			name:             "undo the last change",
+			args:             []string{"undo"},
+			position:         2,
+			expectedPosition: 1,
+			expectedOutput:   []string{"Undid fontsize: 1.0 (default) → 1.3"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings delete system font_scale"},
+			unexpected:       []string{"wm density reset"},
+		},
+		{
+			name:             "redo an undone change",
+			args:             []string{"redo"},
+			position:         1,
+			expectedPosition: 2,
+			expectedOutput:   []string{"Redid fontsize: 1.0 (default) → 1.3"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put system font_scale 1.3"},
+		},
+		{
+			name:             "revert every change",
+			args:             []string{"revert", "0"},
+			position:         2,
+			expectedPosition: 0,
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings delete system font_scale",
+				"adb -s emulator-5554 shell wm density reset",
+			},
+		},
+		{
+			name:             "nothing to redo",
+			args:             []string{"redo"},
+			position:         2,
+			expectedPosition: 2,
+			expectedError:    "nothing to redo on emulator-5554",
+		},
+		{
+			name:             "revert past the end",
+			args:             []string{"revert", "3"},
+			position:         2,
+			expectedPosition: 2,
+			expectedError:    "invalid history position 3, expected 0 to 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			faker.StubSingleDevice(cfg.GetADBPath())
+			path := commands.HistoryPath(cfg, snapshotSerial)
+			writeHistory(t, path, tt.position)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteNestedCommand(cfg, "history", tt.args)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+
+			history, err := commands.LoadHistory(cfg, snapshotSerial)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedPosition, history.Position)
+			assert.Len(t, history.Changes, 2, "undo and redo must not record changes of their own")
+		})
+	}
+}
+
+func TestNewChangeReplacesUndoneChanges(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	cfg.ConfigDir = t.TempDir()
+	faker.StubDPIGet(cfg.GetADBPath(), snapshotSerial, "Physical density: 420\nOverride density: 320")
+	writeHistory(t, commands.HistoryPath(cfg, snapshotSerial), 1)
+
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			require.NoError(t, commands.GetSettingHandler(commands.SettingTypeDPI).SetValue(context.Background(), cfg, adb.Device{Serial: snapshotSerial}, "320"))
+		})
+	})
+
+	history, err := commands.LoadHistory(cfg, snapshotSerial)
+	require.NoError(t, err)
+	require.Len(t, history.Changes, 2)
+	assert.Equal(t, 2, history.Position)
+	assert.Equal(t, commands.SettingTypeDPI, history.Changes[1].Setting)
+	assert.Equal(t, commands.SnapshotSetting{Value: "320", Overridden: true}, history.Changes[1].New)
+}
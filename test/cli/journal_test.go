@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"gadget/internal/adb"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRevertsChangesInReverseOrder(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	cfg.ConfigDir = t.TempDir()
+	stubDeviceSettings(faker, cfg.GetADBPath(), "Physical density: 420", "Physical size: 1080x2400", "null", "no")
+	device := adb.Device{Serial: snapshotSerial, Status: adb.StatusDevice}
+
+	commands.EnableJournal(cfg)
+	defer commands.DisableJournal()
+
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			require.NoError(t, commands.GetSettingHandler(commands.SettingTypeDPI).SetValue(context.Background(), cfg, device, "480"))
+			require.NoError(t, commands.GetSettingHandler(commands.SettingTypeFontSize).SetValue(context.Background(), cfg, device, "1.3"))
+			require.NoError(t, commands.SetDarkMode(context.Background(), cfg, device, true))
+		})
+	})
+
+	journal, err := commands.LoadJournal(cfg, snapshotSerial)
+	require.NoError(t, err)
+	require.NotNil(t, journal)
+	assert.Equal(t, os.Getpid(), journal.PID)
+	require.Len(t, journal.Entries, 3)
+	assert.Equal(t, commands.SnapshotSetting{Value: "420", Overridden: false}, journal.Entries[0].Previous)
+	assert.Equal(t, commands.SnapshotSetting{Value: "1.0", Overridden: false}, journal.Entries[1].Previous)
+	assert.Equal(t, commands.SnapshotSetting{Value: "no", Overridden: false}, journal.Entries[2].Previous)
+
+	faker = util.NewGenericExecFaker()
+	stubDeviceSettings(faker, cfg.GetADBPath(), "Physical density: 420\nOverride density: 480", "Physical size: 1080x2400", "1.3", "yes")
+
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			require.NoError(t, commands.RevertJournals(context.Background(), cfg))
+		})
+	})
+	assert.Contains(t, output, "Reverted all changes on emulator-5554")
+
+	// Reverting must not journal its own changes, and the journal is gone afterwards
+	var reverts []string
+	for _, executed := range util.FormatExecutedCommands(faker.GetExecutedCommands()) {
+		switch executed {
+		case "/test/android/sdk/platform-tools/adb -s emulator-5554 shell cmd uimode night no",
+			"/test/android/sdk/platform-tools/adb -s emulator-5554 shell settings delete system font_scale",
+			"/test/android/sdk/platform-tools/adb -s emulator-5554 shell wm density reset":
+			reverts = append(reverts, executed)
+		}
+	}
+	assert.Equal(t, []string{
+		"/test/android/sdk/platform-tools/adb -s emulator-5554 shell cmd uimode night no",
+		"/test/android/sdk/platform-tools/adb -s emulator-5554 shell settings delete system font_scale",
+		"/test/android/sdk/platform-tools/adb -s emulator-5554 shell wm density reset",
+	}, reverts)
+	assert.NoFileExists(t, commands.JournalPath(cfg, snapshotSerial))
+}
+
+func TestPendingJournals(t *testing.T) {
+	tests := []struct {
+		name            string
+		pid             int
+		expectedPending bool
+	}{
+		{name: "crashed session", pid: 0, expectedPending: true},
+		{name: "this session", pid: os.Getpid(), expectedPending: true},
+		{name: "another running session", pid: os.Getppid(), expectedPending: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			path := commands.JournalPath(cfg, "192.168.1.100:5555")
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			data, err := json.Marshal(commands.Journal{
+				Serial:    "192.168.1.100:5555",
+				PID:       tt.pid,
+				StartedAt: time.Now(),
+				Entries:   []commands.JournalEntry{{Setting: commands.SettingTypeDPI, Previous: commands.SnapshotSetting{Value: "420"}}},
+			})
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, data, 0o644))
+
+			pending, err := commands.PendingJournals(cfg)
+			require.NoError(t, err)
+			if tt.expectedPending {
+				require.Len(t, pending, 1)
+				assert.Equal(t, "192.168.1.100:5555", pending[0].Serial)
+			} else {
+				assert.Empty(t, pending)
+			}
+		})
+	}
+}
+
+func TestSettingChangeStopsWhenCancelled(t *testing.T) {
+	tests := []struct {
+		name        string
+		settingType commands.SettingType
+		value       string // Empty for a reset
+		unexpected  string
+	}{
+		{name: "set DPI", settingType: commands.SettingTypeDPI, value: "480", unexpected: "adb -s emulator-5554 shell wm density 480"},
+		{name: "reset screen size", settingType: commands.SettingTypeScreenSize, unexpected: "adb -s emulator-5554 shell wm size reset"},
+		{name: "set animations", settingType: commands.SettingTypeAnimations, value: "off", unexpected: "adb -s emulator-5554 shell settings put global"},
+		{name: "set TalkBack", settingType: commands.SettingTypeTalkBack, value: "on", unexpected: "adb -s emulator-5554 shell settings put secure"},
+		{name: "set dark mode", settingType: commands.SettingTypeNightMode, value: "yes", unexpected: "adb -s emulator-5554 shell cmd uimode night yes"},
+		{name: "set locale", settingType: commands.SettingTypeLocale, value: "fr-FR", unexpected: "adb -s emulator-5554 shell settings put system system_locales"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			stubDeviceSettings(faker, cfg.GetADBPath(), "Physical density: 420", "Physical size: 1080x2400", "null", "no")
+			device := adb.Device{Serial: snapshotSerial, Status: adb.StatusDevice}
+
+			commands.EnableJournal(cfg)
+			defer commands.DisableJournal()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			var err error
+			util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					handler := commands.GetSettingHandler(tt.settingType)
+					if tt.value != "" {
+						err = handler.SetValue(ctx, cfg, device, tt.value)
+					} else {
+						err = handler.Reset(ctx, cfg, device)
+					}
+				})
+			})
+
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.False(t, executedCommand(faker, tt.unexpected), "Unexpected command executed: %s", tt.unexpected)
+			assert.NoFileExists(t, commands.JournalPath(cfg, snapshotSerial))
+		})
+	}
+}
+
+func TestSettingReadStopsWhenCancelled(t *testing.T) {
+	for _, settingType := range commands.SettingTypes {
+		t.Run(string(settingType), func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			stubDeviceSettings(faker, cfg.GetADBPath(), "Physical density: 420", "Physical size: 1080x2400", "null", "no")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			var err error
+			util.WithFakeExec(faker, func() {
+				_, err = commands.GetSettingHandler(settingType).GetInfo(ctx, cfg, adb.Device{Serial: snapshotSerial})
+			})
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package test
+
+import (
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		command          string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "get default locale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "", "en-US")
+			},
+			command:        "locale",
+			expectedOutput: []string{"Default locale: en-US", "Current locale: en-US", "Suggested: en-US, en-GB"},
+			unexpected:     []string{"setprop", "stop && start"},
+		},
+		{
+			name: "switch to the accents pseudo-locale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "en-XA", "en-US")
+			},
+			command:          "locale",
+			value:            "accents",
+			expectedOutput:   []string{"Locale changed to en-XA on device emulator-5554", "The locale applies once emulator-5554 restarts", "Current locale: en-XA"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put system system_locales en-XA"},
+			unexpected:       []string{"setprop", "stop && start"},
+		},
+		{
+			name: "root restarts Android right away",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "en-XA", "en-US")
+				f.StubRoot(cfg.GetADBPath(), "emulator-5554")
+			},
+			command:        "locale",
+			value:          "accents",
+			expectedOutput: []string{"Locale changed to en-XA on device emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put system system_locales en-XA",
+				"adb -s emulator-5554 shell setprop persist.sys.locale en-XA",
+				"adb -s emulator-5554 shell stop && start",
+				"adb -s emulator-5554 shell getprop init.svc.bootanim",
+			},
+		},
+		{
+			name: "underscores are accepted",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "fr-FR", "en-US")
+			},
+			command:          "locale",
+			value:            "fr_FR",
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put system system_locales fr-FR"},
+		},
+		{
+			name: "before API 33 the locale properties need root",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.build.version.sdk"}, "30", "", 0)
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.product.locale"}, "en-US", "", 0)
+			},
+			command:       "locale",
+			value:         "rtl",
+			expectedError: "changing the locale before API 33 needs root, try \"adb root\"",
+			unexpected:    []string{"setprop", "system_locales", "stop && start"},
+		},
+		{
+			name: "before API 33 with root",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.build.version.sdk"}, "30", "", 0)
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.product.locale"}, "en-US", "", 0)
+				f.StubRoot(cfg.GetADBPath(), "emulator-5554")
+			},
+			command: "locale",
+			value:   "rtl",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell setprop persist.sys.locale ar-XB",
+				"adb -s emulator-5554 shell stop && start",
+			},
+			unexpected: []string{"system_locales"},
+		},
+		{
+			name: "older devices split language and country",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.build.version.sdk"}, "19", "", 0)
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "ro.product.locale.language"}, "en", "", 0)
+				f.StubRoot(cfg.GetADBPath(), "emulator-5554")
+			},
+			command: "locale",
+			value:   "de-DE",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell setprop persist.sys.language de",
+				"adb -s emulator-5554 shell setprop persist.sys.country DE",
+			},
+			unexpected: []string{"persist.sys.locale", "system_locales"},
+		},
+		{
+			name: "invalid locale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			command:       "locale",
+			value:         "english",
+			expectedError: "invalid locale: english",
+			unexpected:    []string{"setprop", "system_locales"},
+		},
+		{
+			name: "setprop fails",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "", "en-US")
+				f.StubRoot(cfg.GetADBPath(), "emulator-5554")
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"setprop", "persist.sys.locale", "ar-XB"}, "", "Failed to set property", 1)
+			},
+			command:       "locale",
+			value:         "rtl",
+			expectedError: "failed to write persist.sys.locale",
+			unexpected:    []string{"stop && start"},
+		},
+		{
+			name: "reset locale",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "ar-XB", "en-US")
+			},
+			command:          "reset",
+			value:            "locale",
+			expectedOutput:   []string{"Locale reset to en-US on device emulator-5554"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings delete system system_locales"},
+			unexpected:       []string{"setprop", "wm density reset"},
+		},
+		{
+			name: "reset locale with root",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "ar-XB", "en-US")
+				f.StubRoot(cfg.GetADBPath(), "emulator-5554")
+			},
+			command: "reset",
+			value:   "locale",
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings delete system system_locales",
+				"adb -s emulator-5554 shell setprop persist.sys.locale ''",
+				"adb -s emulator-5554 shell stop && start",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestLocaleScreenshotsRestoreOriginalLocale(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+	faker.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "de-DE", "en-US")
+	faker.StubRoot(cfg.GetADBPath(), "emulator-5554")
+	faker.AddStub(cfg.GetADBPath(), []string{"-s", "emulator-5554", "shell", "screencap", "/sdcard/screenshot.png"}, "", "screencap failed", 1)
+
+	var err error
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "screenshot-locales", "", "", "", "")
+		})
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to take en-XA screenshot")
+
+	// The failed first screenshot puts the device back in its own locale, not the next pseudo-locale
+	var setprops []string
+	for _, cmd := range util.FormatExecutedCommands(faker.GetExecutedCommands()) {
+		if strings.Contains(cmd, "setprop") {
+			setprops = append(setprops, cmd)
+		}
+	}
+	assert.Equal(t, []string{
+		"/test/android/sdk/platform-tools/adb -s emulator-5554 shell setprop persist.sys.locale en-XA",
+		"/test/android/sdk/platform-tools/adb -s emulator-5554 shell setprop persist.sys.locale de-DE",
+	}, setprops)
+}
+
+func TestScreenshotLocalesRejectsInvalidLocale(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+
+	var err error
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "screenshot-locales", "", "", "", "en-XA,klingon!")
+		})
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid locale: klingon!")
+	assert.False(t, executedCommand(faker, "setprop"))
+}
+
+func TestScreenshotLocalesNeedsRoot(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+	faker.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "", "en-US")
+
+	var err error
+	util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "screenshot-locales", "", "", "", "")
+		})
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "locale screenshots restart Android, which needs root on emulator-5554")
+	assert.False(t, executedCommand(faker, "system_locales en-XA"))
+	assert.False(t, executedCommand(faker, "screencap"))
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiDeviceCommands(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		command          string
+		deviceSelector   string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+	}{
+		{
+			name: "set DPI on all devices",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubMultipleDevices(cfg.GetADBPath())
+				for _, serial := range []string{"emulator-5554", "192.168.1.100:5555"} {
+					f.StubDPISet(cfg.GetADBPath(), serial, "320", 0)
+					f.StubDPIGet(cfg.GetADBPath(), serial, "Physical density: 420\nOverride density: 320")
+				}
+			},
+			command:        "dpi",
+			deviceSelector: "all",
+			value:          "320",
+			expectedOutput: []string{"Running dpi on 2 devices", "emulator-5554: Current DPI: 320", "192.168.1.100:5555: Current DPI: 320", "dpi succeeded on 2 devices"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm density 320",
+				"adb -s 192.168.1.100:5555 shell wm density 320",
+			},
+		},
+		{
+			name: "one failing device fails the run",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubMultipleDevices(cfg.GetADBPath())
+				f.StubDPISet(cfg.GetADBPath(), "emulator-5554", "320", 0)
+				f.StubDPIGet(cfg.GetADBPath(), "emulator-5554", "Physical density: 420\nOverride density: 320")
+				f.StubDPISet(cfg.GetADBPath(), "192.168.1.100:5555", "320", 1)
+			},
+			command:        "dpi",
+			deviceSelector: "all",
+			value:          "320",
+			expectedOutput: []string{"✓ emulator-5554"},
+			expectedError:  "dpi failed on 1 of 2 devices",
+		},
+		{
+			name: "keyword matching one device runs directly",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubMultipleDevices(cfg.GetADBPath())
+				f.StubFontSizeGet(cfg.GetADBPath(), "192.168.1.100:5555", "1.3")
+			},
+			command:          "font-size",
+			deviceSelector:   "wifi",
+			expectedOutput:   []string{"Current font size: 1.3"},
+			expectedCommands: []string{"adb -s 192.168.1.100:5555 shell settings get system font_scale"},
+		},
+		{
+			name: "ambiguous model substring lists candidates",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubADBDevicesCommand(cfg.GetADBPath(), `List of devices attached
+emulator-5554	device product:sdk_gphone64_x86_64 model:sdk_gphone64_x86_64 device:generic_x86_64 transport_id:1
+emulator-5556	device product:sdk_gphone64_x86_64 model:sdk_gphone64_x86_64 device:generic_x86_64 transport_id:2
+`)
+			},
+			command:        "dpi",
+			deviceSelector: "gphone",
+			expectedError:  "\"gphone\" matches 2 devices",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var cmdError error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					cmdError = cli.ExecuteCommand(cfg, tt.command, tt.deviceSelector, "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, cmdError)
+				assert.Contains(t, cmdError.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, cmdError)
+			}
+
+			for _, expectedOut := range tt.expectedOutput {
+				assert.Contains(t, output, expectedOut, "Expected output not found")
+			}
+
+			executedCommands := faker.GetExecutedCommands()
+			for _, expectedCmd := range tt.expectedCommands {
+				found := false
+				for _, executed := range executedCommands {
+					if util.MatchesCommandPattern(executed, expectedCmd) {
+						found = true
+						break
+					}
+				}
+				assert.True(t, found, "Expected command not executed: %s\nActual commands: %v", expectedCmd, util.FormatExecutedCommands(executedCommands))
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		args             []string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "list overlays",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "debug.layout"}, "true", "", 0)
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"settings", "get", "system", "show_touches"}, "1", "", 0)
+			},
+			expectedOutput: []string{"Debug overlays on emulator-5554:", "layout-bounds      on", "overdraw           off", "taps               on"},
+			unexpected:     []string{"setprop", "settings put"},
+		},
+		{
+			name: "show layout bounds",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:           []string{"layout-bounds", "on"},
+			expectedOutput: []string{"Debug overlays changed to layout-bounds on device emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell setprop debug.layout true",
+				"adb -s emulator-5554 shell service call activity 1599295570",
+			},
+			unexpected: []string{"setprop debug.hwui.overdraw", "settings put system show_touches"},
+		},
+		{
+			name: "hide taps, keeping layout bounds",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "debug.layout"}, "true", "", 0)
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"settings", "get", "system", "show_touches"}, "1", "", 0)
+			},
+			args:             []string{"taps", "off", "emulator-5554"},
+			expectedOutput:   []string{"Debug overlays changed to layout-bounds on device emulator-5554"},
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put system show_touches 0"},
+			unexpected:       []string{"setprop debug.layout", "service call activity"},
+		},
+		{
+			name: "unknown overlay",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:          []string{"grid", "on"},
+			expectedError: "unknown debug overlay: grid (expected layout-bounds, overdraw, gpu-profile, taps, pointer-location)",
+			unexpected:    []string{"setprop", "settings put"},
+		},
+		{
+			name: "missing state",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:          []string{"overdraw"},
+			expectedError: "overlay overdraw requires on or off",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteNestedCommand(cfg, "overlay", tt.args)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestResetOverlays(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+
+	var err error
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "reset", "", "", "", "overlays")
+		})
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Debug overlays turned off on device emulator-5554")
+
+	for _, expectedCmd := range []string{
+		"adb -s emulator-5554 shell setprop debug.layout false",
+		"adb -s emulator-5554 shell setprop debug.hwui.overdraw false",
+		"adb -s emulator-5554 shell setprop debug.hwui.profile false",
+		"adb -s emulator-5554 shell settings put system show_touches 0",
+		"adb -s emulator-5554 shell settings put system pointer_location 0",
+		"adb -s emulator-5554 shell service call activity 1599295570",
+	} {
+		assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s", expectedCmd)
+	}
+}
+
+func TestToggleDebugOverlay(t *testing.T) {
+	tests := []struct {
+		name          string
+		profile       string
+		expectedValue string
+	}{
+		{name: "bars off to on", profile: "false", expectedValue: "visual_bars"},
+		{name: "bars on to off", profile: "visual_bars", expectedValue: "false"},
+		{name: "profiling to adb counts as off", profile: "true", expectedValue: "visual_bars"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			faker.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"getprop", "debug.hwui.profile"}, tt.profile, "", 0)
+
+			util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					require.NoError(t, commands.ToggleDebugOverlay(context.Background(), cfg, adb.Device{Serial: "emulator-5554"}, "gpu-profile"))
+				})
+			})
+			assert.True(t, executedCommand(faker, "adb -s emulator-5554 shell setprop debug.hwui.profile "+tt.expectedValue))
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package test
+
+import (
+	"encoding/json"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		args             []string
+		force            bool
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:           "list the built-in presets",
+			setupStubs:     func(f *util.GenericExecFaker, cfg *config.Config) {},
+			args:           []string{"list"},
+			expectedOutput: []string{"a11y-max         dpi=+20%, font-size=2.0  (built-in)", "small-phone      screen-size=720x1280, dpi=320  (built-in)", "Changes such as +20% are from the device default"},
+		},
+		{
+			name: "apply a small phone",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args:           []string{"apply", "small-phone"},
+			expectedOutput: []string{"Applied preset small-phone to emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size 720x1280",
+				"adb -s emulator-5554 shell wm density 320",
+			},
+		},
+		{
+			name: "relative DPI from the physical density",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubDPIGet(cfg.GetADBPath(), "emulator-5554", "Physical density: 420\nOverride density: 380")
+			},
+			args: []string{"apply", "a11y-max", "emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm density 504",
+				"adb -s emulator-5554 shell settings put system font_scale 2.0",
+			},
+		},
+		{
+			name: "relative DPI out of range",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubDPIGet(cfg.GetADBPath(), "emulator-5554", "Physical density: 560")
+			},
+			args:          []string{"apply", "a11y-max"},
+			expectedError: "use --force to apply it anyway",
+			unexpected:    []string{"wm density 672", "font_scale 2.0"},
+		},
+		{
+			name: "relative DPI out of range with force",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubDPIGet(cfg.GetADBPath(), "emulator-5554", "Physical density: 560")
+			},
+			args:             []string{"apply", "a11y-max"},
+			force:            true,
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density 672"},
+		},
+		{
+			name: "presets file keyed by command line names",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				writePresets(t, cfg, `{"night": {"settings": {"font-size": "1.3", "dark-mode": "yes", "high-contrast-text": "on"}}}`)
+			},
+			args: []string{"apply", "night"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put system font_scale 1.3",
+				"adb -s emulator-5554 shell cmd uimode night yes",
+				"adb -s emulator-5554 shell settings put secure high_text_contrast_enabled 1",
+			},
+		},
+		{
+			name: "SettingType names are accepted too",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				writePresets(t, cfg, `{"night": {"settings": {"fontsize": "1.3", "nightmode": "yes"}}}`)
+			},
+			args: []string{"apply", "night"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell settings put system font_scale 1.3",
+				"adb -s emulator-5554 shell cmd uimode night yes",
+			},
+		},
+		{
+			name: "unknown setting in a preset",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				writePresets(t, cfg, `{"night": {"settings": {"font_size": "1.3"}}}`)
+			},
+			args:          []string{"apply", "night"},
+			expectedError: "unknown setting font_size in preset night (expected screen-size, dpi, font-size,",
+			unexpected:    []string{"font_scale"},
+		},
+		{
+			name:          "unknown preset",
+			setupStubs:    func(f *util.GenericExecFaker, cfg *config.Config) {},
+			args:          []string{"apply", "tablet"},
+			expectedError: "unknown preset: tablet (expected a11y-max, small-phone)",
+			unexpected:    []string{"devices"},
+		},
+		{
+			name:          "apply needs a name",
+			setupStubs:    func(f *util.GenericExecFaker, cfg *config.Config) {},
+			args:          []string{"apply"},
+			expectedError: "preset apply requires a preset name",
+		},
+		{
+			name:          "unknown subcommand",
+			setupStubs:    func(f *util.GenericExecFaker, cfg *config.Config) {},
+			args:          []string{"delete", "small-phone"},
+			expectedError: "unknown preset subcommand: delete",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			cfg.Force = tt.force
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteNestedCommand(cfg, "preset", tt.args)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestSavePreset(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	cfg.ConfigDir = t.TempDir()
+	stubDeviceSettings(faker, cfg.GetADBPath(),
+		"Physical density: 420\nOverride density: 480",
+		"Physical size: 1080x2400",
+		"null",
+		"no")
+
+	var err error
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteNestedCommand(cfg, "preset", []string{"save", "dense"})
+		})
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Saved the settings of emulator-5554 as preset dense in "+commands.PresetsPath(cfg))
+
+	data, err := os.ReadFile(commands.PresetsPath(cfg))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"font-size": "default"`)
+	assert.NotContains(t, string(data), `"fontsize"`)
+	var saved map[string]commands.Preset
+	require.NoError(t, json.Unmarshal(data, &saved))
+	require.Contains(t, saved, "dense")
+	assert.Equal(t, "480", saved["dense"].Settings[commands.SettingTypeDPI])
+	assert.Equal(t, "default", saved["dense"].Settings[commands.SettingTypeScreenSize])
+	assert.Equal(t, "default", saved["dense"].Settings[commands.SettingTypeFontSize])
+
+	// The saved preset is listed next to the built-ins and applies what was saved
+	faker = util.NewGenericExecFaker()
+	faker.StubSingleDevice(cfg.GetADBPath())
+	faker.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "", "en-US")
+	output = util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteNestedCommand(cfg, "preset", []string{"list"})
+			require.NoError(t, err)
+			err = cli.ExecuteNestedCommand(cfg, "preset", []string{"apply", "dense"})
+		})
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "dense            screen-size=default, dpi=480, font-size=default, animations=default, dark-mode=default")
+	assert.Contains(t, output, "Saved from emulator-5554 on")
+	for _, expectedCmd := range []string{
+		"adb -s emulator-5554 shell wm size reset",
+		"adb -s emulator-5554 shell wm density 480",
+	} {
+		assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s", expectedCmd)
+	}
+}
+
+func writePresets(t *testing.T, cfg *config.Config, presets string) {
+	require.NoError(t, os.MkdirAll(cfg.ConfigDir, 0o755))
+	require.NoError(t, os.WriteFile(commands.PresetsPath(cfg), []byte(presets), 0o644))
+}
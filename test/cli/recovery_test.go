@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixDevices(t *testing.T) {
+	tests := []struct {
+		name             string
+		devices          string
+		expectedOutput   []string
+		expectedErr      error
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:           "all devices ready",
+			devices:        "List of devices attached\nemulator-5554\tdevice model:sdk_gphone64_x86_64 transport_id:1\n",
+			expectedOutput: []string{"All 1 devices are ready"},
+			unexpected:     []string{"adb reconnect offline", "adb kill-server"},
+		},
+		{
+			name:           "offline device gets reconnected, then the server restarted",
+			devices:        "List of devices attached\nemulator-5554\toffline transport_id:1\n",
+			expectedOutput: []string{"Reconnecting 1 offline devices", "restarting the adb server"},
+			expectedErr:    adb.ErrOffline,
+			expectedCommands: []string{
+				"adb reconnect offline",
+				"adb kill-server",
+				"adb start-server",
+			},
+		},
+		{
+			name:           "unauthorized device is asked again",
+			devices:        "List of devices attached\nR58M123ABC\tunauthorized usb:1-1 transport_id:3\n",
+			expectedOutput: []string{"Accept the \"Allow USB debugging?\" prompt on: R58M123ABC"},
+			expectedErr:    adb.ErrUnauthorized,
+			expectedCommands: []string{
+				"adb -s R58M123ABC reconnect",
+			},
+			unexpected: []string{"adb kill-server"},
+		},
+	}
+
+	options := commands.FixOptions{AuthorizationTimeout: 50 * time.Millisecond, PollInterval: 10 * time.Millisecond}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			faker.StubADBDevicesCommand(cfg.GetADBPath(), tt.devices)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = commands.FixDevices(context.Background(), cfg, options)
+				})
+			})
+
+			if tt.expectedErr != nil {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestUnhealthyDeviceIsListedWithHint(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubADBDevicesCommand(cfg.GetADBPath(), "List of devices attached\nR58M123ABC\tunauthorized usb:1-1 transport_id:3\n")
+
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			require.NoError(t, cli.ExecuteNestedCommand(cfg, "devices", nil))
+		})
+	})
+
+	assert.Contains(t, output, "Unauthorized: accept the \"Allow USB debugging?\" prompt on the device")
+	assert.Contains(t, output, "gadget devices --fix")
+}
+
+func TestRegenerateADBKeys(t *testing.T) {
+	tests := []struct {
+		name             string
+		remoteHost       string
+		runs             int
+		expectedError    string
+		expectedBackups  int
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:             "keys moved aside, keeping an older backup",
+			runs:             1,
+			expectedBackups:  1,
+			expectedCommands: []string{"adb kill-server", "adb start-server"},
+		},
+		{
+			name:            "running twice keeps both backups",
+			runs:            2,
+			expectedBackups: 2,
+		},
+		{
+			name:          "remote server refused",
+			remoteHost:    "10.0.0.2",
+			runs:          1,
+			expectedError: "uses the keys of its own machine",
+			unexpected:    []string{"kill-server", "start-server"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyDir := t.TempDir()
+			t.Setenv("ANDROID_USER_HOME", keyDir)
+			require.NoError(t, os.WriteFile(filepath.Join(keyDir, "adbkey.bak"), []byte("older"), 0o600))
+			adb.UseServer(tt.remoteHost, 0)
+			defer adb.UseServer("", 0)
+
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			var err error
+			for run := range tt.runs {
+				// adb start-server creates the new pair
+				require.NoError(t, os.WriteFile(filepath.Join(keyDir, "adbkey"), []byte(fmt.Sprintf("key %d", run)), 0o600))
+				require.NoError(t, os.WriteFile(filepath.Join(keyDir, "adbkey.pub"), []byte(fmt.Sprintf("public key %d", run)), 0o600))
+				util.CaptureLogOutput(func() {
+					util.WithFakeExec(faker, func() {
+						err = cli.ExecuteNestedCommand(cfg, "devices", []string{"regenerate-keys"})
+					})
+				})
+			}
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			older, err := os.ReadFile(filepath.Join(keyDir, "adbkey.bak"))
+			require.NoError(t, err)
+			assert.Equal(t, "older", string(older), "existing backups are never overwritten")
+
+			backups, err := filepath.Glob(filepath.Join(keyDir, "adbkey.bak-*"))
+			require.NoError(t, err)
+			assert.Len(t, backups, tt.expectedBackups)
+			publicBackups, err := filepath.Glob(filepath.Join(keyDir, "adbkey.pub.bak-*"))
+			require.NoError(t, err)
+			assert.Len(t, publicBackups, tt.expectedBackups)
+
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+// executedCommand reports whether a command matching pattern ran
+func executedCommand(faker *util.GenericExecFaker, pattern string) bool {
+	for _, executed := range faker.GetExecutedCommands() {
+		if util.MatchesCommandPattern(executed, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResetCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		deviceSerial     string
+		value            string
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "reset DPI",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			value:            "dpi",
+			expectedOutput:   []string{"DPI reset to physical density on device emulator-5554"},
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density reset"},
+			unexpected:       []string{"wm size reset", "settings delete system font_scale"},
+		},
+		{
+			name: "reset everything by default",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubLocaleGet(cfg.GetADBPath(), "emulator-5554", "", "en-US")
+			},
+			expectedOutput: []string{"Font size reset to default on device emulator-5554", "Locale is already the default en-US on device emulator-5554"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size reset",
+				"adb -s emulator-5554 shell wm density reset",
+				"adb -s emulator-5554 shell settings delete system font_scale",
+			},
+			unexpected: []string{"setprop persist.sys.locale", "stop && start"},
+		},
+		{
+			name: "reset screen size on a selected device",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubMultipleDevices(cfg.GetADBPath())
+			},
+			deviceSerial:     "192.168.1.100:5555",
+			value:            "screen-size",
+			expectedCommands: []string{"adb -s 192.168.1.100:5555 shell wm size reset"},
+			unexpected:       []string{"emulator-5554 shell wm size reset"},
+		},
+		{
+			name: "reset fails",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+				f.StubADBShellCommand(cfg.GetADBPath(), "emulator-5554", []string{"wm", "density", "reset"}, "", "Error: permission denied", 1)
+			},
+			value:         "dpi",
+			expectedError: "failed to reset DPI",
+		},
+		{
+			name:          "unknown setting",
+			setupStubs:    func(f *util.GenericExecFaker, cfg *config.Config) {},
+			value:         "brightness",
+			expectedError: "unknown setting to reset: brightness",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, "reset", tt.deviceSerial, "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package test
+
+import (
+	"context"
+	"gadget/internal/adb"
+	adbutil "gadget/test/adb/util"
+	"gadget/test/cli/util"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseServer(t *testing.T) {
+	tests := []struct {
+		name            string
+		host            string
+		port            int
+		expectedAddress string
+		expectedArgs    []string
+	}{
+		{
+			name:            "defaults",
+			expectedAddress: "127.0.0.1:5037",
+		},
+		{
+			name:            "remote host",
+			host:            "buildbox",
+			expectedAddress: "buildbox:5037",
+			expectedArgs:    []string{"-H", "buildbox"},
+		},
+		{
+			name:            "custom port",
+			port:            5038,
+			expectedAddress: "127.0.0.1:5038",
+			expectedArgs:    []string{"-P", "5038"},
+		},
+		{
+			name:            "remote host and port",
+			host:            "10.0.0.7",
+			port:            5038,
+			expectedAddress: "10.0.0.7:5038",
+			expectedArgs:    []string{"-H", "10.0.0.7", "-P", "5038"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adb.UseServer(tt.host, tt.port)
+			defer adb.UseServer("", 0)
+
+			assert.Equal(t, tt.expectedAddress, adb.ServerAddress())
+			assert.Equal(t, tt.expectedArgs, adb.ServerArgs())
+
+			// The adb binary gets the same server as the protocol client
+			faker := util.NewGenericExecFaker()
+			util.WithFakeExec(faker, func() {
+				require.NoError(t, adb.ExecuteCommand("/test/adb", "emulator-5554", "reconnect"))
+			})
+			executed := faker.GetExecutedCommands()
+			require.Len(t, executed, 1)
+			assert.Equal(t, append(tt.expectedArgs, "-s", "emulator-5554", "reconnect"), executed[0].Args)
+		})
+	}
+}
+
+func TestCheckServerVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientOutput  string
+		expectedError error
+	}{
+		{
+			name:         "matching versions",
+			clientOutput: "Android Debug Bridge version 1.0.41\nVersion 35.0.2-12147458\n",
+		},
+		{
+			name:          "mismatched versions",
+			clientOutput:  "Android Debug Bridge version 1.0.40\nVersion 28.0.2-debian\n",
+			expectedError: adb.ErrVersionMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := adbutil.NewFakeADBServer()
+			require.NoError(t, err)
+			defer server.Close()
+
+			host, portText, err := net.SplitHostPort(server.Address())
+			require.NoError(t, err)
+			port, err := strconv.Atoi(portText)
+			require.NoError(t, err)
+			adb.UseServer(host, port)
+			defer adb.UseServer("", 0)
+
+			faker := util.NewGenericExecFaker()
+			faker.AddStub("/test/adb", []string{"-P", portText, "version"}, tt.clientOutput, "", 0)
+
+			var client, serverVersion int
+			util.WithFakeExec(faker, func() {
+				client, serverVersion, err = adb.CheckServerVersion(context.Background(), "/test/adb")
+			})
+
+			assert.Equal(t, 41, serverVersion)
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				assert.Contains(t, err.Error(), "protocol version 41 but the adb binary is version 40")
+				assert.NotEmpty(t, adb.Hint(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 41, client)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"gadget/internal/adb"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const snapshotSerial = "emulator-5554"
+
+// stubDeviceSettings stubs what the device reports for every setting a snapshot captures
+func stubDeviceSettings(f *util.GenericExecFaker, adbPath, density, size, fontScale, nightMode string) {
+	f.StubSingleDevice(adbPath)
+	f.StubDPIGet(adbPath, snapshotSerial, density)
+	f.StubScreenSizeGet(adbPath, snapshotSerial, size)
+	f.StubFontSizeGet(adbPath, snapshotSerial, fontScale)
+	f.StubADBShellCommand(adbPath, snapshotSerial, []string{"cmd", "uimode", "night"}, "Night mode: "+nightMode, "", 0)
+	f.StubLocaleGet(adbPath, snapshotSerial, "", "en-US")
+}
+
+func TestSnapshotCommand(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	cfg.ConfigDir = t.TempDir()
+	stubDeviceSettings(faker, cfg.GetADBPath(),
+		"Physical density: 420\nOverride density: 480",
+		"Physical size: 1080x2400",
+		"1.15",
+		"yes")
+
+	var err error
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "snapshot", "", "", "", "")
+		})
+	})
+	require.NoError(t, err)
+
+	path := commands.SnapshotPath(cfg, adb.Device{Serial: snapshotSerial})
+	assert.Contains(t, output, "Saved snapshot of emulator-5554 to "+path)
+
+	snapshot, err := commands.LoadSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, snapshotSerial, snapshot.Serial)
+	assert.Equal(t, "sdk_gphone64_x86_64", snapshot.Model)
+	assert.Equal(t, map[commands.SettingType]commands.SnapshotSetting{
+		commands.SettingTypeDPI:              {Value: "480", Overridden: true},
+		commands.SettingTypeScreenSize:       {Value: "1080x2400", Overridden: false},
+		commands.SettingTypeFontSize:         {Value: "1.15", Overridden: true},
+		commands.SettingTypeAnimations:       {Value: "1.0", Overridden: false},
+		commands.SettingTypeNightMode:        {Value: "yes", Overridden: true},
+		commands.SettingTypeTalkBack:         {Value: "off", Overridden: false},
+		commands.SettingTypeHighContrastText: {Value: "off", Overridden: false},
+		commands.SettingTypeColorInversion:   {Value: "off", Overridden: false},
+		commands.SettingTypeColorCorrection:  {Value: "off", Overridden: false},
+		commands.SettingTypeOverlays:         {Value: "none", Overridden: false},
+		commands.SettingTypeLocale:           {Value: "en-US", Overridden: false},
+	}, snapshot.Settings)
+}
+
+func TestRestoreCommand(t *testing.T) {
+	saved := &commands.Snapshot{
+		Serial:  snapshotSerial,
+		TakenAt: time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC),
+		Settings: map[commands.SettingType]commands.SnapshotSetting{
+			commands.SettingTypeDPI:        {Value: "420", Overridden: false},
+			commands.SettingTypeScreenSize: {Value: "1080x2400", Overridden: false},
+			commands.SettingTypeFontSize:   {Value: "1.15", Overridden: true},
+		},
+		NightMode: "no", // Kept apart from Settings, as older versions did
+	}
+
+	tests := []struct {
+		name             string
+		density          string
+		size             string
+		fontScale        string
+		nightMode        string
+		noSnapshot       bool
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:           "changed settings are put back",
+			density:        "Physical density: 420\nOverride density: 320",
+			size:           "Physical size: 1080x2400",
+			fontScale:      "null",
+			nightMode:      "yes",
+			expectedOutput: []string{"Restored 3 settings on emulator-5554 from its snapshot from 2026-10-01 09:30:00"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm density reset",
+				"adb -s emulator-5554 shell settings put system font_scale 1.15",
+				"adb -s emulator-5554 shell cmd uimode night no",
+			},
+			unexpected: []string{"wm size reset"},
+		},
+		{
+			name:           "unchanged device is left alone",
+			density:        "Physical density: 420",
+			size:           "Physical size: 1080x2400",
+			fontScale:      "1.15",
+			nightMode:      "no",
+			expectedOutput: []string{"emulator-5554 already matches its snapshot"},
+			unexpected:     []string{"wm density reset", "settings put", "cmd uimode night no"},
+		},
+		{
+			name:          "missing snapshot",
+			density:       "Physical density: 420",
+			size:          "Physical size: 1080x2400",
+			fontScale:     "1.15",
+			nightMode:     "no",
+			noSnapshot:    true,
+			expectedError: "no snapshot of emulator-5554, take one with \"gadget snapshot\" first",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.ConfigDir = t.TempDir()
+			stubDeviceSettings(faker, cfg.GetADBPath(), tt.density, tt.size, tt.fontScale, tt.nightMode)
+			if !tt.noSnapshot {
+				require.NoError(t, commands.SaveSnapshot(commands.SnapshotPath(cfg, adb.Device{Serial: snapshotSerial}), saved))
+			}
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, "restore", "", "", "", "")
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
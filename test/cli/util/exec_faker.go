@@ -1,10 +1,12 @@
 package util
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
 )
 
 // CommandStub represents a stubbed command with its expected response
@@ -24,6 +26,7 @@ type ExecutionRecord struct {
 
 // GenericExecFaker provides generic exec.Command faking using the helper process pattern
 type GenericExecFaker struct {
+	mu               sync.Mutex // Commands may be created from several goroutines
 	stubs            []CommandStub
 	executedCommands []ExecutionRecord
 }
@@ -38,6 +41,8 @@ func NewGenericExecFaker() *GenericExecFaker {
 
 // AddStub adds a command stub for exact command and args matching
 func (f *GenericExecFaker) AddStub(command string, args []string, stdout, stderr string, exitCode int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.stubs = append(f.stubs, CommandStub{
 		Command:  command,
 		Args:     args,
@@ -49,11 +54,21 @@ func (f *GenericExecFaker) AddStub(command string, args []string, stdout, stderr
 
 // GetExecutedCommands returns all commands that were executed during the test
 func (f *GenericExecFaker) GetExecutedCommands() []ExecutionRecord {
-	return f.executedCommands
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ExecutionRecord(nil), f.executedCommands...)
 }
 
 // FakeExecCommand returns a fake exec.Cmd that will run our test helper
 func (f *GenericExecFaker) FakeExecCommand(command string, args ...string) *exec.Cmd {
+	return f.FakeExecCommandContext(context.Background(), command, args...)
+}
+
+// FakeExecCommandContext returns a fake exec.Cmd that is killed when ctx is done
+func (f *GenericExecFaker) FakeExecCommandContext(ctx context.Context, command string, args ...string) *exec.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	// Record this command execution
 	f.executedCommands = append(f.executedCommands, ExecutionRecord{
 		Command: command,
@@ -62,7 +77,7 @@ func (f *GenericExecFaker) FakeExecCommand(command string, args ...string) *exec
 
 	cs := []string{"-test.run=TestHelperProcess", "--", command}
 	cs = append(cs, args...)
-	cmd := exec.Command(os.Args[0], cs...)
+	cmd := exec.CommandContext(ctx, os.Args[0], cs...)
 
 	// Pass the fake data via environment variables
 	env := []string{"GO_TEST_HELPER_PROCESS=1"}
@@ -160,6 +175,23 @@ func (f *GenericExecFaker) StubFontSizeSet(adbPath, deviceSerial, scaleValue str
 	f.StubADBShellCommand(adbPath, deviceSerial, []string{"settings", "put", "system", "font_scale", scaleValue}, "", "", exitCode)
 }
 
+// StubLocaleGet stubs the API level and locale properties of a device with API 21 or later
+func (f *GenericExecFaker) StubLocaleGet(adbPath, deviceSerial, current, defaultLocale string) {
+	systemLocales := current
+	if systemLocales == "" {
+		systemLocales = "null"
+	}
+	f.StubADBShellCommand(adbPath, deviceSerial, []string{"getprop", "ro.build.version.sdk"}, "34", "", 0)
+	f.StubADBShellCommand(adbPath, deviceSerial, []string{"settings", "get", "system", "system_locales"}, systemLocales, "", 0)
+	f.StubADBShellCommand(adbPath, deviceSerial, []string{"getprop", "persist.sys.locale"}, current, "", 0)
+	f.StubADBShellCommand(adbPath, deviceSerial, []string{"getprop", "ro.product.locale"}, defaultLocale, "", 0)
+}
+
+// StubRoot stubs the device's shell as running as root, as after "adb root"
+func (f *GenericExecFaker) StubRoot(adbPath, deviceSerial string) {
+	f.StubADBShellCommand(adbPath, deviceSerial, []string{"id", "-u"}, "0", "", 0)
+}
+
 // Additional convenience methods for compatibility with legacy tests
 
 // StubMultipleDevices stubs adb devices to return multiple devices
@@ -196,3 +228,8 @@ func NewTestCommandExecutor(faker *GenericExecFaker) *TestCommandExecutor {
 func (t *TestCommandExecutor) Command(name string, arg ...string) *exec.Cmd {
 	return t.faker.FakeExecCommand(name, arg...)
 }
+
+// CommandContext implements the CommandExecutor interface
+func (t *TestCommandExecutor) CommandContext(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return t.faker.FakeExecCommandContext(ctx, name, arg...)
+}
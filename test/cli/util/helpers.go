@@ -1,8 +1,11 @@
 package util
 
 import (
+	"fmt"
 	"gadget/internal/adb"
 	"gadget/internal/config"
+	"os"
+	"path/filepath"
 )
 
 // WithFakeExec runs a function with a fake command executor
@@ -75,5 +78,7 @@ func TestConfig() *config.Config {
 	return &config.Config{
 		AndroidHome: "/test/android/sdk",
 		MediaPath:   "/test/media",
+		// Setting changes write history; keep it out of the real config directory
+		ConfigDir: filepath.Join(os.TempDir(), fmt.Sprintf("gadget-test-%d", os.Getpid())),
 	}
 }
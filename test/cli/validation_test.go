@@ -0,0 +1,162 @@
+package test
+
+import (
+	"errors"
+	"gadget/internal/cli"
+	"gadget/internal/commands"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		settingType   commands.SettingType
+		value         string
+		expectedError string
+		outOfRange    bool
+	}{
+		{name: "DPI bucket", settingType: commands.SettingTypeDPI, value: "480"},
+		{name: "DPI between buckets", settingType: commands.SettingTypeDPI, value: "420"},
+		{name: "DPI too low", settingType: commands.SettingTypeDPI, value: "10", expectedError: "DPI 10 must be within 120–640 dpi", outOfRange: true},
+		{name: "DPI too high", settingType: commands.SettingTypeDPI, value: "2000", expectedError: "DPI 2000 must be within 120–640 dpi", outOfRange: true},
+		{name: "DPI not a number", settingType: commands.SettingTypeDPI, value: "high", expectedError: "invalid DPI value: high"},
+		{name: "font size suggestion", settingType: commands.SettingTypeFontSize, value: "1.15"},
+		{name: "font size on a step", settingType: commands.SettingTypeFontSize, value: "0.9"},
+		{name: "font size between steps", settingType: commands.SettingTypeFontSize, value: "1.12", expectedError: "Font size 1.12 must be within 0.5–2 in steps of 0.05", outOfRange: true},
+		{name: "font size too large", settingType: commands.SettingTypeFontSize, value: "5", expectedError: "Font size 5 must be within", outOfRange: true},
+		{name: "font size not a number", settingType: commands.SettingTypeFontSize, value: "NaN", expectedError: "invalid font size value: NaN"},
+		{name: "screen size", settingType: commands.SettingTypeScreenSize, value: "1080x2400"},
+		{name: "screen size too small", settingType: commands.SettingTypeScreenSize, value: "10x10", expectedError: "Screen width 10 must be within 240–8192 px", outOfRange: true},
+		{name: "screen height too large", settingType: commands.SettingTypeScreenSize, value: "1080x10000", expectedError: "Screen height 10000 must be within", outOfRange: true},
+		{name: "screen size malformed", settingType: commands.SettingTypeScreenSize, value: "1080", expectedError: "invalid screen size format: 1080"},
+		{name: "animations off", settingType: commands.SettingTypeAnimations, value: "off"},
+		{name: "animation scales by name", settingType: commands.SettingTypeAnimations, value: "window=0.5,animator=off"},
+		{name: "animation scale between steps", settingType: commands.SettingTypeAnimations, value: "0.3", expectedError: "window animation scale 0.3 must be within 0–10 in steps of 0.5", outOfRange: true},
+		{name: "animation scale too large", settingType: commands.SettingTypeAnimations, value: "transition=20", expectedError: "transition animation scale 20 must be within", outOfRange: true},
+		{name: "negative animation scale", settingType: commands.SettingTypeAnimations, value: "-1", expectedError: "invalid animation scale: -1"},
+		{name: "locale with region", settingType: commands.SettingTypeLocale, value: "pt-BR"},
+		{name: "locale with script", settingType: commands.SettingTypeLocale, value: "zh-Hans-CN"},
+		{name: "pseudo-locale shortcut", settingType: commands.SettingTypeLocale, value: "rtl"},
+		{name: "locale not a tag", settingType: commands.SettingTypeLocale, value: "French", expectedError: "invalid locale: French"},
+		{name: "switch on", settingType: commands.SettingTypeTalkBack, value: "on"},
+		{name: "switch as a number", settingType: commands.SettingTypeColorInversion, value: "1", expectedError: "invalid value: 1 (expected on or off)"},
+		{name: "color correction mode", settingType: commands.SettingTypeColorCorrection, value: "deuteranomaly"},
+		{name: "unknown color correction", settingType: commands.SettingTypeColorCorrection, value: "sepia", expectedError: "invalid color correction: sepia"},
+		{name: "no overlays", settingType: commands.SettingTypeOverlays, value: "none"},
+		{name: "several overlays", settingType: commands.SettingTypeOverlays, value: "layout-bounds,taps"},
+		{name: "unknown overlay", settingType: commands.SettingTypeOverlays, value: "layout-bounds,grid", expectedError: "unknown debug overlay: grid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := commands.GetSettingHandler(tt.settingType).Validate(tt.value)
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+			assert.Equal(t, tt.outOfRange, errors.Is(err, commands.ErrValueOutOfRange))
+		})
+	}
+}
+
+func TestSettingSuggestionsAreAllowed(t *testing.T) {
+	for _, settingType := range commands.SettingTypes {
+		handler := commands.GetSettingHandler(settingType)
+		for _, suggestion := range handler.Metadata().Suggestions {
+			assert.NoError(t, handler.Validate(suggestion), "%s suggests %s", settingType, suggestion)
+		}
+	}
+}
+
+func TestSettingCommandOutOfRange(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          string
+		value            string
+		force            bool
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name:          "DPI is rejected",
+			command:       "dpi",
+			value:         "10",
+			expectedError: "DPI 10 must be within 120–640 dpi, use --force to apply it anyway",
+			unexpected:    []string{"adb devices", "wm density 10"},
+		},
+		{
+			name:             "DPI is forced",
+			command:          "dpi",
+			value:            "10",
+			force:            true,
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density 10"},
+		},
+		{
+			name:          "screen size is rejected",
+			command:       "screen-size",
+			value:         "10x10",
+			expectedError: "Screen width 10 must be within 240–8192 px",
+			unexpected:    []string{"wm size 10x10"},
+		},
+		{
+			name:             "font size is forced",
+			command:          "font-size",
+			value:            "3",
+			force:            true,
+			expectedCommands: []string{"adb -s emulator-5554 shell settings put system font_scale 3.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.Force = tt.force
+			faker.StubSingleDevice(cfg.GetADBPath())
+
+			var err error
+			util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteCommand(cfg, tt.command, "", "", "", tt.value)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				assert.Equal(t, cli.ExitValueOutOfRange, cli.ExitCode(err))
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
+
+func TestSettingCommandShowsAllowedValues(t *testing.T) {
+	faker := util.NewGenericExecFaker()
+	cfg := util.TestConfig()
+	faker.StubSingleDevice(cfg.GetADBPath())
+	faker.StubFontSizeGet(cfg.GetADBPath(), "emulator-5554", "1.15")
+
+	var err error
+	output := util.CaptureLogOutput(func() {
+		util.WithFakeExec(faker, func() {
+			err = cli.ExecuteCommand(cfg, "font-size", "", "", "", "")
+		})
+	})
+	require.NoError(t, err)
+	assert.Contains(t, output, "Allowed: 0.5–2 in steps of 0.05")
+	assert.Contains(t, output, "Suggested: 0.85, 1.0, 1.15, 1.3, 1.5, 1.8, 2.0")
+}
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"gadget/internal/cli"
+	"gadget/internal/config"
+	"gadget/test/cli/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubPhoneScreen(f *util.GenericExecFaker, adbPath, size, density string) {
+	f.StubSingleDevice(adbPath)
+	f.StubScreenSizeGet(adbPath, "emulator-5554", size)
+	f.StubDPIGet(adbPath, "emulator-5554", density)
+}
+
+func TestWindowClassCommand(t *testing.T) {
+	tests := []struct {
+		name             string
+		setupStubs       func(*util.GenericExecFaker, *config.Config)
+		args             []string
+		force            bool
+		expectedOutput   []string
+		expectedError    string
+		expectedCommands []string
+		unexpected       []string
+	}{
+		{
+			name: "show the current classes",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			expectedOutput: []string{"emulator-5554: 411x914 dp (compact width, expanded height) at 1080x2400, 420 dpi"},
+			unexpected:     []string{"wm size 1", "wm density 1", "wm density 2", "wm density 4"},
+		},
+		{
+			name: "show with overrides",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400\nOverride size: 1080x1080", "Physical density: 420\nOverride density: 240")
+			},
+			args:           []string{"emulator-5554"},
+			expectedOutput: []string{"720x720 dp (medium width, medium height)"},
+		},
+		{
+			name: "medium width by density alone",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			args:             []string{"medium"},
+			expectedOutput:   []string{"emulator-5554 is now 600x1333 dp (medium width, expanded height)"},
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density 288"},
+			unexpected:       []string{"wm size 1", "wm size reset"},
+		},
+		{
+			name: "expanded width by density alone",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			args:             []string{"expanded"},
+			expectedOutput:   []string{"842x1873 dp (expanded width, expanded height)"},
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density 205"},
+		},
+		{
+			name: "medium square window needs a resize",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			args:           []string{"medium", "medium", "emulator-5554"},
+			expectedOutput: []string{"720x720 dp (medium width, medium height)"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size 1080x1080",
+				"adb -s emulator-5554 shell wm density 240",
+			},
+		},
+		{
+			name: "compact both ways keeps the density",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400", "Physical density: 420")
+			},
+			args:             []string{"compact", "compact"},
+			expectedOutput:   []string{"360x400 dp (compact width, compact height)"},
+			expectedCommands: []string{"adb -s emulator-5554 shell wm size 945x1050"},
+			unexpected:       []string{"wm density 4", "wm density reset"},
+		},
+		{
+			name: "the physical screen clears overrides",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 1080x2400\nOverride size: 1080x1080", "Physical density: 420\nOverride density: 240")
+			},
+			args:           []string{"compact", "expanded"},
+			expectedOutput: []string{"411x914 dp (compact width, expanded height)"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size reset",
+				"adb -s emulator-5554 shell wm density reset",
+			},
+		},
+		{
+			name: "expanded width on a small screen is out of range",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 480x800", "Physical density: 240")
+			},
+			args:          []string{"expanded", "compact"},
+			expectedError: "use --force to apply it anyway",
+			unexpected:    []string{"wm size 4", "wm density 1"},
+		},
+		{
+			name: "out of range with force",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				stubPhoneScreen(f, cfg.GetADBPath(), "Physical size: 480x800", "Physical density: 240")
+			},
+			args:             []string{"expanded", "compact"},
+			force:            true,
+			expectedOutput:   []string{"(expanded width, compact height)"},
+			expectedCommands: []string{"adb -s emulator-5554 shell wm density 75"},
+		},
+		{
+			name: "reset",
+			setupStubs: func(f *util.GenericExecFaker, cfg *config.Config) {
+				f.StubSingleDevice(cfg.GetADBPath())
+			},
+			args: []string{"reset"},
+			expectedCommands: []string{
+				"adb -s emulator-5554 shell wm size reset",
+				"adb -s emulator-5554 shell wm density reset",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faker := util.NewGenericExecFaker()
+			cfg := util.TestConfig()
+			cfg.Force = tt.force
+			tt.setupStubs(faker, cfg)
+
+			var err error
+			output := util.CaptureLogOutput(func() {
+				util.WithFakeExec(faker, func() {
+					err = cli.ExecuteNestedCommand(cfg, "window-class", tt.args)
+				})
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output, expected)
+			}
+			executed := util.FormatExecutedCommands(faker.GetExecutedCommands())
+			for _, expectedCmd := range tt.expectedCommands {
+				assert.True(t, executedCommand(faker, expectedCmd), "Expected command not executed: %s\nActual commands: %v", expectedCmd, executed)
+			}
+			for _, unexpectedCmd := range tt.unexpected {
+				assert.False(t, executedCommand(faker, unexpectedCmd), "Unexpected command executed: %s", unexpectedCmd)
+			}
+		})
+	}
+}
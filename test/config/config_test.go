@@ -0,0 +1,41 @@
+package test
+
+import (
+	"gadget/internal/config"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseADBServerSocket(t *testing.T) {
+	tests := []struct {
+		name         string
+		socket       string
+		expectedHost string
+		expectedPort int
+	}{
+		{name: "unset", socket: ""},
+		{name: "host and port", socket: "tcp:buildbox:5037", expectedHost: "buildbox", expectedPort: 5037},
+		{name: "port only", socket: "tcp:5038", expectedPort: 5038},
+		{name: "IPv6 host", socket: "tcp:[::1]:5037", expectedHost: "::1", expectedPort: 5037},
+		{name: "unsupported scheme", socket: "localfilesystem:/tmp/adb"},
+		{name: "invalid port", socket: "tcp:buildbox:adb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port := config.ParseADBServerSocket(tt.socket)
+			assert.Equal(t, tt.expectedHost, host)
+			assert.Equal(t, tt.expectedPort, port)
+		})
+	}
+}
+
+func TestNewConfigReadsADBServerEnvironment(t *testing.T) {
+	t.Setenv("ADB_SERVER_SOCKET", "tcp:buildbox:5037")
+	t.Setenv("ANDROID_ADB_SERVER_PORT", "5039")
+
+	cfg := config.NewConfig()
+	assert.Equal(t, "buildbox", cfg.ADBServerHost)
+	assert.Equal(t, 5039, cfg.ADBServerPort, "ANDROID_ADB_SERVER_PORT overrides the socket's port")
+}